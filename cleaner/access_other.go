@@ -0,0 +1,12 @@
+//go:build !unix
+
+package cleaner
+
+import "os"
+
+// writable reports whether dir carries a write permission bit; this platform
+// has no access(2), so ACLs are not considered
+func writable(dir string) bool {
+	info, err := os.Stat(dir)
+	return err == nil && info.Mode().Perm()&0200 != 0
+}
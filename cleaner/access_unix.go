@@ -0,0 +1,10 @@
+//go:build unix
+
+package cleaner
+
+import "golang.org/x/sys/unix"
+
+// writable reports whether the current user may create and remove entries in dir
+func writable(dir string) bool {
+	return unix.Access(dir, unix.W_OK|unix.X_OK) == nil
+}
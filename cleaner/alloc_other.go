@@ -0,0 +1,23 @@
+//go:build !unix
+
+package cleaner
+
+import "io/fs"
+
+// allocatedSize falls back to the apparent size where block counts are not
+// available
+func allocatedSize(info fs.FileInfo) int64 {
+	return info.Size()
+}
+
+// deviceID is not available here; callers treat every path as its own
+// filesystem
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// fileID is not available here; walks cannot recognize a directory reached
+// twice
+func fileID(info fs.FileInfo) (fileKey, bool) {
+	return fileKey{}, false
+}
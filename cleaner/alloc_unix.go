@@ -0,0 +1,34 @@
+//go:build unix
+
+package cleaner
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// allocatedSize returns the disk space a file occupies, which is smaller than
+// its apparent size for sparse files; it falls back to the apparent size
+func allocatedSize(info fs.FileInfo) int64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return int64(st.Blocks) * 512
+	}
+	return info.Size()
+}
+
+// deviceID returns the ID of the filesystem a file is on
+func deviceID(info fs.FileInfo) (uint64, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(st.Dev), true
+	}
+	return 0, false
+}
+
+// fileID returns the device and inode of a file, which identify it however it
+// is reached
+func fileID(info fs.FileInfo) (fileKey, bool) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return fileKey{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+	}
+	return fileKey{}, false
+}
@@ -0,0 +1,104 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// TypeStat aggregates the files of one extension
+type TypeStat struct {
+	Extension string `json:"extension"`
+	Size      int64  `json:"size"`
+	Count     int    `json:"count"`
+}
+
+// TypeReport is the file-type breakdown of a directory
+type TypeReport struct {
+	Directory string     `json:"directory"`
+	TotalSize int64      `json:"total_size"`
+	Files     int        `json:"files"`
+	Types     []TypeStat `json:"types"`
+}
+
+// AnalyzeTypes aggregates size and count per file extension across the whole
+// directory tree and reports the extensions taking the most space
+func (sc *SystemCleaner) AnalyzeTypes(directory string) error {
+	var stop chan bool
+	if !sc.jsonOutput {
+		fmt.Fprintln(sc.msg, "\n🧮 Analyzing file types in:", directory)
+		stop = sc.startLoading("Grouping files by extension...")
+	}
+
+	stats := make(map[string]*TypeStat)
+	report := TypeReport{Directory: directory}
+	err := sc.walk(directory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			sc.logger.Printf("Error accessing path %s: %v", path, err)
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			sc.logger.Printf("Error accessing path %s: %v", path, err)
+			return nil
+		}
+
+		ext := strings.ToLower(filepath.Ext(path))
+		if ext == "" {
+			ext = "(none)"
+		}
+		stat, ok := stats[ext]
+		if !ok {
+			stat = &TypeStat{Extension: ext}
+			stats[ext] = stat
+		}
+		stat.Size += info.Size()
+		stat.Count++
+		report.TotalSize += info.Size()
+		report.Files++
+		return nil
+	})
+
+	if stop != nil {
+		stop <- true
+		<-stop
+	}
+
+	if err != nil {
+		return &ScanError{Path: directory, Err: err}
+	}
+
+	for _, stat := range stats {
+		report.Types = append(report.Types, *stat)
+	}
+	sort.Slice(report.Types, func(i, j int) bool {
+		return report.Types[i].Size > report.Types[j].Size
+	})
+
+	if sc.jsonOutput {
+		encoder := json.NewEncoder(sc.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	fmt.Fprintf(sc.out, "\n📊 Top %d file types by size (%d files, %s total):\n",
+		sc.config.TopFiles, report.Files, sc.FormatSize(report.TotalSize))
+	for i, stat := range report.Types {
+		if i >= sc.config.TopFiles {
+			break
+		}
+		var share float64
+		if report.TotalSize > 0 {
+			share = float64(stat.Size) / float64(report.TotalSize) * 100
+		}
+		fmt.Fprintf(sc.out, "📄 %-10s %5.1f%%  %s in %d files\n", stat.Extension, share, sc.FormatSize(stat.Size), stat.Count)
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+package cleaner
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+// junkArchive streams files into a timestamped .tar.gz before they are deleted.
+// It is safe for concurrent use by the clean workers.
+type junkArchive struct {
+	mu      sync.Mutex
+	path    string
+	dir     string
+	reserve int64 // min_free_reserve of the archive's filesystem
+	file    *os.File
+	gz      *gzip.Writer
+	tw      *tar.Writer
+}
+
+// archivePath is the name of an archive created in dir at t
+func archivePath(dir string, t time.Time) string {
+	return filepath.Join(dir, "junk-"+t.Format("20060102-150405")+".tar.gz")
+}
+
+// archiveEntry is the name a file is stored under in the archive
+func archiveEntry(path string) string {
+	return strings.TrimPrefix(filepath.ToSlash(path), "/")
+}
+
+// newJunkArchive creates a new archive file inside dir, refusing to when dir
+// already has no more than reserve bytes free
+func newJunkArchive(dir string, reserve int64) (*junkArchive, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := checkReserve(dir, reserve, 0); err != nil {
+		return nil, err
+	}
+
+	path := archivePath(dir, time.Now())
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	gz := gzip.NewWriter(file)
+	return &junkArchive{path: path, dir: dir, reserve: reserve, file: file, gz: gz, tw: tar.NewWriter(gz)}, nil
+}
+
+// add writes one file (or symlink) into the archive. A regular file is refused
+// with ErrReserve if its uncompressed size would eat into the free-space
+// reserve, so the caller keeps the original. The entry is flushed and synced
+// to disk before add returns, so the original can be deleted right away: a
+// crash or a failing close cannot lose it.
+func (a *junkArchive) add(path string, info fs.FileInfo) error {
+	var link string
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		link = target
+	}
+
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = archiveEntry(path)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !info.Mode().IsRegular() {
+		if err := a.tw.WriteHeader(header); err != nil {
+			return err
+		}
+		return a.flush()
+	}
+	if err := checkReserve(a.dir, a.reserve, info.Size()); err != nil {
+		return err
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := io.Copy(a.tw, src); err != nil {
+		return err
+	}
+	return a.flush()
+}
+
+// flush pushes the entries written so far through the tar and gzip writers
+// and syncs them to disk; the caller holds a.mu
+func (a *junkArchive) flush() error {
+	if err := a.tw.Flush(); err != nil {
+		return err
+	}
+	if err := a.gz.Flush(); err != nil {
+		return err
+	}
+	return a.file.Sync()
+}
+
+// close finishes the archive and returns its size on disk
+func (a *junkArchive) close() (int64, error) {
+	if err := a.tw.Close(); err != nil {
+		a.file.Close()
+		return 0, err
+	}
+	if err := a.gz.Close(); err != nil {
+		a.file.Close()
+		return 0, err
+	}
+	if err := a.file.Close(); err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(a.path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat archive: %w", err)
+	}
+	return info.Size(), nil
+}
+
+// closeArchive finishes an archive and reports where the junk went
+func (sc *SystemCleaner) closeArchive(archive *junkArchive) (int64, error) {
+	size, err := archive.close()
+	if err != nil {
+		return 0, &CleanError{Path: archive.path, Err: err}
+	}
+	fmt.Fprintf(sc.out, "📦 Archived junk to %s (%s)\n", archive.path, sc.FormatSize(size))
+	return size, nil
+}
+
+// archivePreview is the dry-run stand-in for a junkArchive: it names the
+// archive and entries a real clean would write and adds up their size
+type archivePreview struct {
+	mu    sync.Mutex
+	path  string
+	dir   string
+	bytes int64 // uncompressed size of the regular files
+	files int
+}
+
+func newArchivePreview(dir string) *archivePreview {
+	return &archivePreview{path: archivePath(dir, time.Now()), dir: dir}
+}
+
+// add counts a file and returns its entry name
+func (a *archivePreview) add(info fs.FileInfo, path string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.files++
+	if info.Mode().IsRegular() {
+		a.bytes += info.Size()
+	}
+	return archiveEntry(path)
+}
+
+// existingDir returns dir or, when it does not exist yet, the nearest parent
+// that does, which is where the archive's space would come from
+func existingDir(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// reportArchivePreview sums up what a real clean would archive and warns if
+// archive_dir is unusable or too small
+func (sc *SystemCleaner) reportArchivePreview(a *archivePreview) {
+	fmt.Fprintf(sc.out, "🧪 Would archive %d files (%s uncompressed) to %s\n", a.files, sc.FormatSize(a.bytes), a.path)
+
+	dir := existingDir(a.dir)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		fmt.Fprintf(sc.msg, "⚠️  archive_dir %s cannot be created: %s is not a directory\n", a.dir, dir)
+		return
+	}
+	if !writable(dir) {
+		fmt.Fprintf(sc.msg, "⚠️  archive_dir %s cannot be written: %s is not writable\n", a.dir, dir)
+		return
+	}
+	usage, err := disk.Usage(dir)
+	if err != nil {
+		sc.logger.Printf("Error checking free space of %s: %v", dir, err)
+		return
+	}
+	free := int64(usage.Free)
+	fmt.Fprintf(sc.out, "🧪 Archive disk has %s free", sc.FormatSize(free))
+	if sc.config.MinFreeReserve > 0 {
+		fmt.Fprintf(sc.out, ", min_free_reserve is %s", sc.FormatSize(sc.config.MinFreeReserve))
+	}
+	fmt.Fprintln(sc.out)
+	// the uncompressed size is an upper bound on what the archive takes
+	switch {
+	case sc.config.MinFreeReserve > 0 && free <= sc.config.MinFreeReserve:
+		fmt.Fprintln(sc.msg, "⚠️  The archive disk is already at min_free_reserve, a real clean would not start")
+	case free-a.bytes < 0:
+		fmt.Fprintf(sc.msg, "⚠️  The archive may not fit: it could take up to %s\n", sc.FormatSize(a.bytes))
+	case free-a.bytes < sc.config.MinFreeReserve:
+		fmt.Fprintf(sc.msg, "⚠️  The archive may not fit: up to %s would leave %s free, below min_free_reserve; such files would be kept\n",
+			sc.FormatSize(a.bytes), sc.FormatSize(free-a.bytes))
+	}
+}
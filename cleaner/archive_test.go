@@ -0,0 +1,163 @@
+package cleaner
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// archiveEntries reads the names and contents of the entries of a .tar.gz,
+// up to the first unreadable one
+func archiveEntries(t *testing.T, path string) map[string]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			return entries
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatal(err)
+		}
+		entries[header.Name] = string(data)
+	}
+}
+
+// TestJunkArchiveAddIsDurable checks that an entry can be read back before
+// the archive is closed, so deleting the original right after add is safe
+func TestJunkArchiveAddIsDurable(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"one file", map[string]string{"a.tmp": "junk"}},
+		{"several files", map[string]string{"a.tmp": "junk", "b.log": "more junk", "c": ""}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := t.TempDir()
+			archive, err := newJunkArchive(t.TempDir(), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer archive.close()
+
+			want := make(map[string]string)
+			for name, data := range tt.files {
+				path := filepath.Join(src, name)
+				if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+					t.Fatal(err)
+				}
+				info, err := os.Lstat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := archive.add(path, info); err != nil {
+					t.Fatal(err)
+				}
+				want[archiveEntry(path)] = data
+			}
+
+			got := archiveEntries(t, archive.path)
+			for name, data := range want {
+				if got[name] != data {
+					t.Errorf("entry %s holds %q before close, want %q", name, got[name], data)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanToFreeTargetArchives(t *testing.T) {
+	root := t.TempDir()
+	files := makeTree(t, root, 2, 5)
+	archiveDir := t.TempDir()
+	sc := newTestCleaner(t, cleanConfig([]string{root}, 1, 1)+"archive_before_delete: true\narchive_dir: "+archiveDir+"\n")
+
+	// a target above 100% is never met, so every candidate is archived and deleted
+	if err := sc.CleanToFreeTarget(root, 101); err != nil {
+		t.Fatal(err)
+	}
+	archives, _ := filepath.Glob(filepath.Join(archiveDir, "junk-*.tar.gz"))
+	if len(archives) != 1 {
+		t.Fatalf("found archives %v, want one", archives)
+	}
+	entries := archiveEntries(t, archives[0])
+	var missing []string
+	for path := range files {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was not deleted: %v", path, err)
+		}
+		if _, ok := entries[archiveEntry(path)]; !ok {
+			missing = append(missing, path)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("deleted without archiving: %v", missing)
+	}
+}
+
+func TestCleanToFreeTargetRules(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{"a.log": "archive", "b.pdf": "keep", "c.big": "report", "d.tmp": "delete"}
+	for name := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("junk"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rules := filepath.Join(t.TempDir(), "rules.yaml")
+	err := os.WriteFile(rules, []byte(`rules:
+  - name: logs
+    match: {ext: [.log]}
+    action: archive
+  - name: documents
+    match: {ext: [.pdf]}
+    action: keep
+  - name: blobs
+    match: {ext: [.big]}
+    action: report
+  - name: rest
+    action: delete
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archiveDir := t.TempDir()
+	sc := newTestCleaner(t, cleanConfig([]string{root}, 1, 1)+"rules_file: "+rules+"\narchive_dir: "+archiveDir+"\n")
+
+	if err := sc.CleanToFreeTarget(root, 101); err != nil {
+		t.Fatal(err)
+	}
+	archives, _ := filepath.Glob(filepath.Join(archiveDir, "junk-*.tar.gz"))
+	if len(archives) != 1 {
+		t.Fatalf("found archives %v, want one", archives)
+	}
+	entries := archiveEntries(t, archives[0])
+	for name, action := range files {
+		path := filepath.Join(root, name)
+		_, err := os.Lstat(path)
+		if gone := os.IsNotExist(err); gone != (action == "archive" || action == "delete") {
+			t.Errorf("%s (%s): deleted %v", name, action, gone)
+		}
+		if _, ok := entries[archiveEntry(path)]; ok != (action == "archive") {
+			t.Errorf("%s (%s): archived %v", name, action, ok)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package cleaner
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// asciiLabels replaces the symbols that carry meaning; any other emoji
+// becomes a plain "*"
+var asciiLabels = strings.NewReplacer(
+	"❌", "[ERROR]",
+	"⚠️", "[WARN]",
+	"✅", "[OK]",
+	"ℹ️", "[INFO]",
+	"🧪", "[DRY RUN]",
+	"🚨", "!!",
+	"🌐", "[net]",
+	"→", "->",
+	"…", "...",
+	"█", "#",
+	"·", "-",
+	"➕", "+",
+	"➖", "-",
+)
+
+// spinnerFrames are the loading animations selectable with the spinner option
+var spinnerFrames = map[string][]string{
+	"braille": {"⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+	"ascii":   {"|", "/", "-", "\\"},
+	"dots":    {".  ", ".. ", "..."},
+	"none":    nil,
+}
+
+// toASCII rewrites console output for terminals without Unicode
+func toASCII(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r <= unicode.MaxASCII:
+			return r
+		case r == '\uFE0F' || r == '\u200D': // emoji presentation and joiners
+			return -1
+		case r >= 0x1F000 || (r >= 0x2190 && r <= 0x2BFF):
+			return '*'
+		}
+		return r
+	}, asciiLabels.Replace(s))
+}
+
+// asciiWriter passes output through toASCII
+type asciiWriter struct {
+	w io.Writer
+}
+
+func (a asciiWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(a.w, toASCII(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// ASCIIWriter returns a writer that replaces emoji and other symbols written
+// to w with plain-text labels
+func ASCIIWriter(w io.Writer) io.Writer {
+	return asciiWriter{w}
+}
+
+// UnicodeTerminal guesses from the locale whether the terminal can show
+// Unicode. On Unix an unset locale means "C", which is ASCII; Windows does not
+// use the locale variables, so it is assumed to cope.
+func UnicodeTerminal() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			value = strings.ToLower(value)
+			return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+		}
+	}
+	return runtime.GOOS == "windows"
+}
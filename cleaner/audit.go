@@ -0,0 +1,63 @@
+package cleaner
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// auditRecord is one line of the audit log
+type auditRecord struct {
+	TS        time.Time `json:"ts"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	SessionID string    `json:"session_id"`
+}
+
+// auditLog appends a JSON record for every deleted file. Records are written
+// straight to the file, unbuffered, so a crash loses none of them.
+type auditLog struct {
+	mu      sync.Mutex
+	file    *os.File
+	session string
+}
+
+// newSessionID returns a random identifier for one run of the cleaner
+func newSessionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// openAuditLog opens the audit log for appending
+func openAuditLog(path, session string) (*auditLog, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLog{file: file, session: session}, nil
+}
+
+// record appends the deletion of one file
+func (a *auditLog) record(path string, size int64) error {
+	line, err := json.Marshal(auditRecord{TS: time.Now().UTC(), Path: path, Size: size, SessionID: a.session})
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.file.Write(append(line, '\n'))
+	return err
+}
+
+// close syncs the audit log to disk and closes it
+func (a *auditLog) close() error {
+	if err := a.file.Sync(); err != nil {
+		a.file.Close()
+		return err
+	}
+	return a.file.Close()
+}
@@ -0,0 +1,90 @@
+package cleaner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/mem"
+)
+
+// validAutoOptimize checks the auto_optimize settings and fills in defaults
+func (c *Config) validAutoOptimize() error {
+	if c.AutoOptimizeThreshold < 0 || c.AutoOptimizeThreshold > 100 {
+		return fmt.Errorf("auto_optimize_threshold must be between 0 and 100, got %g", c.AutoOptimizeThreshold)
+	}
+	if c.AutoOptimizeSustain < 0 || c.AutoOptimizeCooldown < 0 {
+		return fmt.Errorf("auto_optimize_sustain and auto_optimize_cooldown must not be negative")
+	}
+	if c.AutoOptimizeSustain == 0 {
+		c.AutoOptimizeSustain = 30 * time.Second
+	}
+	if c.AutoOptimizeCooldown == 0 {
+		c.AutoOptimizeCooldown = 15 * time.Minute
+	}
+	return nil
+}
+
+// autoOptimizer decides when the system monitor optimizes memory on its own.
+// A spike has to outlast sustain before it counts, and after a trigger the
+// optimizer stays quiet for cooldown so caches are not dropped over and over.
+type autoOptimizer struct {
+	threshold float64
+	sustain   time.Duration
+	cooldown  time.Duration
+	since     time.Time // start of the current streak above threshold
+	last      time.Time // last trigger
+}
+
+// newAutoOptimizer returns nil when auto_optimize_threshold is off
+func (c *Config) newAutoOptimizer() *autoOptimizer {
+	if c.AutoOptimizeThreshold <= 0 {
+		return nil
+	}
+	return &autoOptimizer{threshold: c.AutoOptimizeThreshold, sustain: c.AutoOptimizeSustain, cooldown: c.AutoOptimizeCooldown}
+}
+
+// due records a memory reading and reports whether to optimize now
+func (a *autoOptimizer) due(percent float64, now time.Time) bool {
+	if a == nil {
+		return false
+	}
+	if percent < a.threshold {
+		a.since = time.Time{}
+		return false
+	}
+	if a.since.IsZero() {
+		a.since = now
+	}
+	if now.Sub(a.since) < a.sustain || (!a.last.IsZero() && now.Sub(a.last) < a.cooldown) {
+		return false
+	}
+	a.last = now
+	a.since = time.Time{}
+	return true
+}
+
+// autoOptimize optimizes memory for the system monitor without prompting,
+// logging the memory use before and after
+func (sc *SystemCleaner) autoOptimize(before *mem.VirtualMemoryStat) {
+	sc.logger.Printf("Auto-optimizing memory: RAM %.1f%% used (%s of %s), above auto_optimize_threshold %g%% for %s",
+		before.UsedPercent, sc.FormatSize(int64(before.Used)), sc.FormatSize(int64(before.Total)),
+		sc.config.AutoOptimizeThreshold, sc.config.AutoOptimizeSustain)
+	if sc.dryRun {
+		fmt.Fprintf(sc.msg, "\n🧪 Dry run: RAM at %.1f%%, memory would be optimized\n", before.UsedPercent)
+		return
+	}
+
+	if _, err := dropCaches(true); err != nil {
+		sc.logger.Printf("Auto-optimizing memory failed: %v", err)
+		fmt.Fprintf(sc.msg, "\n❌ Automatic memory optimization failed: %v\n", err)
+		return
+	}
+	after, err := mem.VirtualMemory()
+	if err != nil {
+		sc.logger.Printf("Error getting memory info: %v", err)
+		return
+	}
+	sc.logger.Printf("Auto-optimized memory: RAM %.1f%% used (%s) before, %.1f%% used (%s) after",
+		before.UsedPercent, sc.FormatSize(int64(before.Used)), after.UsedPercent, sc.FormatSize(int64(after.Used)))
+	fmt.Fprintf(sc.msg, "\n♻️  RAM was at %.1f%%, optimized memory automatically: now %.1f%%\n", before.UsedPercent, after.UsedPercent)
+}
@@ -0,0 +1,167 @@
+package cleaner
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// browserCachePattern is a glob of cache directories of one browser; * stands
+// for the profile, e.g. Default or Profile 1
+type browserCachePattern struct {
+	browser string
+	glob    string
+}
+
+// browserCachePatterns lists, per GOOS, only the directories that hold
+// re-downloadable data: HTTP, code and GPU shader caches. Profile
+// directories themselves, with cookies, history, passwords and site storage,
+// are never matched.
+var browserCachePatterns = map[string][]browserCachePattern{
+	"darwin": {
+		{"Chrome", "~/Library/Caches/Google/Chrome/*/Cache"},
+		{"Chrome", "~/Library/Caches/Google/Chrome/*/Code Cache"},
+		{"Chrome", "~/Library/Application Support/Google/Chrome/*/GPUCache"},
+		{"Chromium", "~/Library/Caches/Chromium/*/Cache"},
+		{"Chromium", "~/Library/Caches/Chromium/*/Code Cache"},
+		{"Chromium", "~/Library/Application Support/Chromium/*/GPUCache"},
+		{"Edge", "~/Library/Caches/Microsoft Edge/*/Cache"},
+		{"Edge", "~/Library/Caches/Microsoft Edge/*/Code Cache"},
+		{"Edge", "~/Library/Application Support/Microsoft Edge/*/GPUCache"},
+		{"Firefox", "~/Library/Caches/Firefox/Profiles/*/cache2"},
+	},
+	"linux": {
+		{"Chrome", "~/.cache/google-chrome/*/Cache"},
+		{"Chrome", "~/.cache/google-chrome/*/Code Cache"},
+		{"Chrome", "~/.config/google-chrome/*/GPUCache"},
+		{"Chromium", "~/.cache/chromium/*/Cache"},
+		{"Chromium", "~/.cache/chromium/*/Code Cache"},
+		{"Chromium", "~/.config/chromium/*/GPUCache"},
+		{"Chromium", "~/snap/chromium/common/.cache/chromium/*/Cache"},
+		{"Chromium", "~/snap/chromium/common/.cache/chromium/*/Code Cache"},
+		{"Edge", "~/.cache/microsoft-edge/*/Cache"},
+		{"Edge", "~/.cache/microsoft-edge/*/Code Cache"},
+		{"Edge", "~/.config/microsoft-edge/*/GPUCache"},
+		{"Firefox", "~/.cache/mozilla/firefox/*/cache2"},
+		{"Firefox", "~/snap/firefox/common/.cache/mozilla/firefox/*/cache2"},
+	},
+	"freebsd": {
+		{"Chromium", "~/.cache/chromium/*/Cache"},
+		{"Chromium", "~/.cache/chromium/*/Code Cache"},
+		{"Chromium", "~/.config/chromium/*/GPUCache"},
+		{"Firefox", "~/.cache/mozilla/firefox/*/cache2"},
+	},
+	"windows": {
+		{"Chrome", "${LOCALAPPDATA}/Google/Chrome/User Data/*/Cache"},
+		{"Chrome", "${LOCALAPPDATA}/Google/Chrome/User Data/*/Code Cache"},
+		{"Chrome", "${LOCALAPPDATA}/Google/Chrome/User Data/*/GPUCache"},
+		{"Chromium", "${LOCALAPPDATA}/Chromium/User Data/*/Cache"},
+		{"Chromium", "${LOCALAPPDATA}/Chromium/User Data/*/Code Cache"},
+		{"Chromium", "${LOCALAPPDATA}/Chromium/User Data/*/GPUCache"},
+		{"Edge", "${LOCALAPPDATA}/Microsoft/Edge/User Data/*/Cache"},
+		{"Edge", "${LOCALAPPDATA}/Microsoft/Edge/User Data/*/Code Cache"},
+		{"Edge", "${LOCALAPPDATA}/Microsoft/Edge/User Data/*/GPUCache"},
+		{"Firefox", "${LOCALAPPDATA}/Mozilla/Firefox/Profiles/*/cache2"},
+	},
+}
+
+// browserCache is a cache directory found on this system
+type browserCache struct {
+	browser, dir string
+}
+
+// browserRecipe empties the cache directories of the installed browsers,
+// keeping the cache directories themselves. It is a fileRecipe: the cleaner
+// deletes the files, so keep_list, max_delete_files and the audit log apply.
+type browserRecipe struct{}
+
+func (browserRecipe) Name() string        { return "browser" }
+func (browserRecipe) Description() string { return "browser caches" }
+func (r browserRecipe) Available() bool   { return len(r.caches()) > 0 }
+
+// caches finds the cache directories of this OS, skipping symlinks so a
+// link cannot lead the recipe out of a cache
+func (browserRecipe) caches() []browserCache {
+	var found []browserCache
+	for _, p := range browserCachePatterns[runtime.GOOS] {
+		glob, ok := resolveLocation(p.glob)
+		if !ok {
+			continue
+		}
+		dirs, _ := filepath.Glob(glob)
+		for _, dir := range dirs {
+			if info, err := os.Lstat(dir); err == nil && info.IsDir() {
+				found = append(found, browserCache{p.browser, dir})
+			}
+		}
+	}
+	return found
+}
+
+// Parts measures the caches of each browser, in alphabetical order
+func (r browserRecipe) Parts() ([]RecipeUsage, error) {
+	sizes := make(map[string]int64)
+	var errs []error
+	for _, c := range r.caches() {
+		size, err := treeSize(c.dir, nil)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		sizes[c.browser] += size
+	}
+	parts := make([]RecipeUsage, 0, len(sizes))
+	for browser, size := range sizes {
+		parts = append(parts, RecipeUsage{Name: browser, Description: browser + " cache", Size: size})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Name < parts[j].Name })
+	return parts, errors.Join(errs...)
+}
+
+func (r browserRecipe) Size() (int64, error) {
+	parts, err := r.Parts()
+	var size int64
+	for _, part := range parts {
+		size += part.Size
+	}
+	return size, err
+}
+
+// Commands is empty; the browser recipe deletes the cache files through the
+// cleaner, see Files
+func (browserRecipe) Commands() ([][]string, error) {
+	return nil, nil
+}
+
+// Files lists every file under the cache directories. The walks do not follow
+// symlinks, so a link inside a cache is deleted, never what it points to.
+func (r browserRecipe) Files() ([]recipeFile, error) {
+	var files []recipeFile
+	var errs []error
+	for _, c := range r.caches() {
+		err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == c.dir {
+					return err
+				}
+				errs = append(errs, err)
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			var size int64
+			if info, err := d.Info(); err == nil && info.Mode().IsRegular() {
+				size = info.Size()
+			}
+			files = append(files, recipeFile{path: path, root: c.dir, part: c.browser + " cache", size: size})
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return files, errors.Join(errs...)
+}
@@ -0,0 +1,58 @@
+package cleaner
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetMaxDuration limits how long deleting may take (0 means no limit). Once
+// the budget is spent the file being removed is finished, the walk stops and
+// the clean reports what it got done instead of failing.
+func (sc *SystemCleaner) SetMaxDuration(budget time.Duration) {
+	sc.budget = budget
+}
+
+// startBudget starts the clock of a clean's time budget; the returned func
+// stops it. Walks stop with ErrBudget once it runs out.
+func (sc *SystemCleaner) startBudget() func() {
+	if sc.budget <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	sc.budgetDone.Store(done)
+	timer := time.AfterFunc(sc.budget, func() {
+		sc.logger.Printf("Time budget of %s reached, stopping the clean", sc.budget)
+		close(done)
+	})
+	return func() {
+		timer.Stop()
+		sc.budgetDone.Store((chan struct{})(nil))
+	}
+}
+
+// budgetSpent returns a channel that is closed once the running clean's time
+// budget is spent; it is nil (never ready) without a budget
+func (sc *SystemCleaner) budgetSpent() <-chan struct{} {
+	done, _ := sc.budgetDone.Load().(chan struct{})
+	return done
+}
+
+// overBudget reports whether the running clean's time budget is spent
+func (sc *SystemCleaner) overBudget() bool {
+	select {
+	case <-sc.budgetSpent():
+		return true
+	default:
+		return false
+	}
+}
+
+// reportBudget tells what a clean stopped by its time budget achieved
+func (sc *SystemCleaner) reportBudget(pending int) {
+	p := sc.Progress()
+	fmt.Fprintf(sc.out, "⏱️  Time budget of %s reached: %d files deleted, %s freed", sc.budget, p.FilesDeleted, sc.FormatSize(p.BytesFreed))
+	if pending > 0 {
+		fmt.Fprintf(sc.out, ", %d cleanup paths left for next time", pending)
+	}
+	fmt.Fprintln(sc.out)
+}
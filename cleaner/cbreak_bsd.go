@@ -0,0 +1,11 @@
+//go:build darwin || freebsd
+
+package cleaner
+
+import "golang.org/x/sys/unix"
+
+// ioctl requests reading and writing terminal settings
+const (
+	getTermios = unix.TIOCGETA
+	setTermios = unix.TIOCSETA
+)
@@ -0,0 +1,9 @@
+package cleaner
+
+import "golang.org/x/sys/unix"
+
+// ioctl requests reading and writing terminal settings
+const (
+	getTermios = unix.TCGETS
+	setTermios = unix.TCSETS
+)
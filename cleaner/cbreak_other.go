@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd
+
+package cleaner
+
+import "errors"
+
+// cbreakSupported tells whether cbreak works on this platform
+const cbreakSupported = false
+
+// cbreak is not supported on this platform, so the monitor takes no keys
+func cbreak(int) (func() error, error) {
+	return nil, errors.New("single-key input is not supported on this platform")
+}
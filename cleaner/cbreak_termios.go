@@ -0,0 +1,27 @@
+//go:build linux || darwin || freebsd
+
+package cleaner
+
+import "golang.org/x/sys/unix"
+
+// cbreakSupported tells whether cbreak works on this platform
+const cbreakSupported = true
+
+// cbreak switches the terminal fd to unbuffered input without echo, so single
+// keypresses can be read, and returns a function restoring its old settings.
+// Signals such as Ctrl+C keep working. Reads return after a tenth of a second
+// even without input, which lets key readers notice they should stop.
+func cbreak(fd int) (func() error, error) {
+	old, err := unix.IoctlGetTermios(fd, getTermios)
+	if err != nil {
+		return nil, err
+	}
+	raw := *old
+	raw.Lflag &^= unix.ICANON | unix.ECHO
+	raw.Cc[unix.VMIN] = 0
+	raw.Cc[unix.VTIME] = 1
+	if err := unix.IoctlSetTermios(fd, setTermios, &raw); err != nil {
+		return nil, err
+	}
+	return func() error { return unix.IoctlSetTermios(fd, setTermios, old) }, nil
+}
@@ -0,0 +1,73 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// minChartBar is the narrowest bar worth drawing; below it the chart falls
+// back to plain text
+const minChartBar = 10
+
+// SetChart makes ShowJunkUsage draw a bar chart scaled to the terminal width
+func (sc *SystemCleaner) SetChart(enabled bool) {
+	sc.chart = enabled
+}
+
+// terminalWidth returns the width of the terminal results are written to, or
+// of $COLUMNS, and false when neither is known
+func (sc *SystemCleaner) terminalWidth() (int, bool) {
+	if f, ok := sc.out.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width, true
+		}
+	}
+	if width, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && width > 0 {
+		return width, true
+	}
+	return 0, false
+}
+
+// drawChart prints one proportional bar per entry. It returns false, drawing
+// nothing, when the terminal width is unknown or too narrow.
+func (sc *SystemCleaner) drawChart(labels []string, sizes []int64) bool {
+	width, ok := sc.terminalWidth()
+	if !ok {
+		return false
+	}
+
+	var labelWidth, sizeWidth int
+	var largest int64
+	formatted := make([]string, len(sizes))
+	for i, size := range sizes {
+		formatted[i] = sc.FormatSize(size)
+		labelWidth = max(labelWidth, utf8.RuneCountInString(labels[i]))
+		sizeWidth = max(sizeWidth, len(formatted[i]))
+		largest = max(largest, size)
+	}
+	labelWidth = min(labelWidth, width*2/5)
+
+	barWidth := width - labelWidth - sizeWidth - 4
+	if barWidth < minChartBar {
+		return false
+	}
+
+	for i, size := range sizes {
+		label := labels[i]
+		if n := utf8.RuneCountInString(label); n > labelWidth {
+			label = "…" + string([]rune(label)[n-labelWidth+1:])
+		}
+		bar := 0
+		if largest > 0 {
+			bar = int(float64(size) / float64(largest) * float64(barWidth))
+		}
+		fmt.Fprintf(sc.out, "%-*s %s%s %*s\n", labelWidth, label,
+			strings.Repeat("█", bar), strings.Repeat("·", barWidth-bar), sizeWidth, formatted[i])
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// protectedPaths are system directories that must never be a cleanup path
+var protectedPaths = []string{
+	"/", "/bin", "/boot", "/dev", "/etc", "/home", "/lib", "/lib64", "/opt",
+	"/proc", "/root", "/sbin", "/sys", "/usr", "/var",
+	"/Applications", "/Library", "/System", "/Users", "/Volumes",
+}
+
+// isProtected reports whether an absolute, resolved path is a protected
+// system directory or the user's home directory
+func isProtected(path string) bool {
+	if home, err := os.UserHomeDir(); err == nil && path == filepath.Clean(home) {
+		return true
+	}
+	for _, p := range protectedPaths {
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
+
+// PathStatus is the readiness of one cleanup path
+type PathStatus struct {
+	Path     string `json:"path"`
+	Resolved string `json:"resolved,omitempty"`
+	Status   string `json:"status"`
+	Ready    bool   `json:"ready"`
+}
+
+// checkPath works out whether a cleanup path can be cleaned, without reading
+// its contents; a regular file passes only when allowFiles is set
+func checkPath(path string, allowFiles bool) PathStatus {
+	ps := PathStatus{Path: path}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		ps.Status = "cannot resolve: " + err.Error()
+		return ps
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if os.IsNotExist(err) {
+		ps.Status = "missing"
+		return ps
+	}
+	if err != nil {
+		ps.Status = "cannot resolve: " + err.Error()
+		return ps
+	}
+	ps.Resolved = resolved
+
+	info, err := os.Stat(resolved)
+	switch {
+	case err != nil:
+		ps.Status = "cannot stat: " + err.Error()
+	case info.Mode().IsRegular() && !allowFiles:
+		ps.Status = "is a file, skipped (set clean_file_paths to clean it)"
+	case info.Mode().IsRegular():
+		if writable(filepath.Dir(resolved)) {
+			ps.Status = "ready (single file)"
+			ps.Ready = true
+		} else {
+			ps.Status = "not writable"
+		}
+	case !info.IsDir():
+		ps.Status = "not a directory"
+	case isProtected(resolved):
+		ps.Status = "protected system directory"
+	default:
+		mount, _ := statMount(resolved)
+		switch {
+		case mount.readOnly:
+			ps.Status = "read-only filesystem"
+		case !writable(resolved):
+			ps.Status = "not writable"
+		default:
+			ps.Status = "ready"
+			ps.Ready = true
+		}
+	}
+	return ps
+}
+
+// CheckConfig reports whether every cleanup path exists, is a writable
+// directory and is not protected, without scanning or deleting anything
+func (sc *SystemCleaner) CheckConfig() error {
+	statuses := make([]PathStatus, len(sc.config.CleanupPaths))
+	var unusable int
+	for i, cp := range sc.config.CleanupPaths {
+		statuses[i] = checkPath(cp.Path, sc.config.CleanFilePaths)
+		if !statuses[i].Ready {
+			unusable++
+		}
+	}
+
+	if sc.jsonOutput {
+		encoder := json.NewEncoder(sc.out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(statuses); err != nil {
+			return err
+		}
+	} else {
+		for _, ps := range statuses {
+			icon := "✅"
+			if !ps.Ready {
+				icon = "❌"
+			}
+			fmt.Fprintf(sc.out, "%s %s → %s\n", icon, ps.Path, ps.Status)
+		}
+		fmt.Fprintf(sc.out, "\n📊 %d of %d cleanup paths ready\n", len(statuses)-unusable, len(statuses))
+	}
+
+	if unusable > 0 {
+		return fmt.Errorf("%w: %d of %d", ErrPathsNotReady, unusable, len(statuses))
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package cleaner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// checkpointInterval is how often a running clean rewrites its checkpoint
+const checkpointInterval = 5 * time.Second
+
+// checkpointState is the on-disk form of a checkpoint
+type checkpointState struct {
+	ConfigHash string   `json:"config_hash"`
+	Done       []string `json:"done"` // cleanup paths and top-level subtrees fully cleaned
+}
+
+// checkpoint records which cleanup paths and top-level subtrees a clean has
+// finished, so an interrupted clean can resume where it left off
+type checkpoint struct {
+	mu    sync.Mutex
+	file  string
+	hash  string
+	done  map[string]bool
+	saved time.Time
+}
+
+// rulesHash fingerprints everything that decides what a clean deletes: the
+// selection settings, the contents of keep_list and rules_file and the
+// -empty-files and -since-boot flags. A checkpoint written under different
+// settings is stale.
+func (sc *SystemCleaner) rulesHash() string {
+	c := sc.config
+	data, _ := json.Marshal(struct {
+		CleanupPaths     []CleanupPath
+		MinAgeDays       int
+		ExcludePatterns  []string
+		MinSize          int64
+		KeepRecent       int
+		ContentTypes     []string
+		PathRegex        []string
+		MaxFileSize      int64
+		MaxFileSizeByExt map[string]string
+		MaxPathSize      int64
+		SizeMode         string
+		CleanFilePaths   bool
+		KeepList         string
+		KeepListData     string
+		RulesFile        string
+		RulesData        string
+		SkipPaths        []string
+		BeforeLast       bool
+		StayOnFilesystem bool
+		SkipActiveWindow time.Duration
+		CleanCooldown    time.Duration
+		DetectGrowing    bool
+		EmptyFiles       string
+		SinceBoot        time.Time
+	}{
+		c.CleanupPaths, c.MinAgeDays, c.ExcludePatterns, c.MinSize, c.KeepRecent, c.ContentTypes, c.PathRegex,
+		c.MaxFileSize, c.MaxFileSizeByExt, c.MaxPathSize, c.SizeMode, c.CleanFilePaths,
+		c.KeepList, fileDigest(c.KeepList), c.RulesFile, fileDigest(c.RulesFile),
+		c.SkipPaths, c.OnlyBeforeLastClean, c.StayOnFilesystem, c.SkipActiveWindow, c.CleanCooldown, c.DetectGrowing,
+		sc.emptyFiles, sc.sinceBoot,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// fileDigest hashes the contents of the file at path, or returns "" when
+// there is none
+func fileDigest(path string) string {
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadCheckpoint reads the configured checkpoint file, starting afresh when it
+// is missing or was written for a different configuration
+func (sc *SystemCleaner) loadCheckpoint() (*checkpoint, error) {
+	ck := &checkpoint{
+		file:  sc.config.CheckpointFile,
+		hash:  sc.rulesHash(),
+		done:  make(map[string]bool),
+		saved: time.Now(),
+	}
+
+	data, err := os.ReadFile(ck.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return ck, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state checkpointState
+	if err := json.Unmarshal(data, &state); err != nil {
+		sc.logger.Printf("Ignoring unreadable checkpoint %s: %v", ck.file, err)
+		return ck, nil
+	}
+	if state.ConfigHash != ck.hash {
+		sc.logger.Printf("Ignoring stale checkpoint %s: the configuration has changed", ck.file)
+		return ck, nil
+	}
+	for _, path := range state.Done {
+		ck.done[path] = true
+	}
+	return ck, nil
+}
+
+// isDone reports whether path was fully cleaned by an earlier run
+func (ck *checkpoint) isDone(path string) bool {
+	ck.mu.Lock()
+	defer ck.mu.Unlock()
+	return ck.done[path]
+}
+
+// markDone records path as fully cleaned, saving the checkpoint if the last
+// save was more than checkpointInterval ago
+func (ck *checkpoint) markDone(path string) error {
+	ck.mu.Lock()
+	defer ck.mu.Unlock()
+	ck.done[path] = true
+	if time.Since(ck.saved) < checkpointInterval {
+		return nil
+	}
+	return ck.saveLocked()
+}
+
+// save writes the checkpoint file
+func (ck *checkpoint) save() error {
+	ck.mu.Lock()
+	defer ck.mu.Unlock()
+	return ck.saveLocked()
+}
+
+func (ck *checkpoint) saveLocked() error {
+	state := checkpointState{ConfigHash: ck.hash}
+	for path := range ck.done {
+		state.Done = append(state.Done, path)
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write then rename so a crash never leaves a truncated checkpoint
+	tmp := ck.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, ck.file); err != nil {
+		return err
+	}
+	ck.saved = time.Now()
+	return nil
+}
+
+// remove deletes the checkpoint file after a clean completes
+func (ck *checkpoint) remove() error {
+	err := os.Remove(ck.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// subtrees returns the hooks for walking one cleanup path: skip reports the
+// directories to leave out because an earlier run cleaned them, and records a
+// top-level subtree as done once the walk moves past it and flush returns, so
+// files still queued for deletion are never counted as cleaned; finish records
+// the path as done after the walk and its deletions complete. With a nil
+// flush only finish records anything.
+func (sc *SystemCleaner) subtrees(ck *checkpoint, root string, flush func()) (skip func(dir string) bool, finish func()) {
+	var current string
+	mark := func(path string) {
+		if err := ck.markDone(path); err != nil {
+			sc.logger.Printf("Error saving checkpoint %s: %v", ck.file, err)
+		}
+	}
+
+	skip = func(dir string) bool {
+		if ck.isDone(dir) {
+			return true
+		}
+		if flush == nil || filepath.Dir(dir) != root {
+			return false
+		}
+		// walks are lexical, so reaching a new top-level directory means the
+		// previous one is finished
+		if current != "" {
+			flush()
+			mark(current)
+		}
+		current = dir
+		return false
+	}
+	finish = func() {
+		if current != "" {
+			mark(current)
+		}
+		mark(root)
+	}
+	return skip, finish
+}
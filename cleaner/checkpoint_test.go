@@ -0,0 +1,90 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRulesHash(t *testing.T) {
+	dir := t.TempDir()
+	keep := filepath.Join(dir, "keep.txt")
+	rules := filepath.Join(dir, "rules.yaml")
+	write := func(path, data string) {
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(keep, "/tmp/precious\n")
+	write(rules, "rules:\n  - name: all\n    action: delete\n")
+	base := fmt.Sprintf("cleanup_paths: [%s]\nkeep_list: %s\nrules_file: %s\n", dir, keep, rules)
+	hash := newTestCleaner(t, base).rulesHash()
+
+	tests := []struct {
+		name   string
+		change func(sc *SystemCleaner)
+	}{
+		{"keep_recent", func(sc *SystemCleaner) { sc.config.KeepRecent = 3 }},
+		{"path_regex", func(sc *SystemCleaner) { sc.config.PathRegex = []string{`\.tmp$`} }},
+		{"per-path rule", func(sc *SystemCleaner) { sc.config.CleanupPaths[0].PathRegex = []string{`\.log$`} }},
+		{"max_file_size", func(sc *SystemCleaner) { sc.config.MaxFileSize = 1 << 20 }},
+		{"clean_cooldown", func(sc *SystemCleaner) { sc.config.CleanCooldown = time.Hour }},
+		{"keep_list contents", func(sc *SystemCleaner) { write(keep, "/tmp/other\n") }},
+		{"rules_file contents", func(sc *SystemCleaner) { write(rules, "rules:\n  - name: all\n    action: keep\n") }},
+		{"empty files", func(sc *SystemCleaner) { sc.SetEmptyFiles("skip") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := newTestCleaner(t, base)
+			if sc.rulesHash() != hash {
+				t.Fatal("the same settings hash differently")
+			}
+			tt.change(sc)
+			if sc.rulesHash() == hash {
+				t.Errorf("changing %s leaves the hash unchanged", tt.name)
+			}
+			write(keep, "/tmp/precious\n")
+			write(rules, "rules:\n  - name: all\n    action: delete\n")
+		})
+	}
+}
+
+// TestSubtreesWaitForDeletions checks that a top-level directory is only
+// recorded as done once the deletions queued from it have finished
+func TestSubtreesWaitForDeletions(t *testing.T) {
+	root := t.TempDir()
+	sc := newTestCleaner(t, "")
+	ck := &checkpoint{file: filepath.Join(t.TempDir(), "checkpoint.json"), done: make(map[string]bool), saved: time.Now()}
+
+	var removed atomic.Int64
+	pool := newDeletePool(4, func(_ *fileRemover, job deleteJob) {
+		time.Sleep(10 * time.Microsecond)
+		removed.Add(1)
+	})
+	skip, finish := sc.subtrees(ck, root, pool.flush)
+
+	var queued int64
+	for _, name := range []string{"a", "b", "c"} {
+		dir := filepath.Join(root, name)
+		if skip(dir) {
+			t.Fatalf("%s skipped before it was cleaned", dir)
+		}
+		if name != "a" && removed.Load() != queued {
+			t.Errorf("%s recorded as done with %d of its %d files deleted", name, removed.Load(), queued)
+		}
+		for i := 0; i < 2*minPoolFiles; i++ {
+			pool.add(deleteJob{path: filepath.Join(dir, fmt.Sprint(i))})
+			queued++
+		}
+	}
+	pool.wait()
+	finish()
+	for _, name := range []string{"a", "b", "c"} {
+		if !ck.isDone(filepath.Join(root, name)) {
+			t.Errorf("%s not recorded as done", name)
+		}
+	}
+}
@@ -0,0 +1,976 @@
+// Package cleaner implements junk cleaning, large-file scanning, memory
+// optimization and system monitoring for the System Cleaner CLI.
+package cleaner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// SystemCleaner handles the cleaning operations
+type SystemCleaner struct {
+	config     *Config
+	logger     *log.Logger
+	logWriter  *bufferedLog
+	stopChan   chan struct{}
+	stopOnce   sync.Once
+	operations *sync.WaitGroup
+	jsonOutput bool
+	progress   progressTracker
+	out        io.Writer // results: usage tables, scan lists, JSON
+	msg        io.Writer // progress, spinners, warnings
+
+	confirmPath func(path string, size int64) bool
+	prompter    Prompter
+	units       string
+	fixedUnit   int // see SetUnit
+	dryRun      bool
+	template    *template.Template
+	session     string // identifies this run in the audit log
+	chart       bool
+	ownFiles    map[string]map[string]bool // see findOwnFiles
+	filePaths   map[string]bool            // see findFilePaths
+	warnedFiles sync.Map
+	review      func(files []FileInfo) []FileInfo
+	gpu         bool
+	ascii       bool
+	budget      time.Duration
+	budgetDone  atomic.Value // chan struct{}, see startBudget
+	maxFiles    int
+	inodes      bool
+	events      eventHandler
+	failures    errorSummary // see ErrorSummary
+	state       *runState
+	keys        *os.File // see SetMonitorKeys
+	keep        keepList // loaded from keep_list by loadKeep
+
+	oneFilesystem bool          // see SetOneFilesystem
+	emptyFiles    string        // see SetEmptyFiles
+	job           string        // the job CleanJob is running, for metrics
+	sinceBoot     time.Time     // see SetSinceBoot
+	script        *deleteScript // see SetScriptOut
+	trim          bool          // see SetTrim
+	force         bool          // see SetForce
+}
+
+// FileInfo represents information about a file
+type FileInfo struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// NewSystemCleaner creates a new instance of SystemCleaner
+func NewSystemCleaner(configPath string) (*SystemCleaner, error) {
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// parseConfig has checked the placeholders, so this cannot fail
+	config.logPath, _ = strftime(config.LogFile, time.Now())
+	if err := os.MkdirAll(filepath.Dir(config.logPath), 0755); err != nil {
+		return nil, &ConfigError{Path: configPath, Err: fmt.Errorf("failed to create log directory: %w", err)}
+	}
+	logFile, err := os.OpenFile(config.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, &ConfigError{Path: configPath, Err: fmt.Errorf("failed to open log file %s: %w", config.logPath, err)}
+	}
+
+	logWriter := newBufferedLog(logFile)
+	logger := log.New(logWriter, "", log.LstdFlags)
+
+	sc := &SystemCleaner{
+		config:     config,
+		logger:     logger,
+		logWriter:  logWriter,
+		stopChan:   make(chan struct{}),
+		operations: &sync.WaitGroup{},
+		out:        os.Stdout,
+		msg:        os.Stderr,
+		units:      "iec",
+		session:    newSessionID(),
+		prompter:   NewStdPrompter(os.Stdin, os.Stderr),
+	}
+	for _, line := range config.logs {
+		logger.Print(line)
+	}
+	for _, note := range config.notes {
+		logger.Print(note)
+		fmt.Fprintln(sc.msg, "⚠️ ", note)
+	}
+	sc.applyPriority()
+	sc.ownFiles = sc.findOwnFiles()
+	sc.filePaths = sc.findFilePaths()
+	if sc.state, err = loadState(config.StateFile); err != nil {
+		logger.Printf("Ignoring unreadable state file %s: %v", config.StateFile, err)
+		fmt.Fprintf(sc.msg, "⚠️  Ignoring unreadable state file %s: %v\n", config.StateFile, err)
+	}
+	return sc, nil
+}
+
+// Config returns the loaded configuration
+func (sc *SystemCleaner) Config() *Config {
+	return sc.config
+}
+
+// SetJSONOutput switches reports that support it to machine-readable JSON
+func (sc *SystemCleaner) SetJSONOutput(enabled bool) {
+	sc.jsonOutput = enabled
+}
+
+// SetOutput redirects results and human-oriented messages; by default results
+// go to stdout and messages to stderr
+func (sc *SystemCleaner) SetOutput(out, msg io.Writer) {
+	sc.out = out
+	sc.msg = msg
+}
+
+// SetPathConfirm makes CleanJunk ask confirm about every cleanup path (with its
+// junk size in bytes) and skip the ones it declines; nil cleans all paths
+func (sc *SystemCleaner) SetPathConfirm(confirm func(path string, size int64) bool) {
+	sc.confirmPath = confirm
+}
+
+// SetReview makes CleanJunk list every file it would delete and hand the list
+// to review, deleting only the files it returns; nil deletes them all
+func (sc *SystemCleaner) SetReview(review func(files []FileInfo) []FileInfo) {
+	sc.review = review
+}
+
+// SetGPUMonitor adds NVIDIA GPU utilization and memory to SystemMonitor; it is
+// skipped with a warning when no GPU tools are installed
+func (sc *SystemCleaner) SetGPUMonitor(enabled bool) {
+	sc.gpu = enabled
+}
+
+// SetASCII replaces emoji in all output with plain-text labels and makes the
+// default spinner ASCII, for terminals without Unicode
+func (sc *SystemCleaner) SetASCII(enabled bool) {
+	if enabled && !sc.ascii {
+		sc.out = ASCIIWriter(sc.out)
+		sc.msg = ASCIIWriter(sc.msg)
+	}
+	sc.ascii = enabled
+}
+
+// SetDryRun makes destructive operations report what they would do instead
+// of doing it
+func (sc *SystemCleaner) SetDryRun(enabled bool) {
+	sc.dryRun = enabled
+}
+
+// Logger returns the logger used for operational errors
+func (sc *SystemCleaner) Logger() *log.Logger {
+	return sc.logger
+}
+
+// LogPath returns the file this run logs to: log_file with its date
+// placeholders expanded
+func (sc *SystemCleaner) LogPath() string {
+	return sc.config.logPath
+}
+
+// FlushLog writes buffered log lines to the log file
+func (sc *SystemCleaner) FlushLog() error {
+	return sc.logWriter.Flush()
+}
+
+// Close flushes and closes the log file; call it before the program exits
+func (sc *SystemCleaner) Close() error {
+	return sc.logWriter.Close()
+}
+
+// Stop signals running operations to stop; it is safe to call more than once
+func (sc *SystemCleaner) Stop() {
+	sc.stopOnce.Do(func() { close(sc.stopChan) })
+}
+
+// Wait blocks until all background operations have finished
+func (sc *SystemCleaner) Wait() {
+	sc.operations.Wait()
+}
+
+// startLoading shows a loading animation. Send on the returned channel to stop
+// it, then receive from it to wait until the final line has been printed.
+func (sc *SystemCleaner) startLoading(message string) chan bool {
+	stop := make(chan bool)
+	sc.operations.Add(1)
+
+	go func() {
+		defer close(stop)
+		frames := sc.spinnerFrames()
+		i := 0
+		for {
+			select {
+			case <-stop:
+				fmt.Fprintf(sc.msg, "\r✅ %s\n", message)
+				sc.operations.Done()
+				return
+			case <-sc.stopChan:
+				fmt.Fprintf(sc.msg, "\r❌ %s (interrupted)\n", message)
+				sc.operations.Done()
+				<-stop
+				return
+			default:
+				if len(frames) > 0 {
+					fmt.Fprintf(sc.msg, "\r%s %s", frames[i%len(frames)], message)
+				}
+				i++
+				time.Sleep(100 * time.Millisecond)
+			}
+		}
+	}()
+
+	return stop
+}
+
+// spinnerFrames returns the loading animation of the configured spinner style
+func (sc *SystemCleaner) spinnerFrames() []string {
+	style := sc.config.Spinner
+	if style == "" {
+		style = "braille"
+		if sc.ascii {
+			style = "ascii"
+		}
+	}
+	return spinnerFrames[style]
+}
+
+// getDirSize calculates the total size of the files in a cleanup path that
+// pass its cleanup rules
+func (sc *SystemCleaner) getDirSize(cp CleanupPath) (int64, error) {
+	usage, err := sc.getDirUsage(cp)
+	return usage.size, err
+}
+
+// dirUsage is the junk of one cleanup path
+type dirUsage struct {
+	size  int64
+	files int64 // one inode each
+	empty int64 // zero-byte files, even those SetEmptyFiles leaves out
+}
+
+// getDirUsage is getDirSize that also counts the junk files
+func (sc *SystemCleaner) getDirUsage(cp CleanupPath) (dirUsage, error) {
+	var usage dirUsage
+	err := runWithTimeout(sc.config.ScanTimeout, func(ctx context.Context) error {
+		opts := walkOptions{ctx: ctx, empty: func(string) { usage.empty++ }}
+		return sc.walkJunkWith(cp, opts, func(_ string, info fs.FileInfo) {
+			usage.size += sc.fileSize(info)
+			usage.files++
+		})
+	})
+	if errors.Is(err, ErrTimeout) {
+		// the abandoned walk may still be adding to usage
+		return dirUsage{}, err
+	}
+	return usage, err
+}
+
+// fileSize returns the size counted for a junk file: its apparent size, or the
+// space allocated on disk when size_mode is "allocated"
+func (sc *SystemCleaner) fileSize(info fs.FileInfo) int64 {
+	if sc.config.SizeMode == "allocated" {
+		return allocatedSize(info)
+	}
+	return info.Size()
+}
+
+// ShowJunkUsage displays information about junk files
+func (sc *SystemCleaner) ShowJunkUsage() error {
+	fmt.Fprintln(sc.msg, "\n🔍 Scanning junk files...")
+
+	fmt.Fprintln(sc.msg, "clean paths")
+	fmt.Fprintln(sc.msg, sc.config.cleanupDirs())
+
+	var report JunkReport
+	for i, usage := range sc.pathUsage() {
+		path := sc.config.CleanupPaths[i].Path
+		report.Paths = append(report.Paths, PathUsage{Path: path, Size: usage.size, Network: isNetworkPath(path), OverLimit: sc.config.overPathSize(usage.size)})
+		if sc.inodes {
+			report.Paths[i].Files = usage.files
+		}
+		report.Total += usage.size
+		report.EmptyFiles += usage.empty
+	}
+	if sc.inodes {
+		sc.addInodes(&report)
+	}
+	report.Recipes = sc.recipeUsage()
+	for _, usage := range report.Recipes {
+		report.Total += usage.Size
+	}
+	if sc.jsonOutput {
+		encoder := json.NewEncoder(sc.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	if sc.template != nil {
+		return sc.render(Report{Kind: "junk", Junk: &report})
+	}
+
+	if !sc.chart || !sc.chartJunk(report) {
+		sc.listJunk(report)
+	}
+	if sc.inodes {
+		sc.listInodes(report)
+	}
+	sc.listEmpty(report)
+
+	if report.Total == 0 && (sc.emptyFiles != "only" || report.EmptyFiles == 0) {
+		fmt.Fprintln(sc.out, "\n✅ No junk files found! Your system is clean.")
+		return nil
+	}
+
+	fmt.Fprintf(sc.out, "\n🚨 Total Junk Size: %s 🚨\n", sc.FormatSize(report.Total))
+	return nil
+}
+
+// listJunk prints the junk usage one line per cleanup path and recipe
+func (sc *SystemCleaner) listJunk(report JunkReport) {
+	for _, usage := range report.Paths {
+		line := fmt.Sprintf("📂 %s → %s", usage.Path, sc.FormatSize(usage.Size))
+		if sc.inodes {
+			line += fmt.Sprintf(" in %d files", usage.Files)
+		}
+		if usage.Network {
+			line += " 🌐 network"
+		}
+		if usage.OverLimit {
+			line += " 🚨 over max_path_size, a clean skips it"
+		}
+		fmt.Fprintln(sc.out, line)
+	}
+	for _, usage := range report.Recipes {
+		fmt.Fprintf(sc.out, "🧰 %s → %s\n", usage.Description, sc.FormatSize(usage.Size))
+		for _, part := range usage.Parts {
+			fmt.Fprintf(sc.out, "   %s → %s\n", part.Description, sc.FormatSize(part.Size))
+		}
+	}
+}
+
+// chartJunk draws the junk usage as a bar chart, reporting false when the
+// terminal is too narrow or its width is unknown
+func (sc *SystemCleaner) chartJunk(report JunkReport) bool {
+	var labels []string
+	var sizes []int64
+	for _, usage := range report.Paths {
+		label := usage.Path
+		if usage.Network {
+			label += " (network)"
+		}
+		labels = append(labels, label)
+		sizes = append(sizes, usage.Size)
+	}
+	for _, usage := range report.Recipes {
+		labels = append(labels, usage.Description)
+		sizes = append(sizes, usage.Size)
+	}
+	return sc.drawChart(labels, sizes)
+}
+
+// selectPaths returns the cleanup paths to clean, asking the path confirmation
+// hook about each one when it is set
+func (sc *SystemCleaner) selectPaths() []CleanupPath {
+	if sc.confirmPath == nil {
+		return sc.config.CleanupPaths
+	}
+
+	var selected []CleanupPath
+	for _, cp := range sc.config.CleanupPaths {
+		size, err := sc.getDirSize(cp)
+		if err != nil {
+			sc.logger.Printf("Error scanning directory %s: %v", cp.Path, err)
+		}
+		if sc.confirmPath(cp.Path, size) {
+			selected = append(selected, cp)
+		} else {
+			sc.logger.Printf("Skipping %s at user request", cp.Path)
+			sc.emit(Event{Type: EventSkipped, Path: cp.Path, Reason: "declined"})
+		}
+	}
+	return selected
+}
+
+// writablePaths drops the cleanup paths that sit on a read-only mount, with a
+// single warning for each, so their files are not tried one by one
+func (sc *SystemCleaner) writablePaths(paths []CleanupPath) []CleanupPath {
+	var writable []CleanupPath
+	for _, cp := range paths {
+		mount, err := statMount(cp.Path)
+		if err != nil {
+			sc.logger.Printf("Error checking mount of %s: %v", cp.Path, err)
+		}
+		if mount.readOnly {
+			sc.logger.Printf("Skipping %s: read-only filesystem", cp.Path)
+			sc.emit(Event{Type: EventSkipped, Path: cp.Path, Reason: "read-only filesystem"})
+			fmt.Fprintf(sc.msg, "⚠️  Skipping %s: it is on a read-only filesystem\n", cp.Path)
+			continue
+		}
+		writable = append(writable, cp)
+	}
+	return writable
+}
+
+// idlePaths drops the cleanup paths whose directory was modified within
+// skip_active_window, assuming something is still writing to them
+func (sc *SystemCleaner) idlePaths(paths []CleanupPath) []CleanupPath {
+	if sc.config.SkipActiveWindow <= 0 {
+		return paths
+	}
+
+	var idle []CleanupPath
+	for _, cp := range paths {
+		info, err := os.Stat(cp.Path)
+		if err == nil {
+			if age := time.Since(info.ModTime()); age < sc.config.SkipActiveWindow {
+				age = age.Round(time.Second)
+				sc.logger.Printf("Skipping %s: modified %s ago, within skip_active_window", cp.Path, age)
+				fmt.Fprintf(sc.msg, "⚠️  Skipping %s: modified %s ago, it looks busy\n", cp.Path, age)
+				sc.emit(Event{Type: EventSkipped, Path: cp.Path, Reason: "modified within skip_active_window"})
+				continue
+			}
+		}
+		idle = append(idle, cp)
+	}
+	return idle
+}
+
+// cooledDownPaths drops the cleanup paths the state file says were cleaned
+// within clean_cooldown, so frequent runs do not walk tidy directories again
+func (sc *SystemCleaner) cooledDownPaths(paths []CleanupPath) []CleanupPath {
+	if sc.config.CleanCooldown <= 0 || sc.state == nil {
+		return paths
+	}
+
+	var due []CleanupPath
+	for _, cp := range paths {
+		last := sc.state.lastCleanOf(cp.Path)
+		if age := time.Since(last); !last.IsZero() && age < sc.config.CleanCooldown {
+			age = age.Round(time.Second)
+			sc.logger.Printf("Skipping %s: last cleaned at %s, %s ago, within clean_cooldown", cp.Path, last.Format(time.RFC3339), age)
+			fmt.Fprintf(sc.msg, "⏳ Skipping %s: cleaned %s ago, at %s\n", cp.Path, age, last.Format("2006-01-02 15:04:05"))
+			sc.emit(Event{Type: EventSkipped, Path: cp.Path, Reason: "cleaned within clean_cooldown"})
+			continue
+		}
+		due = append(due, cp)
+	}
+	return due
+}
+
+// checkDeleteLimit counts the files a clean would delete and refuses to go on
+// when they exceed max_delete_files, unless the confirm hook approves
+func (sc *SystemCleaner) checkDeleteLimit(paths []CleanupPath) error {
+	if sc.config.MaxDeleteFiles <= 0 {
+		return nil
+	}
+
+	var count int
+	for _, cp := range paths {
+		err := sc.walkJunk(cp, func(string, fs.FileInfo) { count++ })
+		if err != nil && !errors.Is(err, ErrInterrupted) {
+			sc.logger.Printf("Error accessing path %s: %v", cp.Path, err)
+		}
+	}
+	return sc.checkDeleteCount(count)
+}
+
+// checkDeleteCount is checkDeleteLimit for a known number of files
+func (sc *SystemCleaner) checkDeleteCount(count int) error {
+	if sc.config.MaxDeleteFiles <= 0 || count <= sc.config.MaxDeleteFiles {
+		return nil
+	}
+
+	sc.logger.Printf("Clean would delete %d files, above max_delete_files (%d)", count, sc.config.MaxDeleteFiles)
+	question := fmt.Sprintf("This would delete %d files, more than max_delete_files (%d). Really continue?", count, sc.config.MaxDeleteFiles)
+	if sc.confirmExtra(question) {
+		return nil
+	}
+	return fmt.Errorf("%w: %d files would be deleted, max_delete_files is %d", ErrDeleteLimit, count, sc.config.MaxDeleteFiles)
+}
+
+// openAudit opens the configured audit log; it returns nil when there is none
+// or on a dry run
+func (sc *SystemCleaner) openAudit() (*auditLog, error) {
+	if sc.config.AuditLog == "" || sc.dryRun {
+		return nil, nil
+	}
+	audit, err := openAuditLog(sc.config.AuditLog, sc.session)
+	if err != nil {
+		return nil, &CleanError{Path: sc.config.AuditLog, Err: err}
+	}
+	return audit, nil
+}
+
+// audit records a deleted file in the audit log, if one is open
+func (sc *SystemCleaner) audit(audit *auditLog, path string, size int64) {
+	if audit == nil {
+		return
+	}
+	if err := audit.record(path, size); err != nil {
+		sc.logger.Printf("Error writing audit log %s: %v", sc.config.AuditLog, err)
+	}
+}
+
+// closeAudit closes the audit log, if one is open
+func (sc *SystemCleaner) closeAudit(audit *auditLog) {
+	if audit == nil {
+		return
+	}
+	if err := audit.close(); err != nil {
+		sc.logger.Printf("Error closing audit log %s: %v", sc.config.AuditLog, err)
+	}
+}
+
+// CleanJunk removes junk files, running the configured pre_hook before and
+// post_hook after. The clean is recorded in history_db when it is set, and
+// pushed to the metrics endpoint when there is one.
+func (sc *SystemCleaner) CleanJunk() (err error) {
+	started := time.Now()
+	failures := sc.failures.count()
+	sc.progress.reset()
+	defer func() {
+		sc.recordHistory(started, sc.failures.count()-failures, err)
+		sc.pushMetrics(started, sc.failures.count()-failures)
+	}()
+
+	if err := sc.runHook("pre-hook", sc.config.PreHook); err != nil {
+		return &CleanError{Path: "pre_hook", Err: err}
+	}
+	err = sc.cleanJunk()
+	if hookErr := sc.runHook("post-hook", sc.config.PostHook); hookErr != nil {
+		if err != nil {
+			// the clean's own error is returned; keep a record of this one
+			sc.logger.Printf("Error running post_hook: %v", hookErr)
+		} else {
+			err = &CleanError{Path: "post_hook", Err: hookErr}
+		}
+	}
+	return err
+}
+
+// applyRule returns the walkOptions.ruled hook of a junk clean: files a
+// rules_file rule reports or keeps are announced, and the ones it archives are
+// marked in archived
+func (sc *SystemCleaner) applyRule(archived map[string]bool) func(path string, info fs.FileInfo, rule *junkRule) {
+	return func(path string, info fs.FileInfo, rule *junkRule) {
+		switch rule.Action {
+		case ruleArchive:
+			archived[path] = true
+		case ruleReport:
+			sc.logger.Printf("Rule %s reports %s (%d bytes)", rule.Name, path, info.Size())
+			sc.emit(Event{Type: EventSkipped, Path: path, Size: sc.fileSize(info), Reason: "reported by rule " + rule.Name})
+			fmt.Fprintf(sc.out, "📝 %s: %s (%s)\n", rule.Name, path, sc.FormatSize(sc.fileSize(info)))
+		case ruleKeep:
+			sc.emit(Event{Type: EventSkipped, Path: path, Size: sc.fileSize(info), Reason: "kept by rule " + rule.Name})
+		}
+	}
+}
+
+// cleanJunk does the work of CleanJunk
+func (sc *SystemCleaner) cleanJunk() error {
+	fmt.Fprintln(sc.msg, "\n🗑️  Deleting junk files...")
+
+	fmt.Fprintln(sc.msg, "clean paths")
+	fmt.Fprintln(sc.msg, sc.config.cleanupDirs())
+
+	if err := sc.loadKeep(); err != nil {
+		return err
+	}
+
+	paths := sc.sizeLimitedPaths(sc.cooledDownPaths(sc.idlePaths(sc.writablePaths(sc.selectPaths()))))
+	dirs := make([]string, len(paths))
+	for i, cp := range paths {
+		dirs[i] = cp.Path
+	}
+
+	var selected map[string]bool
+	if sc.review != nil {
+		var err error
+		if selected, err = sc.reviewJunk(paths); err != nil {
+			return err
+		}
+		if len(selected) == 0 {
+			fmt.Fprintln(sc.msg, "ℹ️  No files selected, nothing to clean")
+			return nil
+		}
+		if err := sc.checkDeleteCount(len(selected)); err != nil {
+			return err
+		}
+	} else if err := sc.checkDeleteLimit(paths); err != nil {
+		return err
+	}
+
+	var archive *junkArchive
+	archives := sc.config.ArchiveBeforeDelete || sc.config.archivesByRule()
+	if archives && !sc.dryRun {
+		var err error
+		archive, err = newJunkArchive(sc.config.ArchiveDir, sc.config.MinFreeReserve)
+		if err != nil {
+			sc.logger.Printf("Not cleaning: cannot archive to %s: %v", sc.config.ArchiveDir, err)
+			return &CleanError{Path: sc.config.ArchiveDir, Err: err}
+		}
+	}
+	var preview *archivePreview
+	if archives && sc.dryRun {
+		preview = newArchivePreview(sc.config.ArchiveDir)
+	}
+
+	// a clean limited to part of a path (CleanWithin) finishes no path, so it
+	// neither resumes nor updates a checkpoint
+	var ck *checkpoint
+	if sc.config.CheckpointFile != "" && !sc.dryRun && !limited(paths) {
+		var err error
+		if ck, err = sc.loadCheckpoint(); err != nil {
+			return &CleanError{Path: sc.config.CheckpointFile, Err: err}
+		}
+		if n := len(ck.done); n > 0 {
+			fmt.Fprintf(sc.msg, "⏩ Resuming from checkpoint: %d paths and subtrees already cleaned\n", n)
+		}
+	}
+
+	audit, err := sc.openAudit()
+	if err != nil {
+		return err
+	}
+	defer sc.closeAudit(audit)
+
+	sc.progress.start(dirs)
+	defer sc.progress.finish()
+	defer sc.startBudget()()
+	started := time.Now()
+	freed := sc.startFreedCheck(paths)
+
+	var local, remote []CleanupPath
+	for _, cp := range paths {
+		if isNetworkPath(cp.Path) {
+			remote = append(remote, cp)
+		} else {
+			local = append(local, cp)
+		}
+	}
+
+	var remoteFiles, remoteBytes atomic.Int64
+	var counter typeCounter
+	var completedMu sync.Mutex
+	var completed []string // paths cleaned to the end, for the state file
+	var spared pathSet     // files dedupe leaves alone: deselected in review or junk in a dry run
+	cleanPath := func(ctx context.Context, cp CleanupPath, network bool) error {
+		var skip func(string) bool
+		var finish func()
+		if ck != nil {
+			if ck.isDone(cp.Path) {
+				sc.progress.pathDone(cp.Path)
+				return nil
+			}
+		}
+
+		types := make(map[string]string)  // content type of each sniffed file, until it is queued
+		archived := make(map[string]bool) // files matched by an archive rule, until they are queued
+		remove := func(remover *fileRemover, job deleteJob) {
+			path, info := job.path, job.info
+			if selected != nil && !selected[path] {
+				spared.add(path)
+				sc.emit(Event{Type: EventSkipped, Path: path, Size: sc.fileSize(info), Reason: "deselected in review"})
+				return
+			}
+			if sc.dryRun {
+				spared.add(path)
+				sc.emit(Event{Type: EventSkipped, Path: path, Size: sc.fileSize(info), Reason: "dry run"})
+				sc.scriptDelete(path, sc.fileSize(info), "")
+				if preview != nil && (sc.config.ArchiveBeforeDelete || job.archive) {
+					fmt.Fprintf(sc.out, "🧪 Would archive %s as %s and delete it (%s)\n", path, preview.add(info, path), sc.FormatSize(sc.fileSize(info)))
+				} else {
+					fmt.Fprintf(sc.out, "🧪 Would delete %s (%s)\n", path, sc.FormatSize(sc.fileSize(info)))
+				}
+				sc.progress.deletedFrom(cp.Path, sc.fileSize(info))
+				if job.mimeType != "" {
+					counter.add(job.mimeType)
+				}
+				return
+			}
+			if archive != nil && (sc.config.ArchiveBeforeDelete || job.archive) {
+				if err := archive.add(path, info); err != nil {
+					sc.logger.Printf("Error archiving file %s, keeping it: %v", path, err)
+					sc.emit(Event{Type: EventError, Path: path, Size: sc.fileSize(info), Reason: "archiving failed, file kept", Err: err})
+					return
+				}
+			}
+			if err := sc.removeFile(remover, path); err != nil {
+				sc.logger.Printf("Error removing file %s: %v", path, err)
+				sc.emit(Event{Type: EventError, Path: path, Size: sc.fileSize(info), Reason: "delete failed", Err: err})
+				return
+			}
+			sc.emit(Event{Type: EventDeleted, Path: path, Size: sc.fileSize(info)})
+			sc.audit(audit, path, sc.fileSize(info))
+			sc.progress.deletedFrom(cp.Path, sc.fileSize(info))
+			freed.deleted(cp.Path, allocatedSize(info))
+			if job.mimeType != "" {
+				counter.add(job.mimeType)
+			}
+			if network {
+				remoteFiles.Add(1)
+				remoteBytes.Add(sc.fileSize(info))
+			}
+		}
+
+		workers := sc.config.deleteWorkers()
+		if network {
+			workers = 1
+		}
+		pool := newDeletePool(workers, remove)
+		if ck != nil {
+			var flush func()
+			if !sc.config.DetectGrowing { // growing files are only queued once the walk ends
+				flush = pool.flush
+			}
+			skip, finish = sc.subtrees(ck, cp.Path, flush)
+		}
+		queue := func(path string, info fs.FileInfo) {
+			pool.add(deleteJob{path: path, info: info, mimeType: types[path], archive: archived[path]})
+			delete(types, path)
+			delete(archived, path)
+		}
+
+		var sampled []junkFile
+		var kept int
+		opts := walkOptions{ctx: ctx, skip: skip}
+		opts.kept = func(path string) {
+			kept++
+			sc.emit(Event{Type: EventSkipped, Path: path, Reason: "keep_recent"})
+		}
+		opts.sniffed = func(path, mimeType string) { types[path] = mimeType }
+		opts.matched = func(path string, re *regexp.Regexp, match []string) {
+			sc.logger.Printf("Matched %s with path_regex %q: %s", path, re, formatGroups(re, match))
+		}
+		opts.ruled = sc.applyRule(archived)
+		err := sc.walkJunkWith(cp, opts, func(path string, info fs.FileInfo) {
+			sc.emit(Event{Type: EventScanned, Path: path, Size: sc.fileSize(info)})
+			sc.progress.foundIn(cp.Path, sc.fileSize(info))
+			if sc.config.DetectGrowing {
+				sampled = append(sampled, junkFile{path: path, info: info})
+				return
+			}
+			queue(path, info)
+		})
+		if errors.Is(err, ErrInterrupted) || errors.Is(err, ErrTimeout) || errors.Is(err, ErrBudget) {
+			pool.wait()
+			return err
+		}
+		if sc.config.DetectGrowing {
+			stable, ok := sc.stableFiles(sampled)
+			if !ok {
+				pool.wait()
+				return ErrInterrupted
+			}
+			for _, f := range stable {
+				queue(f.path, f.info)
+			}
+		}
+		pool.wait()
+		if errors.Is(err, ErrTooDeep) {
+			fmt.Fprintf(sc.msg, "⚠️  Stopped cleaning %s: %v\n", cp.Path, err)
+			sc.emit(Event{Type: EventError, Path: cp.Path, Reason: "over max_walk_depth", Err: err})
+		}
+		if err != nil {
+			sc.logger.Printf("Error accessing path %s: %v", cp.Path, err)
+		}
+		if kept > 0 {
+			sc.logger.Printf("Preserved %d recent files in %s (keep_recent)", kept, cp.Path)
+		}
+		if finish != nil {
+			finish()
+		}
+		if err == nil {
+			sc.progress.walked(cp.Path)
+		}
+		sc.progress.pathDone(cp.Path)
+		if cp.limit == "" {
+			completedMu.Lock()
+			completed = append(completed, cp.Path)
+			completedMu.Unlock()
+		}
+		return nil
+	}
+	cleanWithTimeout := func(cp CleanupPath, network bool) {
+		err := runWithTimeout(sc.config.CleanTimeout, func(ctx context.Context) error {
+			return cleanPath(ctx, cp, network)
+		})
+		if errors.Is(err, ErrTimeout) {
+			// give up on the path rather than report the clean as interrupted
+			sc.logger.Printf("Gave up cleaning %s: %v", cp.Path, err)
+			fmt.Fprintf(sc.msg, "⚠️  Gave up cleaning %s: %v\n", cp.Path, err)
+			sc.emit(Event{Type: EventSkipped, Path: cp.Path, Reason: "clean_timeout reached", Err: err})
+			sc.progress.pathDone(cp.Path)
+		}
+	}
+
+	runParallel(len(local), sc.config.cleanWorkers(), func(i int) { cleanWithTimeout(local[i], false) })
+	runParallel(len(remote), sc.config.networkWorkers(), func(i int) { cleanWithTimeout(remote[i], true) })
+
+	if len(remote) > 0 && !sc.dryRun {
+		// without a local cache every delete costs the server a lookup and a remove
+		fmt.Fprintf(sc.out, "🌐 Network paths: %d files (%s) deleted, about %d remote operations\n",
+			remoteFiles.Load(), sc.FormatSize(remoteBytes.Load()), 2*remoteFiles.Load())
+	}
+
+	if archive != nil {
+		size, err := sc.closeArchive(archive)
+		if err != nil {
+			return err
+		}
+		freed.written(archive.dir, size)
+	}
+	if preview != nil {
+		sc.reportArchivePreview(preview)
+	}
+	if sc.config.Dedupe && len(sc.Progress().PathsPending) == 0 {
+		if err := sc.dedupe(paths, &spared, audit, freed); err != nil {
+			return err
+		}
+	}
+	sc.finishFreedCheck(freed)
+
+	sc.reportTypes(&counter)
+
+	pending := sc.Progress().PathsPending
+	if len(pending) == 0 {
+		sc.runRecipes()
+		sc.trimCleaned()
+	}
+	if !sc.dryRun {
+		completedMu.Lock()
+		err := sc.state.recordClean(completed, started)
+		completedMu.Unlock()
+		if err != nil {
+			sc.logger.Printf("Error updating state file %s: %v", sc.config.StateFile, err)
+		}
+	}
+	if ck != nil {
+		var err error
+		if len(pending) > 0 {
+			err = ck.save()
+		} else {
+			err = ck.remove()
+		}
+		if err != nil {
+			sc.logger.Printf("Error updating checkpoint %s: %v", ck.file, err)
+		}
+	}
+	if len(pending) > 0 && sc.overBudget() {
+		sc.reportBudget(len(pending))
+		return nil
+	}
+	if len(pending) > 0 {
+		return &CleanError{Path: pending[0], Err: ErrInterrupted}
+	}
+
+	if sc.dryRun {
+		p := sc.Progress()
+		fmt.Fprintf(sc.out, "🧪 Dry run: %d files (%s) would be deleted\n", p.FilesDeleted, sc.FormatSize(p.BytesFreed))
+		return nil
+	}
+
+	sc.reportNotDeleted()
+	fmt.Fprintln(sc.out, "✅ Junk files cleaned successfully!")
+	return nil
+}
+
+// ScanLargeFiles finds and reports large files in a directory
+func (sc *SystemCleaner) ScanLargeFiles(directory string) error {
+	return sc.ScanAndSave(directory, "")
+}
+
+// ScanAndSave is ScanLargeFiles that also records every file it walks to a
+// JSON baseline at savePath, when it is set, so the directory is walked once.
+// A scan cut short by -max-files is not saved.
+func (sc *SystemCleaner) ScanAndSave(directory, savePath string) error {
+	fmt.Fprintln(sc.msg, "\n🔎 Scanning for large files in:", directory)
+
+	stop := sc.startLoading("Analyzing files...")
+
+	var snapshot *ScanSnapshot
+	if savePath != "" {
+		snapshot = &ScanSnapshot{Directory: directory, Created: time.Now()}
+	}
+	top := newTopFiles(sc.config.TopFiles, bySize)
+	err := runWithTimeout(sc.config.ScanTimeout, func(ctx context.Context) error {
+		return sc.walkContext(ctx, directory, sc.capped(func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				sc.logger.Printf("Error accessing path %s: %v", path, err)
+				sc.emit(Event{Type: EventError, Path: path, Reason: "cannot read", Err: err})
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				sc.logger.Printf("Error accessing path %s: %v", path, err)
+				sc.emit(Event{Type: EventError, Path: path, Reason: "cannot read file info", Err: err})
+				return nil
+			}
+			sc.emit(Event{Type: EventScanned, Path: path, Size: info.Size()})
+			if snapshot != nil {
+				snapshot.Files = append(snapshot.Files, FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+			}
+			if info.Size() > sc.config.largeThreshold(path) {
+				top.add(FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+			}
+			return nil
+		}))
+	})
+
+	stop <- true
+	<-stop
+
+	truncated := sc.checkTruncated(directory, &err)
+	if truncated {
+		defer sc.warnTruncated()
+	}
+	if err != nil {
+		return &ScanError{Path: directory, Err: err}
+	}
+	if snapshot != nil {
+		if truncated {
+			return fmt.Errorf("not saving the scan to %s: it is incomplete (-max-files)", savePath)
+		}
+		if err := sc.writeSnapshot(snapshot, savePath); err != nil {
+			return err
+		}
+	}
+
+	files := top.sorted()
+	if sc.jsonOutput {
+		encoder := json.NewEncoder(sc.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(files)
+	}
+	if sc.template != nil {
+		return sc.render(Report{Kind: "scan", Scan: &ScanReport{Directory: directory, Files: files}})
+	}
+
+	if sc.config.TopFiles > 0 {
+		fmt.Fprintf(sc.out, "\n📂 Top %d largest files:\n", sc.config.TopFiles)
+	} else {
+		fmt.Fprintf(sc.out, "\n📂 All %d large files:\n", len(files))
+	}
+	for _, file := range files {
+		fmt.Fprintf(sc.out, "📄 %s → %s\n", file.Path, sc.FormatSize(file.Size))
+	}
+
+	return nil
+}
@@ -0,0 +1,201 @@
+package cleaner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// newTestCleaner returns a cleaner for the given YAML config, logging to a
+// temporary directory and discarding its output
+func newTestCleaner(tb testing.TB, config string) *SystemCleaner {
+	tb.Helper()
+	dir := tb.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	config += "\nlog_file: " + filepath.Join(dir, "cleaner.log") + "\n"
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		tb.Fatal(err)
+	}
+	sc, err := NewSystemCleaner(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	sc.SetOutput(io.Discard, io.Discard)
+	tb.Cleanup(func() { sc.Close() })
+	return sc
+}
+
+// makeTree creates dirs directories of files files each under root, every
+// file a different size, and returns the size of every file by path
+func makeTree(tb testing.TB, root string, dirs, files int) map[string]int64 {
+	tb.Helper()
+	sizes := make(map[string]int64, dirs*files)
+	for d := 0; d < dirs; d++ {
+		dir := filepath.Join(root, fmt.Sprintf("dir%03d", d))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			tb.Fatal(err)
+		}
+		for f := 0; f < files; f++ {
+			path := filepath.Join(dir, fmt.Sprintf("file%04d.tmp", f))
+			data := strings.Repeat("x", 1+(d*files+f)%97)
+			if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+				tb.Fatal(err)
+			}
+			sizes[path] = int64(len(data))
+		}
+	}
+	return sizes
+}
+
+// cleanConfig is the config of a clean of roots with the given worker counts
+func cleanConfig(roots []string, cleanWorkers, deleteWorkers int) string {
+	return fmt.Sprintf("cleanup_paths: [%s]\nclean_workers: %d\ndelete_workers: %d\n",
+		strings.Join(roots, ", "), cleanWorkers, deleteWorkers)
+}
+
+// recordEvents counts the events of type t per path
+func recordEvents(sc *SystemCleaner, t EventType) (counts map[string]int, sizes map[string]int64) {
+	var mu sync.Mutex
+	counts = make(map[string]int)
+	sizes = make(map[string]int64)
+	sc.SetEventHandler(func(e Event) {
+		if e.Type != t {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		counts[e.Path]++
+		sizes[e.Path] = e.Size
+	})
+	return counts, sizes
+}
+
+func TestCleanJunkWorkers(t *testing.T) {
+	tests := []struct {
+		name          string
+		roots         int
+		dirs, files   int
+		cleanWorkers  int
+		deleteWorkers int
+	}{
+		{"serial", 1, 3, 10, 1, 1},
+		{"small path stays serial", 1, 1, minPoolFiles / 2, 1, 8},
+		{"delete pool", 1, 2, minPoolFiles, 1, 8},
+		{"parallel paths", 4, 2, 50, 4, 1},
+		{"parallel paths and delete pool", 3, 1, 2 * minPoolFiles, 3, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var roots []string
+			want := make(map[string]int64)
+			for r := 0; r < tt.roots; r++ {
+				root := t.TempDir()
+				roots = append(roots, root)
+				for path, size := range makeTree(t, root, tt.dirs, tt.files) {
+					want[path] = size
+				}
+			}
+			var wantBytes int64
+			for _, size := range want {
+				wantBytes += size
+			}
+
+			// a serial dry run walks the same files the parallel clean deletes
+			serial := newTestCleaner(t, cleanConfig(roots, 1, 1))
+			serial.SetDryRun(true)
+			walked, _ := recordEvents(serial, EventSkipped)
+			if err := serial.CleanJunk(); err != nil {
+				t.Fatalf("serial dry run: %v", err)
+			}
+
+			sc := newTestCleaner(t, cleanConfig(roots, tt.cleanWorkers, tt.deleteWorkers))
+			deleted, sizes := recordEvents(sc, EventDeleted)
+			if err := sc.CleanJunk(); err != nil {
+				t.Fatalf("CleanJunk: %v", err)
+			}
+
+			for path, size := range want {
+				if walked[path] != 1 {
+					t.Errorf("serial walk reached %s %d times, want 1", path, walked[path])
+				}
+				if deleted[path] != 1 {
+					t.Errorf("%s deleted %d times, want 1", path, deleted[path])
+				}
+				if sizes[path] != size {
+					t.Errorf("%s deleted with size %d, want %d", path, sizes[path], size)
+				}
+				if _, err := os.Lstat(path); !os.IsNotExist(err) {
+					t.Errorf("%s still exists: %v", path, err)
+				}
+			}
+			if len(deleted) != len(want) || len(walked) != len(want) {
+				t.Errorf("deleted %d and walked %d files, want %d", len(deleted), len(walked), len(want))
+			}
+
+			p := sc.Progress()
+			if p.FilesDeleted != len(want) || p.BytesFreed != wantBytes {
+				t.Errorf("progress counted %d files and %d bytes, want %d and %d", p.FilesDeleted, p.BytesFreed, len(want), wantBytes)
+			}
+			var pathFiles int
+			var pathBytes int64
+			for _, total := range sc.progress.pathTotals() {
+				pathFiles += total.files
+				pathBytes += total.bytes
+			}
+			if pathFiles != len(want) || pathBytes != wantBytes {
+				t.Errorf("per-path totals add up to %d files and %d bytes, want %d and %d", pathFiles, pathBytes, len(want), wantBytes)
+			}
+		})
+	}
+}
+
+func TestCleanJunkWorkersCountErrors(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can delete from read-only directories")
+	}
+	root := t.TempDir()
+	want := makeTree(t, root, 1, 2*minPoolFiles)
+	dir := filepath.Join(root, "dir000")
+	if err := os.Chmod(dir, 0555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(dir, 0755) })
+
+	sc := newTestCleaner(t, cleanConfig([]string{root}, 1, 8))
+	failed, _ := recordEvents(sc, EventError)
+	if err := sc.CleanJunk(); err != nil {
+		t.Fatalf("CleanJunk: %v", err)
+	}
+	for path := range want {
+		if failed[path] != 1 {
+			t.Errorf("%s failed %d times, want 1", path, failed[path])
+		}
+	}
+	if n := sc.failures.count(); n != len(want) {
+		t.Errorf("error summary counts %d failures, want %d", n, len(want))
+	}
+	if p := sc.Progress(); p.FilesDeleted != 0 || p.BytesFreed != 0 {
+		t.Errorf("progress counted %d files and %d bytes, want none", p.FilesDeleted, p.BytesFreed)
+	}
+}
+
+func BenchmarkCleanJunk(b *testing.B) {
+	for _, workers := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("delete_workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				root := b.TempDir()
+				makeTree(b, root, 4, 1000)
+				sc := newTestCleaner(b, cleanConfig([]string{root}, 1, workers))
+				b.StartTimer()
+				if err := sc.CleanJunk(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ConfigDiff is the junk one cleanup path (or recipe) holds under each of two
+// configs. A side without the path has -1.
+type ConfigDiff struct {
+	Path string `json:"path"`
+	A    int64  `json:"a"`
+	B    int64  `json:"b"`
+	Diff int64  `json:"diff"` // B minus A, counting a missing side as 0
+}
+
+// ConfigComparison is the result of CompareConfigs, the paths where the
+// configs disagree most first
+type ConfigComparison struct {
+	A      string       `json:"a"`
+	B      string       `json:"b"`
+	Paths  []ConfigDiff `json:"paths"`
+	TotalA int64        `json:"total_a"`
+	TotalB int64        `json:"total_b"`
+}
+
+// measureConfig loads a config and measures the junk of its cleanup paths and
+// recipes, the same estimate a dry run makes, keyed by path
+func (sc *SystemCleaner) measureConfig(path string) (map[string]int64, error) {
+	other, err := NewSystemCleaner(path)
+	if err != nil {
+		return nil, err
+	}
+	defer other.Close()
+	other.stopChan = sc.stopChan // Ctrl+C stops the measuring
+	other.emptyFiles = sc.emptyFiles
+	other.sinceBoot = sc.sinceBoot
+	other.oneFilesystem = sc.oneFilesystem
+
+	sizes := make(map[string]int64)
+	for i, usage := range other.pathUsage() {
+		dir := filepath.Clean(expandHome(other.config.CleanupPaths[i].Path))
+		sizes[dir] += usage.size
+	}
+	for _, usage := range other.recipeUsage() {
+		sizes["recipe "+usage.Name] += usage.Size
+	}
+	return sizes, nil
+}
+
+// CompareConfigs measures how much junk each of two configs would clean and
+// prints them side by side, per cleanup path and in total, with the paths
+// where they disagree most first. Nothing is deleted.
+func (sc *SystemCleaner) CompareConfigs(a, b string) (*ConfigComparison, error) {
+	fmt.Fprintf(sc.msg, "\n⚖️  Measuring the junk of %s and %s...\n", a, b)
+	sizesA, err := sc.measureConfig(a)
+	if err != nil {
+		return nil, err
+	}
+	sizesB, err := sc.measureConfig(b)
+	if err != nil {
+		return nil, err
+	}
+
+	cmp := &ConfigComparison{A: a, B: b}
+	for path, size := range sizesA {
+		d := ConfigDiff{Path: path, A: size, B: -1, Diff: -size}
+		if other, ok := sizesB[path]; ok {
+			d.B = other
+			d.Diff = other - size
+		}
+		cmp.Paths = append(cmp.Paths, d)
+		cmp.TotalA += size
+	}
+	for path, size := range sizesB {
+		if _, ok := sizesA[path]; !ok {
+			cmp.Paths = append(cmp.Paths, ConfigDiff{Path: path, A: -1, B: size, Diff: size})
+		}
+		cmp.TotalB += size
+	}
+	sort.Slice(cmp.Paths, func(i, j int) bool {
+		di, dj := abs64(cmp.Paths[i].Diff), abs64(cmp.Paths[j].Diff)
+		if di != dj {
+			return di > dj
+		}
+		return cmp.Paths[i].Path < cmp.Paths[j].Path
+	})
+
+	if sc.jsonOutput {
+		encoder := json.NewEncoder(sc.out)
+		encoder.SetIndent("", "  ")
+		return cmp, encoder.Encode(cmp)
+	}
+	sc.listComparison(cmp)
+	return cmp, nil
+}
+
+// listComparison prints a comparison as a table. Rows where one config
+// cleans at least twice as much as the other are flagged.
+func (sc *SystemCleaner) listComparison(cmp *ConfigComparison) {
+	size := func(n int64) string {
+		if n < 0 {
+			return "-"
+		}
+		return sc.FormatSize(n)
+	}
+	diff := func(n int64) string {
+		if n > 0 {
+			return "+" + sc.FormatSize(n)
+		}
+		return sc.FormatSize(n)
+	}
+	rows := [][]string{{"PATH", "A", "B", "B-A"}}
+	for _, d := range cmp.Paths {
+		rows = append(rows, []string{d.Path, size(d.A), size(d.B), diff(d.Diff)})
+	}
+	rows = append(rows, []string{"Total", sc.FormatSize(cmp.TotalA), sc.FormatSize(cmp.TotalB), diff(cmp.TotalB - cmp.TotalA)})
+
+	widths := make([]int, 4)
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len([]rune(cell)))
+		}
+	}
+	fmt.Fprintf(sc.out, "\nA: %s\nB: %s\n\n", cmp.A, cmp.B)
+	for r, row := range rows {
+		line := fmt.Sprintf("%-*s  %*s  %*s  %*s", widths[0], row[0], widths[1], row[1], widths[2], row[2], widths[3], row[3])
+		if r > 0 && r <= len(cmp.Paths) && disagrees(cmp.Paths[r-1]) {
+			line += "  🔥"
+		}
+		fmt.Fprintln(sc.out, strings.TrimRight(line, " "))
+	}
+}
+
+// disagrees reports whether one side cleans at least twice as much as the
+// other, or the path is only in one config and holds junk there
+func disagrees(d ConfigDiff) bool {
+	a, b := max(d.A, 0), max(d.B, 0)
+	return d.Diff != 0 && (a == 0 || b == 0 || a >= 2*b || b >= 2*a)
+}
+
+// abs64 returns the absolute value of n
+func abs64(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,141 @@
+package cleaner
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+const (
+	compressSample     = 64 << 10 // bytes of each file compressed to estimate its ratio
+	compressMinSavings = 0.25     // share a file must shrink by to be reported
+)
+
+// CompressibleFile is a junk file that would shrink a lot if compressed
+type CompressibleFile struct {
+	Path       string  `json:"path"`
+	Size       int64   `json:"size"`
+	Compressed int64   `json:"compressed"` // estimated gzip size
+	Savings    int64   `json:"savings"`
+	Ratio      float64 `json:"ratio"` // compressed / size
+}
+
+// CompressReport lists the compressible junk of the cleanup paths
+type CompressReport struct {
+	Sampled      int                `json:"sampled"`
+	Files        []CompressibleFile `json:"files"`
+	TotalSavings int64              `json:"total_savings"`
+}
+
+// compressRatio gzips the first compressSample bytes of a file and returns
+// the compressed size of the sample divided by its length
+func compressRatio(path string) (float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var counter countingWriter
+	gz := gzip.NewWriter(&counter)
+	n, err := io.Copy(gz, io.LimitReader(file, compressSample))
+	if err != nil {
+		return 0, err
+	}
+	if err := gz.Close(); err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 1, nil
+	}
+	return float64(counter) / float64(n), nil
+}
+
+// countingWriter discards what is written to it, counting the bytes
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// ScanCompressible samples the junk files above max_file_size in every
+// cleanup path and reports the ones gzip would shrink by at least a quarter,
+// with the space compressing them would save. It reads file contents, so it
+// is slower than the other reports; nothing is changed.
+func (sc *SystemCleaner) ScanCompressible() error {
+	var stop chan bool
+	if !sc.jsonOutput {
+		fmt.Fprintln(sc.msg, "\n🗜️  Sampling large junk files for compressibility...")
+		stop = sc.startLoading("Compressing samples...")
+	}
+
+	var report CompressReport
+	var scanErr error
+	for _, cp := range sc.config.CleanupPaths {
+		err := sc.walkJunk(cp, func(path string, info fs.FileInfo) {
+			if !info.Mode().IsRegular() || info.Size() <= sc.config.MaxFileSize {
+				return
+			}
+			ratio, err := compressRatio(path)
+			if err != nil {
+				sc.logger.Printf("Error reading file %s: %v", path, err)
+				sc.emit(Event{Type: EventError, Path: path, Reason: "cannot sample", Err: err})
+				return
+			}
+			report.Sampled++
+			if ratio > 1-compressMinSavings {
+				return
+			}
+			compressed := int64(float64(info.Size()) * ratio)
+			report.Files = append(report.Files, CompressibleFile{
+				Path:       path,
+				Size:       info.Size(),
+				Compressed: compressed,
+				Savings:    info.Size() - compressed,
+				Ratio:      ratio,
+			})
+			report.TotalSavings += info.Size() - compressed
+		})
+		if err != nil {
+			sc.logger.Printf("Error scanning directory %s: %v", cp.Path, err)
+			if scanErr == nil {
+				scanErr = &ScanError{Path: cp.Path, Err: err}
+			}
+		}
+	}
+
+	if stop != nil {
+		stop <- true
+		<-stop
+	}
+
+	sort.Slice(report.Files, func(i, j int) bool {
+		return report.Files[i].Savings > report.Files[j].Savings
+	})
+
+	if sc.jsonOutput {
+		encoder := json.NewEncoder(sc.out)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+		return scanErr
+	}
+
+	if len(report.Files) == 0 {
+		fmt.Fprintf(sc.out, "\n✨ None of the %d large junk files would shrink by %.0f%% or more\n", report.Sampled, compressMinSavings*100)
+		return scanErr
+	}
+	fmt.Fprintf(sc.out, "\n🗜️  %d of %d large junk files are compressible, saving about %s:\n",
+		len(report.Files), report.Sampled, sc.FormatSize(report.TotalSavings))
+	for _, file := range report.Files {
+		fmt.Fprintf(sc.out, "📄 %s: %s → ~%s (saves ~%s, %.0f%%)\n", file.Path,
+			sc.FormatSize(file.Size), sc.FormatSize(file.Compressed), sc.FormatSize(file.Savings), (1-file.Ratio)*100)
+	}
+	return scanErr
+}
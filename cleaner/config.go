@@ -0,0 +1,312 @@
+package cleaner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config holds the application configuration
+type Config struct {
+	CleanupPaths []CleanupPath `yaml:"cleanup_paths" json:"cleanup_paths"`
+	// Named sets of cleanup paths with their own rules, run by the clean command
+	Jobs []Job `yaml:"jobs" json:"jobs,omitempty"`
+
+	// Cleanup paths that are files are skipped unless this is set
+	CleanFilePaths bool   `yaml:"clean_file_paths" json:"clean_file_paths"`
+	MaxFileSize    int64  `yaml:"max_file_size" json:"max_file_size"` // in bytes
+	TopFiles       int    `yaml:"top_files" json:"top_files"`
+	LogFile        string `yaml:"log_file" json:"log_file"`
+
+	// Large-file thresholds per extension, e.g. ".log": "10MB"; "*" replaces
+	// max_file_size for unlisted extensions
+	MaxFileSizeByExt map[string]string `yaml:"max_file_size_by_ext" json:"max_file_size_by_ext,omitempty"`
+
+	Banner  *string `yaml:"banner" json:"banner,omitempty"` // replaces the startup banner; "" hides it
+	Spinner string  `yaml:"spinner" json:"spinner"`         // braille (default), ascii, dots or none
+
+	// Defaults for cleanup paths that do not set their own rules
+	MinAgeDays      int      `yaml:"min_age_days" json:"min_age_days"`
+	ExcludePatterns []string `yaml:"exclude_patterns" json:"exclude_patterns"`
+	MinSize         int64    `yaml:"min_size" json:"min_size"`           // in bytes
+	KeepRecent      int      `yaml:"keep_recent" json:"keep_recent"`     // newest files kept in every directory
+	ContentTypes    []string `yaml:"content_types" json:"content_types"` // sniffed MIME types to clean, e.g. "application/zip"
+	PathRegex       []string `yaml:"path_regex" json:"path_regex"`       // regular expressions one of which the full path must match
+
+	SizeMode string `yaml:"size_mode" json:"size_mode"` // "apparent" (default) or "allocated" disk usage, like du
+
+	// File listing absolute paths, one per line, that cleans never delete
+	KeepList string `yaml:"keep_list" json:"keep_list"`
+
+	// YAML file of named rules deciding, in order, what becomes of every file
+	// that passes the cleanup path's own rules
+	RulesFile string `yaml:"rules_file" json:"rules_file"`
+
+	// Relative path patterns skipped by every walk, e.g. "**/node_modules/**"
+	SkipPaths []string `yaml:"skip_paths" json:"skip_paths"`
+
+	FreeSpaceStrategy string `yaml:"free_space_strategy" json:"free_space_strategy"` // "oldest" or "largest"
+	FreeSpaceBatch    int    `yaml:"free_space_batch" json:"free_space_batch"`       // files deleted between free-space checks
+
+	// Escalation steps for the free-space target, tried in order until it is met
+	FreeSpaceTiers []FreeSpaceTier `yaml:"free_space_tiers" json:"free_space_tiers,omitempty"`
+
+	IOProfile    string `yaml:"io_profile" json:"io_profile"`       // "ssd" (default) or "hdd"
+	ScanWorkers  int    `yaml:"scan_workers" json:"scan_workers"`   // paths scanned at once, overrides io_profile
+	CleanWorkers int    `yaml:"clean_workers" json:"clean_workers"` // paths cleaned at once, overrides io_profile
+
+	// Files of one large path deleted at once, overrides io_profile
+	DeleteWorkers int `yaml:"delete_workers" json:"delete_workers"`
+
+	// CPU and IO priority of the whole run, applied at startup (Linux only)
+	NiceLevel int    `yaml:"nice_level" json:"nice_level"` // -20 to 19, 0 = unchanged
+	IOClass   string `yaml:"io_class" json:"io_class"`     // "idle" or "best-effort", "" = unchanged
+
+	NetworkWorkers int `yaml:"network_workers" json:"network_workers"` // network (NFS/SMB) paths cleaned at once, default 1
+
+	ArchiveBeforeDelete bool   `yaml:"archive_before_delete" json:"archive_before_delete"` // pack junk into a .tar.gz before deleting it
+	ArchiveDir          string `yaml:"archive_dir" json:"archive_dir"`
+	MinFreeReserve      int64  `yaml:"min_free_reserve" json:"min_free_reserve"` // bytes the archive must leave free on its disk
+
+	MaxDeleteFiles int   `yaml:"max_delete_files" json:"max_delete_files"` // abort cleans deleting more files than this (0 = no limit)
+	MaxPathSize    int64 `yaml:"max_path_size" json:"max_path_size"`       // skip cleanup paths with more junk than this, in bytes (0 = no limit)
+
+	DetectGrowing bool `yaml:"detect_growing" json:"detect_growing"` // skip files that grow while a clean samples them
+
+	VerifyDeletion  bool `yaml:"verify_deletion" json:"verify_deletion"`     // re-stat every deleted file to make sure it is gone
+	CheckFreedSpace bool `yaml:"check_freed_space" json:"check_freed_space"` // compare free space before and after a clean
+
+	// Per-path limits after which a stuck walk is abandoned, e.g. "10m" (0 = none)
+	ScanTimeout  time.Duration `yaml:"scan_timeout" json:"scan_timeout"`
+	CleanTimeout time.Duration `yaml:"clean_timeout" json:"clean_timeout"`
+
+	// Shell commands run before and after every clean; a failing pre_hook aborts it
+	PreHook  string `yaml:"pre_hook" json:"pre_hook"`
+	PostHook string `yaml:"post_hook" json:"post_hook"`
+
+	AuditLog string `yaml:"audit_log" json:"audit_log"` // JSON-lines record of every deleted file
+
+	CheckpointFile string `yaml:"checkpoint_file" json:"checkpoint_file"` // lets an interrupted clean resume
+
+	// Records when each cleanup path was last cleaned successfully; with
+	// only_before_last_clean a clean only considers files modified before then
+	StateFile           string `yaml:"state_file" json:"state_file"`
+	OnlyBeforeLastClean bool   `yaml:"only_before_last_clean" json:"only_before_last_clean"`
+	// Cleanup paths cleaned within this window are skipped, e.g. "1h" (0 = off)
+	CleanCooldown time.Duration `yaml:"clean_cooldown" json:"clean_cooldown"`
+
+	// Delete all but one copy of identical files across the cleanup paths,
+	// keeping the oldest (default) or newest
+	Dedupe      bool   `yaml:"dedupe" json:"dedupe"`
+	DedupeKeep  string `yaml:"dedupe_keep" json:"dedupe_keep"`
+	HashWorkers int    `yaml:"hash_workers" json:"hash_workers"` // files hashed at once by dedupe, overrides io_profile
+
+	HistoryDB string `yaml:"history_db" json:"history_db"` // SQLite database recording every clean
+
+	Metrics Metrics `yaml:"metrics" json:"metrics"` // pushed after every clean
+
+	// Built-in cleanups: apt, dnf, journal and snap (run with sudo) and docker
+	Recipes       []string `yaml:"recipes" json:"recipes"`
+	JournalVacuum string   `yaml:"journal_vacuum" json:"journal_vacuum"` // journalctl --vacuum-time value, default "2weeks"
+
+	RemoteHosts []RemoteHost `yaml:"remote_hosts" json:"remote_hosts"` // reported by the remote-usage command
+
+	// Walks never descend into mounts of another filesystem, like find -xdev
+	StayOnFilesystem bool `yaml:"stay_on_filesystem" json:"stay_on_filesystem"`
+
+	MaxWalkDepth int `yaml:"max_walk_depth" json:"max_walk_depth"` // directory levels a walk may descend before it aborts (0 = no limit)
+
+	// Cleanup paths modified within this window are skipped as busy, e.g. "5m" (0 = off)
+	SkipActiveWindow time.Duration `yaml:"skip_active_window" json:"skip_active_window"`
+
+	// The system monitor optimizes memory once RAM usage stays at or above this
+	// percent for auto_optimize_sustain, at most once per auto_optimize_cooldown
+	AutoOptimizeThreshold float64       `yaml:"auto_optimize_threshold" json:"auto_optimize_threshold"` // 0 = off
+	AutoOptimizeSustain   time.Duration `yaml:"auto_optimize_sustain" json:"auto_optimize_sustain"`     // default 30s
+	AutoOptimizeCooldown  time.Duration `yaml:"auto_optimize_cooldown" json:"auto_optimize_cooldown"`   // default 15m
+
+	// Window of each CPU reading in the system monitor, default 1s; 0 measures
+	// since the previous reading without blocking
+	CPUSampleInterval *time.Duration `yaml:"cpu_sample_interval" json:"cpu_sample_interval,omitempty"`
+	CPUPerCore        bool           `yaml:"cpu_per_core" json:"cpu_per_core"` // also show every core's percent
+
+	// The system monitor fits the growth of each disk over fill_window and
+	// warns once it is projected full within fill_warn_horizon
+	FillWindow      time.Duration `yaml:"fill_window" json:"fill_window"`             // default 10m
+	FillWarnHorizon time.Duration `yaml:"fill_warn_horizon" json:"fill_warn_horizon"` // default 24h
+
+	notes   []string // problems met while loading, logged and shown once the log is open
+	logs    []string // details of the loading, only logged
+	source  string   // where the config was loaded from, for ExplainConfig
+	logPath string   // log_file with its placeholders expanded, set by NewSystemCleaner
+
+	configuredDirs []string // cleanup paths as written, before glob expansion, for remote hosts
+
+	extLimits map[string]int64          // max_file_size_by_ext in bytes, keyed by lowercased extension
+	regexes   map[string]*regexp.Regexp // every path_regex pattern, compiled
+	junkRules []junkRule                // loaded from rules_file
+}
+
+// cleanupDirs returns the directories of all cleanup paths
+func (c *Config) cleanupDirs() []string {
+	dirs := make([]string, len(c.CleanupPaths))
+	for i, cp := range c.CleanupPaths {
+		dirs[i] = cp.Path
+	}
+	return dirs
+}
+
+// loadConfig loads the configuration from a YAML file, from stdin when path
+// is "-", from an http(s) URL or from nothing when it is "", then applies
+// environment overrides
+func loadConfig(path string) (*Config, error) {
+	if isConfigURL(path) {
+		return loadConfigURL(path)
+	}
+
+	var file []byte
+	var err error
+	switch path {
+	case "":
+	case "-":
+		file, err = io.ReadAll(os.Stdin)
+	default:
+		file, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	return parseConfig(path, file)
+}
+
+// parseConfig decodes and validates a configuration read from path
+func parseConfig(path string, file []byte) (*Config, error) {
+	config := &Config{source: path}
+	if err := yaml.Unmarshal(file, config); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	if err := config.applyEnv(); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+
+	if config.LogFile == "" {
+		config.LogFile = "cleaner.log"
+	}
+	if _, err := strftime(config.LogFile, time.Time{}); err != nil {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("log_file: %w", err)}
+	}
+
+	if config.FreeSpaceStrategy == "" {
+		config.FreeSpaceStrategy = "oldest"
+	}
+	if config.FreeSpaceStrategy != "oldest" && config.FreeSpaceStrategy != "largest" {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("invalid free_space_strategy %q (want oldest or largest)", config.FreeSpaceStrategy)}
+	}
+	if config.FreeSpaceBatch <= 0 {
+		config.FreeSpaceBatch = 100
+	}
+	if config.SizeMode == "" {
+		config.SizeMode = "apparent"
+	}
+	if config.SizeMode != "apparent" && config.SizeMode != "allocated" {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("invalid size_mode %q (want apparent or allocated)", config.SizeMode)}
+	}
+	for i := range config.FreeSpaceTiers {
+		tier := &config.FreeSpaceTiers[i]
+		if tier.Name == "" {
+			tier.Name = fmt.Sprintf("tier %d", i+1)
+		}
+		for _, pattern := range tier.Patterns {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, &ConfigError{Path: path, Err: fmt.Errorf("free_space_tiers %s: invalid pattern %q", tier.Name, pattern)}
+			}
+		}
+	}
+	if _, ok := spinnerFrames[config.Spinner]; config.Spinner != "" && !ok {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("invalid spinner %q (want braille, ascii, dots or none)", config.Spinner)}
+	}
+	if err := config.validJobs(); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	config.configuredDirs = config.cleanupDirs()
+	if err := config.expandAllPathGlobs(); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	if err := config.validExtLimits(); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	if err := config.validContentTypes(); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	if err := config.validPathRegex(); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	if config.IOProfile == "" {
+		config.IOProfile = "ssd"
+	}
+	if err := validIOProfile(config.IOProfile); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	if err := config.validPriority(); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	if err := config.validAutoOptimize(); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	if err := config.validCPUSampling(); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	if err := config.validFillProjection(); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	if config.MaxWalkDepth < 0 {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("max_walk_depth must not be negative")}
+	}
+	if config.MaxPathSize < 0 {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("max_path_size must not be negative")}
+	}
+	if config.SkipActiveWindow < 0 {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("skip_active_window must not be negative")}
+	}
+	for _, name := range config.Recipes {
+		if err := validRecipe(name); err != nil {
+			return nil, &ConfigError{Path: path, Err: err}
+		}
+	}
+	if config.JournalVacuum == "" {
+		config.JournalVacuum = "2weeks"
+	}
+	if config.ArchiveBeforeDelete && config.ArchiveDir == "" {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("archive_before_delete requires archive_dir")}
+	}
+	rules, err := loadJunkRules(config.RulesFile)
+	if err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	config.junkRules = rules
+	if config.archivesByRule() && config.ArchiveDir == "" {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("archive rules of rules_file require archive_dir")}
+	}
+	if err := config.validMetrics(); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	if err := config.validDedupeKeep(); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
+	}
+	if config.OnlyBeforeLastClean && config.StateFile == "" {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("only_before_last_clean requires state_file")}
+	}
+	if config.CleanCooldown < 0 {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("clean_cooldown must not be negative")}
+	}
+	if config.CleanCooldown > 0 && config.StateFile == "" {
+		return nil, &ConfigError{Path: path, Err: fmt.Errorf("clean_cooldown requires state_file")}
+	}
+
+	return config, nil
+}
@@ -0,0 +1,120 @@
+package cleaner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// configFetchTimeout bounds the download of a config from a URL
+const configFetchTimeout = 30 * time.Second
+
+// maxConfigSize is the largest config accepted from a URL
+const maxConfigSize = 1 << 20
+
+// configTokenEnv names the variable holding a bearer token for config URLs
+const configTokenEnv = "CLEANPC_CONFIG_TOKEN"
+
+// isConfigURL reports whether a -config value is an http(s) URL
+func isConfigURL(path string) bool {
+	return strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "http://")
+}
+
+// configCachePath is where the last good config fetched from url is kept
+func configCachePath(url string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, "cleanpc", "config-"+hex.EncodeToString(sum[:8])+".yaml"), nil
+}
+
+// fetchConfig downloads a config, sending the bearer token from the
+// environment when one is set
+func fetchConfig(url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv(configTokenEnv); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := &http.Client{Timeout: configFetchTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxConfigSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxConfigSize {
+		return nil, fmt.Errorf("config is larger than %d bytes", maxConfigSize)
+	}
+	return data, nil
+}
+
+// loadConfigURL fetches and validates a config from url and caches it. When
+// the fetch or the validation fails, the last good cached copy is used.
+func loadConfigURL(url string) (*Config, error) {
+	cache, cacheErr := configCachePath(url)
+
+	data, err := fetchConfig(url)
+	if err == nil {
+		var config *Config
+		if config, err = parseConfig(url, data); err == nil {
+			if cacheErr == nil {
+				cacheErr = writeConfigCache(cache, data)
+			}
+			if cacheErr != nil {
+				config.notes = append(config.notes, fmt.Sprintf("Could not cache config from %s: %v", url, cacheErr))
+			}
+			return config, nil
+		}
+		var cfgErr *ConfigError
+		if errors.As(err, &cfgErr) {
+			err = cfgErr.Err
+		}
+	}
+
+	if cacheErr != nil {
+		return nil, &ConfigError{Path: url, Err: err}
+	}
+	cached, readErr := os.ReadFile(cache)
+	if readErr != nil {
+		return nil, &ConfigError{Path: url, Err: err}
+	}
+	config, parseErr := parseConfig(cache, cached)
+	if parseErr != nil {
+		return nil, &ConfigError{Path: url, Err: err}
+	}
+	config.source = cache + ", the cached copy of " + url
+	config.notes = append(config.notes, fmt.Sprintf("Fetching config from %s failed (%v); using the cached copy %s", url, err, cache))
+	return config, nil
+}
+
+// writeConfigCache stores a fetched config atomically
+func writeConfigCache(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,122 @@
+package cleaner
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// editConfigFile changes the YAML config at path through its node tree, so the
+// user's comments, anchors, key order and quoting survive. The edited config
+// must still load before it replaces the file.
+func editConfigFile(path string, edit func(root *yaml.Node) error) error {
+	if path == "" || path == "-" || isConfigURL(path) {
+		return fmt.Errorf("config %q is not a file that can be edited", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return &ConfigError{Path: path, Err: err}
+	}
+	if doc.Kind == 0 {
+		// an empty file has no document yet
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return &ConfigError{Path: path, Err: fmt.Errorf("top level is not a mapping")}
+	}
+	if err := edit(root); err != nil {
+		return &ConfigError{Path: path, Err: err}
+	}
+	plainMergeKeys(root)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	if _, err := parseConfig(path, buf.Bytes()); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// plainMergeKeys clears the resolved tag of "<<" merge keys, which the encoder
+// would otherwise write out as "!!merge <<"
+func plainMergeKeys(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!merge" {
+		node.Tag = ""
+	}
+	for _, child := range node.Content {
+		plainMergeKeys(child)
+	}
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// appendCleanupPaths adds plain path entries, each with a line comment, to the
+// cleanup_paths of a config mapping, creating the key when it is missing
+func appendCleanupPaths(root *yaml.Node, paths, comments []string) error {
+	seq := mappingValue(root, "cleanup_paths")
+	switch {
+	case seq == nil:
+		seq = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "cleanup_paths"}, seq)
+	case seq.Kind == yaml.AliasNode:
+		return fmt.Errorf("cleanup_paths refers to anchor &%s; add the paths there by hand", seq.Value)
+	case seq.Kind == yaml.ScalarNode && seq.Tag == "!!null":
+		*seq = yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", LineComment: seq.LineComment}
+	case seq.Kind != yaml.SequenceNode:
+		return fmt.Errorf("cleanup_paths is not a list")
+	}
+
+	for i, path := range paths {
+		entry := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path, Style: yaml.DoubleQuotedStyle}
+		if seq.Style&yaml.FlowStyle == 0 && comments[i] != "" {
+			entry.LineComment = "# " + comments[i]
+		}
+		seq.Content = append(seq.Content, entry)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package cleaner
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// sniffType detects the MIME type of a file from its first 512 bytes,
+// ignoring its name
+func sniffType(name string) (string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(buf[:n]))
+	if err != nil {
+		return "", err
+	}
+	return mediaType, nil
+}
+
+// matchesType reports whether a MIME type matches one of the patterns, which
+// may use globs such as "image/*"
+func matchesType(mimeType string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), mimeType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validContentTypes checks the global and per-path content_types patterns
+func (c *Config) validContentTypes() error {
+	check := func(patterns []string) error {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil || !strings.Contains(pattern, "/") {
+				return fmt.Errorf("invalid content_types pattern %q (want e.g. application/zip or image/*)", pattern)
+			}
+		}
+		return nil
+	}
+	if err := check(c.ContentTypes); err != nil {
+		return err
+	}
+	for _, cp := range c.CleanupPaths {
+		if err := check(cp.ContentTypes); err != nil {
+			return fmt.Errorf("%s: %w", cp.Path, err)
+		}
+	}
+	return nil
+}
+
+// typeCounter tallies cleaned files by detected MIME type; it is shared by
+// the clean workers
+type typeCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// add counts one cleaned file of the given type
+func (t *typeCounter) add(mimeType string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.counts == nil {
+		t.counts = make(map[string]int)
+	}
+	t.counts[mimeType]++
+}
+
+// reportTypes prints the counts, most common type first
+func (sc *SystemCleaner) reportTypes(t *typeCounter) {
+	if len(t.counts) == 0 {
+		return
+	}
+	types := make([]string, 0, len(t.counts))
+	for mimeType := range t.counts {
+		types = append(types, mimeType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if t.counts[types[i]] != t.counts[types[j]] {
+			return t.counts[types[i]] > t.counts[types[j]]
+		}
+		return types[i] < types[j]
+	})
+
+	fmt.Fprintln(sc.out, "🧬 Files by content type:")
+	for _, mimeType := range types {
+		fmt.Fprintf(sc.out, "   %-30s %d\n", mimeType, t.counts[mimeType])
+	}
+}
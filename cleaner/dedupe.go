@@ -0,0 +1,376 @@
+package cleaner
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// validDedupeKeep checks dedupe_keep, defaulting it to oldest
+func (c *Config) validDedupeKeep() error {
+	switch c.DedupeKeep {
+	case "":
+		c.DedupeKeep = "oldest"
+	case "oldest", "newest":
+	default:
+		return fmt.Errorf("invalid dedupe_keep %q (want oldest or newest)", c.DedupeKeep)
+	}
+	return nil
+}
+
+// dupFile is a candidate for deduplication
+type dupFile struct {
+	root   string // cleanup path it was found in
+	path   string
+	info   fs.FileInfo
+	minAge time.Duration // min_age_days of its cleanup path
+}
+
+// pathSet is a set of paths that is safe for concurrent use
+type pathSet struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+func (s *pathSet) add(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.paths == nil {
+		s.paths = make(map[string]bool)
+	}
+	s.paths[path] = true
+}
+
+func (s *pathSet) has(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paths[path]
+}
+
+// stopReader fails with ErrInterrupted once stop is closed, so a long read
+// can be given up between two chunks
+type stopReader struct {
+	r    io.Reader
+	stop <-chan struct{}
+}
+
+func (s stopReader) Read(p []byte) (int, error) {
+	select {
+	case <-s.stop:
+		return 0, ErrInterrupted
+	default:
+	}
+	return s.r.Read(p)
+}
+
+// hashFile streams a file through SHA-256, giving up once stop is closed
+func hashFile(path string, stop <-chan struct{}) ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	file, err := os.Open(path)
+	if err != nil {
+		return sum, err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, stopReader{r: file, stop: stop}); err != nil {
+		return sum, err
+	}
+	copy(sum[:], h.Sum(nil))
+	return sum, nil
+}
+
+// hashedFile is a duplicate candidate with the outcome of hashing it
+type hashedFile struct {
+	dupFile
+	sum [sha256.Size]byte
+	err error
+}
+
+// hashCandidates hashes every file of the same-sized groups, hash_workers at
+// a time. Each worker writes only its own entries, so no locking is needed.
+func (sc *SystemCleaner) hashCandidates(bySize map[int64][]dupFile) ([]hashedFile, error) {
+	var hashed []hashedFile
+	for _, files := range bySize {
+		if len(files) < 2 {
+			continue
+		}
+		for _, f := range files {
+			hashed = append(hashed, hashedFile{dupFile: f})
+		}
+	}
+	runParallel(len(hashed), sc.config.hashWorkers(), func(i int) {
+		hashed[i].sum, hashed[i].err = hashFile(hashed[i].path, sc.stopChan)
+	})
+	for _, h := range hashed {
+		if errors.Is(h.err, ErrInterrupted) {
+			return nil, &ScanError{Path: h.path, Err: ErrInterrupted}
+		}
+	}
+	return hashed, nil
+}
+
+// findDuplicates returns the groups of identical files under the cleanup
+// paths, each with the copy to keep first. Files are grouped by size and only
+// same-sized files are hashed, hash_workers at a time. Empty files and hard
+// links to the kept copy are left out. Junk rules do not apply, but exclude
+// patterns, skip_paths, .cleanignore, keep_list and the tool's own files do,
+// and files in spared are left out.
+func (sc *SystemCleaner) findDuplicates(paths []CleanupPath, spared *pathSet) ([][]dupFile, error) {
+	bySize := make(map[int64][]dupFile)
+	seen := make(map[string]bool) // nested cleanup paths reach files twice
+	for _, cp := range paths {
+		if sc.skipFilePath(cp.Path) {
+			continue
+		}
+		rules := sc.config.rulesFor(cp)
+		own := sc.ownFiles[cp.Path]
+		kept := sc.keep.keepChecker(cp.Path)
+		err := sc.walkContext(context.Background(), cp.Path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == cp.Path {
+					return err
+				}
+				sc.logger.Printf("Error accessing path %s: %v", path, err)
+				sc.emit(Event{Type: EventError, Path: path, Reason: "cannot read", Err: err})
+				return nil
+			}
+			if own[path] || (path != cp.Path && rules.excluded(path)) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || seen[path] || spared.has(path) || sc.protected(kept, path) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				sc.logger.Printf("Error accessing path %s: %v", path, err)
+				sc.emit(Event{Type: EventError, Path: path, Reason: "cannot read file info", Err: err})
+				return nil
+			}
+			if info.Size() == 0 {
+				return nil
+			}
+			seen[path] = true
+			bySize[info.Size()] = append(bySize[info.Size()], dupFile{root: cp.Path, path: path, info: info, minAge: rules.minAge})
+			return nil
+		})
+		if err != nil {
+			return nil, &ScanError{Path: cp.Path, Err: err}
+		}
+	}
+
+	hashed, err := sc.hashCandidates(bySize)
+	if err != nil {
+		return nil, err
+	}
+	// files of different sizes never share a group, so the hash alone is not
+	// enough as a key
+	type key struct {
+		size int64
+		sum  [sha256.Size]byte
+	}
+	byHash := make(map[key][]dupFile)
+	for _, h := range hashed {
+		if h.err != nil {
+			sc.logger.Printf("Error reading file %s: %v", h.path, h.err)
+			sc.emit(Event{Type: EventError, Path: h.path, Reason: "cannot hash", Err: h.err})
+			continue
+		}
+		k := key{h.info.Size(), h.sum}
+		byHash[k] = append(byHash[k], h.dupFile)
+	}
+	var groups [][]dupFile
+	for _, group := range byHash {
+		if group = sc.orderCopies(group); len(group) > 1 {
+			groups = append(groups, group)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i][0].path < groups[j][0].path })
+	return groups, nil
+}
+
+// orderCopies puts the copy dedupe_keep keeps first and drops hard links to it
+func (sc *SystemCleaner) orderCopies(group []dupFile) []dupFile {
+	sort.Slice(group, func(i, j int) bool {
+		a, b := group[i].info.ModTime(), group[j].info.ModTime()
+		if a.Equal(b) {
+			return group[i].path < group[j].path
+		}
+		if sc.config.DedupeKeep == "newest" {
+			return a.After(b)
+		}
+		return a.Before(b)
+	})
+
+	copies := group[:1]
+	for _, f := range group[1:] {
+		if !os.SameFile(group[0].info, f.info) {
+			copies = append(copies, f)
+		}
+	}
+	return copies
+}
+
+// guarded reports whether a copy that would go is protected by the guards
+// of a junk clean: it was modified within min_age_days of its cleanup path,
+// or a rules_file rule keeps, reports or archives it
+func (sc *SystemCleaner) guarded(f dupFile, now time.Time) bool {
+	if now.Sub(f.info.ModTime()) < f.minAge {
+		sc.logger.Printf("Keeping duplicate %s: modified within min_age_days", f.path)
+		sc.emit(Event{Type: EventSkipped, Path: f.path, Size: f.info.Size(), Reason: "duplicate within min_age_days"})
+		return true
+	}
+	if len(sc.config.junkRules) == 0 {
+		return false
+	}
+	rule, err := sc.config.ruleFor(f.root, f.path, f.info, now)
+	if err != nil {
+		sc.logger.Printf("Error reading file %s: %v", f.path, err)
+		sc.emit(Event{Type: EventError, Path: f.path, Reason: "cannot evaluate rule", Err: err})
+		return true
+	}
+	if rule != nil && rule.Action != ruleDelete {
+		sc.logger.Printf("Keeping duplicate %s: %s by rule %s", f.path, rule.Action, rule.Name)
+		sc.emit(Event{Type: EventSkipped, Path: f.path, Size: f.info.Size(), Reason: "kept by rule " + rule.Name})
+		return true
+	}
+	return false
+}
+
+// dupDeletion is a copy dedupe plans to delete, with the copy that stays
+type dupDeletion struct {
+	dupFile
+	keep string
+}
+
+// planDedupe picks the copies to delete from the duplicate groups, leaving
+// out guarded copies and, with detect_growing, copies that grow. It returns
+// false if the clean is interrupted while sampling.
+func (sc *SystemCleaner) planDedupe(groups [][]dupFile) ([]dupDeletion, bool) {
+	now := time.Now()
+	var plan []dupDeletion
+	for _, group := range groups {
+		for _, f := range group[1:] {
+			if !sc.guarded(f, now) {
+				plan = append(plan, dupDeletion{dupFile: f, keep: group[0].path})
+			}
+		}
+	}
+	if !sc.config.DetectGrowing || len(plan) == 0 {
+		return plan, true
+	}
+
+	sampled := make([]junkFile, len(plan))
+	for i, d := range plan {
+		sampled[i] = junkFile{path: d.path, info: d.info}
+	}
+	stable, ok := sc.stableFiles(sampled)
+	if !ok {
+		return nil, false
+	}
+	stayed := make(map[string]bool, len(stable))
+	for _, f := range stable {
+		stayed[f.path] = true
+	}
+	var kept []dupDeletion
+	for _, d := range plan {
+		if stayed[d.path] {
+			kept = append(kept, d)
+		}
+	}
+	return kept, true
+}
+
+// dedupe deletes all but one copy of every group of identical files across
+// the cleanup paths, or lists them in a dry run, and reports the space freed
+// apart from the junk clean. The guards of a junk clean apply to the copies
+// that would go, and the plan is shown and, with a prompter, confirmed
+// before anything is deleted.
+func (sc *SystemCleaner) dedupe(paths []CleanupPath, spared *pathSet, audit *auditLog, freed *freedCheck) error {
+	fmt.Fprintln(sc.msg, "\n♊ Looking for duplicate files across the cleanup paths...")
+	groups, err := sc.findDuplicates(paths, spared)
+	if err != nil {
+		return err
+	}
+	plan, ok := sc.planDedupe(groups)
+	if !ok {
+		return &CleanError{Path: "dedupe", Err: ErrInterrupted}
+	}
+	if len(plan) == 0 {
+		fmt.Fprintln(sc.out, "♊ No duplicate files found")
+		return nil
+	}
+	if err := sc.checkDeleteCount(len(plan)); err != nil {
+		return err
+	}
+
+	var planned int64
+	for _, d := range plan {
+		size := sc.fileSize(d.info)
+		planned += size
+		if sc.dryRun {
+			sc.emit(Event{Type: EventSkipped, Path: d.path, Size: size, Reason: "dry run"})
+			sc.scriptDelete(d.path, size, "duplicate of "+d.keep)
+			fmt.Fprintf(sc.out, "🧪 Would delete duplicate %s (%s), keeping %s\n", d.path, sc.FormatSize(size), d.keep)
+		} else {
+			fmt.Fprintf(sc.out, "♊ Duplicate %s (%s) of %s\n", d.path, sc.FormatSize(size), d.keep)
+		}
+	}
+	if sc.dryRun {
+		for _, d := range plan {
+			sc.progress.deletedFrom(d.root, sc.fileSize(d.info))
+		}
+		fmt.Fprintf(sc.out, "🧪 Deduplication would free %s by deleting %d duplicate files\n", sc.FormatSize(planned), len(plan))
+		return nil
+	}
+	// without a prompter (-yes) the plan printed above is the record
+	question := fmt.Sprintf("Delete these %d duplicate files (%s)?", len(plan), sc.FormatSize(planned))
+	if sc.prompter != nil && !sc.prompter.Confirm(question) {
+		sc.logger.Printf("Deduplication declined: %d duplicate files kept", len(plan))
+		fmt.Fprintln(sc.msg, "ℹ️  Duplicates kept")
+		return nil
+	}
+
+	remover := newFileRemover()
+	defer remover.close()
+	var deleted int
+	var reclaimed int64
+	for _, d := range plan {
+		select {
+		case <-sc.stopChan:
+			return &CleanError{Path: d.path, Err: ErrInterrupted}
+		case <-sc.budgetSpent():
+			sc.logger.Printf("Deduplication stopped: %v", ErrBudget)
+			return nil
+		default:
+		}
+
+		size := sc.fileSize(d.info)
+		if err := sc.removeFile(remover, d.path); err != nil {
+			sc.logger.Printf("Error removing file %s: %v", d.path, err)
+			sc.emit(Event{Type: EventError, Path: d.path, Size: size, Reason: "delete failed", Err: err})
+			continue
+		}
+		sc.logger.Printf("Deleted duplicate %s, kept %s", d.path, d.keep)
+		sc.emit(Event{Type: EventDeleted, Path: d.path, Size: size})
+		sc.audit(audit, d.path, size)
+		freed.deleted(d.root, allocatedSize(d.info))
+		sc.progress.deletedFrom(d.root, size)
+		deleted++
+		reclaimed += size
+	}
+
+	fmt.Fprintf(sc.out, "♊ Deduplication freed %s by deleting %d duplicate files\n", sc.FormatSize(reclaimed), deleted)
+	return nil
+}
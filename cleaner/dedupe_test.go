@@ -0,0 +1,183 @@
+package cleaner
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// makeDuplicates writes groups of identical files under root, copies[i]
+// copies of content i, and returns the dedupe candidates grouped by size
+func makeDuplicates(tb testing.TB, root string, copies []int, size int) (map[int64][]dupFile, map[string][]byte) {
+	tb.Helper()
+	bySize := make(map[int64][]dupFile)
+	contents := make(map[string][]byte)
+	for i, n := range copies {
+		// same-sized contents that differ, so only the hash tells them apart
+		data := append([]byte(strings.Repeat("x", size-8)), fmt.Sprintf("%08d", i)...)
+		for c := 0; c < n; c++ {
+			path := filepath.Join(root, fmt.Sprintf("content%d-copy%d", i, c))
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				tb.Fatal(err)
+			}
+			info, err := os.Lstat(path)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			bySize[info.Size()] = append(bySize[info.Size()], dupFile{root: root, path: path, info: info})
+			contents[path] = data
+		}
+	}
+	return bySize, contents
+}
+
+func TestHashCandidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		copies  []int
+	}{
+		{"serial", 1, []int{2, 3, 1}},
+		{"parallel", 4, []int{2, 3, 1, 5}},
+		{"more workers than files", 32, []int{2, 2}},
+		{"single files are not hashed", 4, []int{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			bySize, contents := makeDuplicates(t, root, tt.copies, 1000)
+			sc := newTestCleaner(t, fmt.Sprintf("hash_workers: %d\n", tt.workers))
+
+			hashed, err := sc.hashCandidates(bySize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want int
+			for _, files := range bySize {
+				if len(files) > 1 {
+					want += len(files)
+				}
+			}
+			if len(hashed) != want {
+				t.Errorf("hashed %d files, want %d", len(hashed), want)
+			}
+			seen := make(map[string]bool)
+			for _, h := range hashed {
+				if seen[h.path] {
+					t.Errorf("%s hashed twice", h.path)
+				}
+				seen[h.path] = true
+				if h.err != nil {
+					t.Errorf("%s: %v", h.path, h.err)
+				}
+				if h.sum != sha256.Sum256(contents[h.path]) {
+					t.Errorf("%s: wrong hash", h.path)
+				}
+			}
+		})
+	}
+}
+
+func TestHashCandidatesInterrupted(t *testing.T) {
+	bySize, _ := makeDuplicates(t, t.TempDir(), []int{3}, 1000)
+	sc := newTestCleaner(t, "hash_workers: 2\n")
+	close(sc.stopChan)
+
+	_, err := sc.hashCandidates(bySize)
+	var scanErr *ScanError
+	if !errors.As(err, &scanErr) || !errors.Is(err, ErrInterrupted) {
+		t.Errorf("got %v, want a ScanError wrapping ErrInterrupted", err)
+	}
+}
+
+func TestHashFileStreams(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+	}{
+		{"empty", 0},
+		{"one byte", 1},
+		{"several read buffers", 3<<20 + 17},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := []byte(strings.Repeat("junk", tt.size/4+1)[:tt.size])
+			path := filepath.Join(t.TempDir(), "file")
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			sum, err := hashFile(path, make(chan struct{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sum != sha256.Sum256(data) {
+				t.Errorf("wrong hash for %d bytes", tt.size)
+			}
+		})
+	}
+}
+
+// TestFindDuplicatesWorkers checks that hashing in parallel finds the same
+// groups, in the same order, as hashing serially
+func TestFindDuplicatesWorkers(t *testing.T) {
+	root := t.TempDir()
+	makeDuplicates(t, root, []int{2, 4, 1, 3, 1, 2}, 512)
+	config := fmt.Sprintf("cleanup_paths: [%s]\n", root)
+
+	groupPaths := func(workers int) [][]string {
+		sc := newTestCleaner(t, config+fmt.Sprintf("hash_workers: %d\n", workers))
+		groups, err := sc.findDuplicates(sc.config.CleanupPaths, &pathSet{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths [][]string
+		for _, group := range groups {
+			var names []string
+			for _, f := range group {
+				names = append(names, f.path)
+			}
+			paths = append(paths, names)
+		}
+		return paths
+	}
+	serial := groupPaths(1)
+	if len(serial) != 4 {
+		t.Fatalf("found %d groups, want 4: %v", len(serial), serial)
+	}
+	for _, workers := range []int{2, 8} {
+		if parallel := groupPaths(workers); !reflect.DeepEqual(parallel, serial) {
+			t.Errorf("hash_workers %d found %v, want %v", workers, parallel, serial)
+		}
+	}
+}
+
+func BenchmarkHashCandidates(b *testing.B) {
+	root := b.TempDir()
+	copies := make([]int, 16)
+	for i := range copies {
+		copies[i] = 2
+	}
+	bySize, _ := makeDuplicates(b, root, copies, 1<<20)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("hash_workers=%d", workers), func(b *testing.B) {
+			sc := newTestCleaner(b, fmt.Sprintf("hash_workers: %d\n", workers))
+			b.SetBytes(int64(len(copies) * 2 << 20))
+			for i := 0; i < b.N; i++ {
+				hashed, err := sc.hashCandidates(bySize)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, h := range hashed {
+					if h.err != nil {
+						b.Fatal(h.err)
+					}
+				}
+			}
+		})
+	}
+}
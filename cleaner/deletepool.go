@@ -0,0 +1,85 @@
+package cleaner
+
+import (
+	"io/fs"
+	"sync"
+)
+
+// minPoolFiles is how many files a cleanup path must have before its deletions
+// are spread over workers; below that a pool costs more than it saves
+const minPoolFiles = 256
+
+// deleteJob is one file handed from the walk to a deletion worker, with its
+// sniffed content type if content_types is set
+type deleteJob struct {
+	path     string
+	info     fs.FileInfo
+	mimeType string
+	archive  bool // an archive rule of rules_file matched it
+}
+
+// deletePool deletes the files of one cleanup path as its walk finds them.
+// The first minPoolFiles are deleted on the walking goroutine; after that the
+// walk only produces jobs and the deletion workers, each with its own
+// fileRemover, consume them. remove must be safe for concurrent use.
+type deletePool struct {
+	workers int
+	remove  func(r *fileRemover, job deleteJob)
+	serial  *fileRemover
+	seen    int
+	jobs    chan deleteJob
+	wg      sync.WaitGroup
+	pending sync.WaitGroup // queued jobs not yet removed
+}
+
+// newDeletePool returns a pool of at most workers deletion workers
+func newDeletePool(workers int, remove func(r *fileRemover, job deleteJob)) *deletePool {
+	return &deletePool{workers: workers, remove: remove, serial: newFileRemover()}
+}
+
+// add deletes a file, or queues it once the path has proven large enough
+func (p *deletePool) add(job deleteJob) {
+	p.seen++
+	if p.workers <= 1 || p.seen <= minPoolFiles {
+		p.remove(p.serial, job)
+		return
+	}
+	if p.jobs == nil {
+		p.start()
+	}
+	p.pending.Add(1)
+	p.jobs <- job
+}
+
+// flush waits until every queued file has been removed, leaving the workers
+// running for the jobs still to come
+func (p *deletePool) flush() {
+	p.pending.Wait()
+}
+
+// start launches the workers
+func (p *deletePool) start() {
+	p.jobs = make(chan deleteJob, p.workers*4)
+	for i := 0; i < p.workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			r := newFileRemover()
+			defer r.close()
+			for job := range p.jobs {
+				p.remove(r, job)
+				p.pending.Done()
+			}
+		}()
+	}
+}
+
+// wait finishes the queued deletions and releases the removers
+func (p *deletePool) wait() {
+	if p.jobs != nil {
+		close(p.jobs)
+		p.wg.Wait()
+		p.jobs = nil
+	}
+	p.serial.close()
+}
@@ -0,0 +1,111 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestDeletePool(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		jobs    int
+	}{
+		{"no jobs", 4, 0},
+		{"one worker", 1, 3 * minPoolFiles},
+		{"below the pool threshold", 8, minPoolFiles},
+		{"just above the threshold", 8, minPoolFiles + 1},
+		{"pool", 8, 10 * minPoolFiles},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			handled := make(map[string]int)
+			removers := make(map[*fileRemover]bool)
+			pool := newDeletePool(tt.workers, func(r *fileRemover, job deleteJob) {
+				mu.Lock()
+				defer mu.Unlock()
+				handled[job.path]++
+				removers[r] = true
+			})
+			for i := 0; i < tt.jobs; i++ {
+				pool.add(deleteJob{path: fmt.Sprintf("file%d", i)})
+			}
+			pool.wait()
+
+			if len(handled) != tt.jobs {
+				t.Errorf("handled %d files, want %d", len(handled), tt.jobs)
+			}
+			for path, n := range handled {
+				if n != 1 {
+					t.Errorf("%s handled %d times, want 1", path, n)
+				}
+			}
+			// the serial remover, plus one per worker once the pool starts
+			wantRemovers := 1
+			if tt.workers > 1 && tt.jobs > minPoolFiles {
+				wantRemovers += tt.workers
+			}
+			if tt.jobs > 0 && len(removers) > wantRemovers {
+				t.Errorf("used %d removers, want at most %d", len(removers), wantRemovers)
+			}
+		})
+	}
+}
+
+func TestDeletePoolRemovesFiles(t *testing.T) {
+	dir := t.TempDir()
+	want := makeTree(t, dir, 3, minPoolFiles)
+	var mu sync.Mutex
+	var errs []error
+	pool := newDeletePool(4, func(r *fileRemover, job deleteJob) {
+		if err := r.remove(job.path); err != nil {
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}
+	})
+	for path := range want {
+		pool.add(deleteJob{path: path})
+	}
+	pool.wait()
+
+	if err := errors.Join(errs...); err != nil {
+		t.Fatal(err)
+	}
+	for path := range want {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists: %v", path, err)
+		}
+	}
+}
+
+func BenchmarkDeletePool(b *testing.B) {
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				dir := b.TempDir()
+				var paths []string
+				for f := 0; f < 4000; f++ {
+					path := filepath.Join(dir, fmt.Sprintf("file%04d", f))
+					if err := os.WriteFile(path, []byte("junk"), 0644); err != nil {
+						b.Fatal(err)
+					}
+					paths = append(paths, path)
+				}
+				b.StartTimer()
+
+				pool := newDeletePool(workers, func(r *fileRemover, job deleteJob) { r.remove(job.path) })
+				for _, path := range paths {
+					pool.add(deleteJob{path: path})
+				}
+				pool.wait()
+			}
+		})
+	}
+}
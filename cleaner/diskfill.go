@@ -0,0 +1,165 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+// minFillSamples is how many readings a disk needs before it is projected
+const minFillSamples = 5
+
+// fillLogInterval is how often the projection of each disk is logged
+const fillLogInterval = time.Minute
+
+// DiskSample is one reading of a filesystem holding cleanup paths
+type DiskSample struct {
+	Path          string  `json:"path"` // the first cleanup path on it
+	Used          int64   `json:"used"`
+	Free          int64   `json:"free"`
+	Rate          float64 `json:"rate"`            // bytes per second fitted over fill_window
+	FullInSeconds float64 `json:"full_in_seconds"` // -1 when the disk is not filling or too few readings exist
+}
+
+// validFillProjection checks and defaults fill_window and fill_warn_horizon
+func (c *Config) validFillProjection() error {
+	if c.FillWindow < 0 || c.FillWarnHorizon < 0 {
+		return fmt.Errorf("fill_window and fill_warn_horizon must not be negative")
+	}
+	if c.FillWindow == 0 {
+		c.FillWindow = 10 * time.Minute
+	}
+	if c.FillWarnHorizon == 0 {
+		c.FillWarnHorizon = 24 * time.Hour
+	}
+	return nil
+}
+
+// fillPoint is one used-space reading of a disk
+type fillPoint struct {
+	t    time.Time
+	used float64
+}
+
+// diskTracker keeps the recent readings of one filesystem
+type diskTracker struct {
+	path   string
+	points []fillPoint
+	warned bool      // a warning was shown and the projection has not recovered
+	logged time.Time // last time the projection was logged
+}
+
+// newDiskTrackers returns one tracker per filesystem holding a cleanup path
+func (sc *SystemCleaner) newDiskTrackers() []*diskTracker {
+	var trackers []*diskTracker
+	seen := make(map[uint64]bool)
+	for _, cp := range sc.config.CleanupPaths {
+		info, err := os.Stat(cp.Path)
+		if err != nil {
+			continue
+		}
+		if dev, ok := deviceID(info); ok {
+			if seen[dev] {
+				continue
+			}
+			seen[dev] = true
+		}
+		trackers = append(trackers, &diskTracker{path: cp.Path})
+	}
+	return trackers
+}
+
+// add records a reading and forgets the ones older than window
+func (d *diskTracker) add(now time.Time, used int64, window time.Duration) {
+	d.points = append(d.points, fillPoint{t: now, used: float64(used)})
+	cut := 0
+	for cut < len(d.points) && now.Sub(d.points[cut].t) > window {
+		cut++
+	}
+	d.points = d.points[cut:]
+}
+
+// rate fits a least-squares line through the readings and returns its slope
+// in bytes per second. ok is false until there are enough readings.
+func (d *diskTracker) rate() (slope float64, ok bool) {
+	n := float64(len(d.points))
+	if len(d.points) < minFillSamples {
+		return 0, false
+	}
+	var sumX, sumY, sumXY, sumXX float64
+	for _, p := range d.points {
+		x := p.t.Sub(d.points[0].t).Seconds()
+		sumX += x
+		sumY += p.used
+		sumXY += x * p.used
+		sumXX += x * x
+	}
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denom, true
+}
+
+// sampleDisk reads a disk and projects when it will be full
+func (sc *SystemCleaner) sampleDisk(d *diskTracker, now time.Time) (DiskSample, error) {
+	usage, err := disk.Usage(d.path)
+	if err != nil {
+		return DiskSample{}, err
+	}
+	d.add(now, int64(usage.Used), sc.config.FillWindow)
+	s := DiskSample{Path: d.path, Used: int64(usage.Used), Free: int64(usage.Free), FullInSeconds: -1}
+	if rate, ok := d.rate(); ok {
+		s.Rate = rate
+		if rate > 0 {
+			s.FullInSeconds = float64(usage.Free) / rate
+		}
+	}
+	return s, nil
+}
+
+// reportFill logs a disk's projection now and then and warns once when it
+// falls below fill_warn_horizon, until it recovers
+func (sc *SystemCleaner) reportFill(d *diskTracker, s DiskSample, now time.Time) {
+	if now.Sub(d.logged) >= fillLogInterval && len(d.points) >= minFillSamples {
+		d.logged = now
+		sc.logger.Printf("Disk of %s: %s free, %s/min, %s", s.Path, sc.FormatSize(s.Free), sc.formatRate(s.Rate*60), fillText(s))
+	}
+	soon := s.FullInSeconds >= 0 && s.FullInSeconds < sc.config.FillWarnHorizon.Seconds()
+	if soon && !d.warned {
+		sc.logger.Printf("Disk of %s projected full in %s, within fill_warn_horizon %s", s.Path, formatETA(s.FullInSeconds), sc.config.FillWarnHorizon)
+		fmt.Fprintf(sc.msg, "\n⚠️  The disk of %s will be full in %s at the current rate\n", s.Path, formatETA(s.FullInSeconds))
+	}
+	d.warned = soon
+}
+
+// fillText describes a projection for the monitor line and the log
+func fillText(s DiskSample) string {
+	switch {
+	case s.FullInSeconds >= 0:
+		return "full in " + formatETA(s.FullInSeconds)
+	case s.Rate < 0:
+		return "shrinking"
+	case s.Rate == 0 && s.Used > 0:
+		return "steady"
+	}
+	return "measuring"
+}
+
+// formatETA renders a projected time roughly, e.g. "~14 hours"
+func formatETA(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second))
+	switch {
+	case seconds > (100 * 365 * 24 * time.Hour).Seconds():
+		return "over 100 years"
+	case d < time.Minute:
+		return "under a minute"
+	case d < time.Hour:
+		return fmt.Sprintf("~%d minutes", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("~%d hours", int(d.Hours()))
+	}
+	return fmt.Sprintf("~%d days", int(d.Hours()/24))
+}
@@ -0,0 +1,163 @@
+package cleaner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// dockerTimeout bounds every Docker API request; prunes can take a while
+const dockerTimeout = 5 * time.Minute
+
+// pruner is implemented by recipes that reclaim space through an API rather
+// than by running commands; it returns the bytes reclaimed
+type pruner interface {
+	Prune() (int64, error)
+}
+
+// dockerRecipe prunes dangling images, stopped containers and anonymous
+// unused volumes through the Docker Engine API
+type dockerRecipe struct {
+	socket string
+	client *http.Client
+}
+
+// newDockerRecipe talks to $DOCKER_HOST when it is a unix socket, otherwise to
+// the default socket
+func newDockerRecipe() dockerRecipe {
+	socket := "/var/run/docker.sock"
+	if host := os.Getenv("DOCKER_HOST"); strings.HasPrefix(host, "unix://") {
+		socket = strings.TrimPrefix(host, "unix://")
+	}
+	return dockerRecipe{
+		socket: socket,
+		client: &http.Client{
+			Timeout: dockerTimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socket)
+				},
+			},
+		},
+	}
+}
+
+func (dockerRecipe) Name() string        { return "docker" }
+func (dockerRecipe) Description() string { return "unused Docker data" }
+
+// Available reports whether the Docker daemon answers on its socket
+func (r dockerRecipe) Available() bool {
+	if _, err := os.Stat(r.socket); err != nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://docker/_ping", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// call sends a request to the Docker API and decodes the JSON reply into v
+func (r dockerRecipe) call(method, path string, v any) error {
+	req, err := http.NewRequest(method, "http://docker"+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("docker API %s %s: %s", method, path, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// anonymousVolumes matches the volumes Docker created without a name; named
+// volumes often hold data worth keeping, so they are never pruned
+var anonymousVolumes = url.QueryEscape(`{"label":["com.docker.volume.anonymous"]}`)
+
+// Size adds up dangling images, the writable layers of stopped containers and
+// anonymous volumes nothing uses
+func (r dockerRecipe) Size() (int64, error) {
+	var df struct {
+		Images []struct {
+			RepoTags   []string
+			Size       int64
+			SharedSize int64
+			Containers int64
+		}
+		Containers []struct {
+			State  string
+			SizeRw int64
+		}
+		Volumes []struct {
+			Labels    map[string]string
+			UsageData struct {
+				Size     int64
+				RefCount int64
+			}
+		}
+	}
+	if err := r.call(http.MethodGet, "/system/df", &df); err != nil {
+		return 0, err
+	}
+
+	var size int64
+	for _, image := range df.Images {
+		dangling := len(image.RepoTags) == 0 || (len(image.RepoTags) == 1 && image.RepoTags[0] == "<none>:<none>")
+		if dangling && image.Containers == 0 {
+			size += image.Size - max(image.SharedSize, 0)
+		}
+	}
+	for _, container := range df.Containers {
+		if container.State != "running" && container.State != "paused" {
+			size += container.SizeRw
+		}
+	}
+	for _, volume := range df.Volumes {
+		_, anonymous := volume.Labels["com.docker.volume.anonymous"]
+		if anonymous && volume.UsageData.RefCount == 0 && volume.UsageData.Size > 0 {
+			size += volume.UsageData.Size
+		}
+	}
+	return size, nil
+}
+
+// Commands is empty; the Docker recipe prunes through the API
+func (dockerRecipe) Commands() ([][]string, error) {
+	return nil, nil
+}
+
+// Prune removes stopped containers first, so the images and volumes they held
+// can go too
+func (r dockerRecipe) Prune() (int64, error) {
+	var reclaimed int64
+	for _, path := range []string{
+		"/containers/prune",
+		`/images/prune?filters=` + url.QueryEscape(`{"dangling":["true"]}`),
+		"/volumes/prune?filters=" + anonymousVolumes,
+	} {
+		var result struct{ SpaceReclaimed int64 }
+		if err := r.call(http.MethodPost, path, &result); err != nil {
+			return reclaimed, err
+		}
+		reclaimed += result.SpaceReclaimed
+	}
+	return reclaimed, nil
+}
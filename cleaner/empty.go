@@ -0,0 +1,47 @@
+package cleaner
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// SetEmptyFiles selects what junk walks do with zero-byte files: "" counts
+// them as junk like any other file, "skip" leaves them out since deleting
+// them frees no space, and "only" keeps nothing else, for a quick tidy of
+// lock and marker files. Zero-byte files are counted in the junk report
+// either way.
+func (sc *SystemCleaner) SetEmptyFiles(mode string) error {
+	switch mode {
+	case "", "skip", "only":
+	default:
+		return fmt.Errorf("invalid empty files mode %q (want skip or only)", mode)
+	}
+	sc.emptyFiles = mode
+	return nil
+}
+
+// leftOutBySize reports whether a junk file is left out by SetEmptyFiles
+func (sc *SystemCleaner) leftOutBySize(info fs.FileInfo) bool {
+	switch sc.emptyFiles {
+	case "skip":
+		return info.Size() == 0
+	case "only":
+		return info.Size() != 0
+	}
+	return false
+}
+
+// listEmpty prints how many zero-byte files the junk report found
+func (sc *SystemCleaner) listEmpty(report JunkReport) {
+	if report.EmptyFiles == 0 {
+		return
+	}
+	switch sc.emptyFiles {
+	case "skip":
+		fmt.Fprintf(sc.out, "🫙 %d empty files left out (they free no space)\n", report.EmptyFiles)
+	case "only":
+		fmt.Fprintf(sc.out, "🫙 %d empty files, nothing else is cleaned\n", report.EmptyFiles)
+	default:
+		fmt.Fprintf(sc.out, "🫙 %d of the junk files are empty\n", report.EmptyFiles)
+	}
+}
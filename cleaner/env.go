@@ -0,0 +1,65 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// envPrefix starts the name of every environment variable that overrides a
+// config key: CLEANPC_ followed by the key in upper case
+const envPrefix = "CLEANPC_"
+
+// applyEnv overrides config keys from the environment, e.g. max_file_size
+// from CLEANPC_MAX_FILE_SIZE. Lists of paths and patterns are split on the OS
+// path list separator (":", or ";" on Windows); other structured keys take
+// YAML flow syntax.
+func (c *Config) applyEnv() error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		name := envPrefix + strings.ToUpper(key)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setFromEnv(v.Field(i), value); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// setFromEnv stores an environment value in a config field
+func setFromEnv(field reflect.Value, value string) error {
+	switch field.Interface().(type) {
+	case string:
+		field.SetString(value)
+	case *string:
+		field.Set(reflect.ValueOf(&value))
+	case []string:
+		field.Set(reflect.ValueOf(filepath.SplitList(value)))
+	case []CleanupPath:
+		var paths []CleanupPath
+		for _, path := range filepath.SplitList(value) {
+			paths = append(paths, CleanupPath{Path: path})
+		}
+		field.Set(reflect.ValueOf(paths))
+	default:
+		// a fresh value, so a YAML list replaces the file's instead of merging
+		fresh := reflect.New(field.Type())
+		if err := yaml.Unmarshal([]byte(value), fresh.Interface()); err != nil {
+			return err
+		}
+		field.Set(fresh.Elem())
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInterrupted is returned when an operation is stopped by an interrupt signal
+var ErrInterrupted = errors.New("operation interrupted")
+
+// ErrTimeout is returned when an operation on a path exceeds scan_timeout or clean_timeout
+var ErrTimeout = errors.New("operation timed out")
+
+// ErrBudget is returned by walks that stop because the clean's max duration is spent
+var ErrBudget = errors.New("time budget spent")
+
+// ErrTooDeep is returned by walks that reach a directory nested deeper than max_walk_depth
+var ErrTooDeep = errors.New("directory nesting exceeds max_walk_depth")
+
+// ErrReserve is returned when writing would leave less than min_free_reserve free
+var ErrReserve = errors.New("not enough free space above min_free_reserve")
+
+// ErrDeleteLimit is returned when a clean would delete more than max_delete_files
+var ErrDeleteLimit = errors.New("deletion limit exceeded")
+
+// ErrStillExists is returned when verify_deletion finds a removed file still in place
+var ErrStillExists = errors.New("file still exists after deletion")
+
+// ErrPathsNotReady is returned by CheckConfig when some cleanup paths cannot be cleaned
+var ErrPathsNotReady = errors.New("cleanup paths not ready")
+
+// ConfigError reports a problem loading or validating the configuration
+type ConfigError struct {
+	Path string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("failed to load config %s: %v", e.Path, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error { return e.Err }
+
+// ScanError reports a filesystem problem while scanning a path
+type ScanError struct {
+	Path string
+	Err  error
+}
+
+func (e *ScanError) Error() string {
+	return fmt.Sprintf("error scanning %s: %v", e.Path, e.Err)
+}
+
+func (e *ScanError) Unwrap() error { return e.Err }
+
+// CleanError reports a filesystem problem while cleaning a path
+type CleanError struct {
+	Path string
+	Err  error
+}
+
+func (e *CleanError) Error() string {
+	return fmt.Sprintf("error cleaning %s: %v", e.Path, e.Err)
+}
+
+func (e *CleanError) Unwrap() error { return e.Err }
@@ -0,0 +1,104 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// errorExamples is how many paths ErrorSummary shows for each kind of error
+const errorExamples = 2
+
+// errorKinds classifies errors for the summary, first match wins
+var errorKinds = []struct {
+	target error
+	name   string
+}{
+	{fs.ErrPermission, "permission denied"},
+	{syscall.EBUSY, "device busy"},
+	{syscall.EROFS, "read-only filesystem"},
+	{syscall.ENOSPC, "no space left"},
+	{syscall.EIO, "I/O error"},
+	{fs.ErrNotExist, "vanished"},
+	{ErrTimeout, "timed out"},
+	{ErrReserve, "archive reserve reached"},
+}
+
+// errorKindOf names the kind of err for the summary
+func errorKindOf(err error) string {
+	for _, kind := range errorKinds {
+		if errors.Is(err, kind.target) {
+			return kind.name
+		}
+	}
+	return "other"
+}
+
+// errorGroup is one kind of error in an errorSummary
+type errorGroup struct {
+	name     string
+	paths    map[string]bool
+	examples []string
+}
+
+// errorSummary counts the paths that failed during a run by kind of error.
+// A path met by several walks is counted once.
+type errorSummary struct {
+	mu     sync.Mutex
+	groups []*errorGroup // in the order they were first met
+}
+
+// add records that path failed with err
+func (s *errorSummary) add(path string, err error) {
+	name := errorKindOf(err)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var group *errorGroup
+	for _, g := range s.groups {
+		if g.name == name {
+			group = g
+			break
+		}
+	}
+	if group == nil {
+		group = &errorGroup{name: name, paths: make(map[string]bool)}
+		s.groups = append(s.groups, group)
+	}
+	if group.paths[path] {
+		return
+	}
+	group.paths[path] = true
+	if len(group.examples) < errorExamples {
+		group.examples = append(group.examples, path)
+	}
+}
+
+// count returns how many paths have failed so far
+func (s *errorSummary) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int
+	for _, g := range s.groups {
+		n += len(g.paths)
+	}
+	return n
+}
+
+// ErrorSummary returns a one-line account of the files and paths that could
+// not be read, archived or deleted so far, grouped by kind of error with a
+// few example paths, e.g. "3 permission denied (e.g. /var/log/x), 1 device
+// busy (e.g. /mnt/y)". It is empty when nothing failed.
+func (sc *SystemCleaner) ErrorSummary() string {
+	sc.failures.mu.Lock()
+	defer sc.failures.mu.Unlock()
+
+	parts := make([]string, len(sc.failures.groups))
+	for i, g := range sc.failures.groups {
+		parts[i] = fmt.Sprintf("%d %s (e.g. %s)", len(g.paths), g.name, strings.Join(g.examples, ", "))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,52 @@
+package cleaner
+
+import "sync"
+
+// EventType tells what happened to the file or path of an Event
+type EventType string
+
+const (
+	EventScanned EventType = "scanned" // a file was found by a clean or a large-file scan
+	EventDeleted EventType = "deleted" // a file was deleted
+	EventSkipped EventType = "skipped" // a file or cleanup path was left alone; Reason says why
+	EventError   EventType = "error"   // a file could not be read, archived or deleted
+)
+
+// Event reports progress of CleanJunk and ScanLargeFiles to library users
+type Event struct {
+	Type   EventType
+	Path   string
+	Size   int64  // file size in bytes (size_mode applies in cleans); 0 for cleanup paths
+	Reason string // for skipped and error events
+	Err    error  // for error events
+}
+
+// eventHandler delivers events to the hook, one at a time
+type eventHandler struct {
+	mu sync.Mutex
+	fn func(Event)
+}
+
+// SetEventHandler makes CleanJunk and ScanLargeFiles call fn for every file
+// they find, delete, skip or fail on. Calls are serialized even when files
+// are deleted by several workers, and fn should return quickly because it
+// runs on the deleting goroutines; it must not call SetEventHandler itself.
+// nil turns events off.
+func (sc *SystemCleaner) SetEventHandler(fn func(Event)) {
+	sc.events.mu.Lock()
+	defer sc.events.mu.Unlock()
+	sc.events.fn = fn
+}
+
+// emit passes an event to the handler, if one is set, and records error
+// events for ErrorSummary
+func (sc *SystemCleaner) emit(e Event) {
+	if e.Type == EventError && e.Err != nil {
+		sc.failures.add(e.Path, e.Err)
+	}
+	sc.events.mu.Lock()
+	defer sc.events.mu.Unlock()
+	if sc.events.fn != nil {
+		sc.events.fn(e)
+	}
+}
@@ -0,0 +1,112 @@
+package cleaner
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sensitiveKeys are config keys whose values may embed credentials, such as a
+// token in a hook's curl command
+var sensitiveKeys = map[string]bool{
+	"pre_hook":  true,
+	"post_hook": true,
+}
+
+// redactSource hides the password of a config URL
+func redactSource(source string) string {
+	if !isConfigURL(source) {
+		return source
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		return source
+	}
+	return u.Redacted()
+}
+
+// markInherited notes the global value beside each rule a cleanup path
+// leaves unset
+func markInherited(entry *yaml.Node, inherited map[string]string) {
+	for i := 0; i+1 < len(entry.Content); i += 2 {
+		key, value := entry.Content[i], entry.Content[i+1]
+		if global, ok := inherited[key.Value]; ok && value.Tag == "!!null" {
+			value.LineComment = "inherits " + global
+		}
+	}
+}
+
+// ExplainConfig prints the configuration in effect as YAML: the file after
+// environment overrides, defaults and command-line changes. Worker counts
+// taken from io_profile are noted beside their keys, and keys that may hold
+// credentials are marked. It changes nothing.
+func (sc *SystemCleaner) ExplainConfig() error {
+	var root yaml.Node
+	if err := root.Encode(sc.config); err != nil {
+		return err
+	}
+
+	source := redactSource(sc.config.source)
+	switch source {
+	case "":
+		source = "no file"
+	case "-":
+		source = "stdin"
+	}
+	root.HeadComment = fmt.Sprintf("Effective configuration, loaded from %s with CLEANPC_ overrides, defaults and flags applied", source)
+	if os.Getenv(configTokenEnv) != "" {
+		root.HeadComment += fmt.Sprintf("\n%s is set (value redacted)", configTokenEnv)
+	}
+
+	derived := map[string]int{
+		"scan_workers":   sc.config.scanWorkers(),
+		"clean_workers":  sc.config.cleanWorkers(),
+		"delete_workers": sc.config.deleteWorkers(),
+		"hash_workers":   sc.config.hashWorkers(),
+	}
+	inherited := map[string]string{
+		"min_age_days": fmt.Sprint(sc.config.MinAgeDays),
+		"min_size":     fmt.Sprint(sc.config.MinSize),
+		"keep_recent":  fmt.Sprint(sc.config.KeepRecent),
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if n, ok := derived[key.Value]; ok && value.Value == "0" {
+			value.LineComment = fmt.Sprintf("%d from io_profile %s", n, sc.config.IOProfile)
+		}
+		if key.Value == "network_workers" && value.Value == "0" {
+			value.LineComment = fmt.Sprintf("%d by default", sc.config.networkWorkers())
+		}
+		if key.Value == "log_file" && sc.config.logPath != "" && sc.config.logPath != value.Value {
+			value.LineComment = "this run: " + sc.config.logPath
+		}
+		if key.Value == "max_file_size" {
+			if limit, ok := sc.config.extLimits["*"]; ok {
+				value.LineComment = fmt.Sprintf("overridden by \"*\": %d for unlisted extensions", limit)
+			}
+		}
+		if key.Value == "max_file_size_by_ext" {
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				ext, size := value.Content[j], value.Content[j+1]
+				size.LineComment = fmt.Sprintf("%d bytes", sc.config.extLimits[normalizeExt(ext.Value)])
+			}
+		}
+		if key.Value == "cleanup_paths" {
+			for _, entry := range value.Content {
+				markInherited(entry, inherited)
+			}
+		}
+		if sensitiveKeys[key.Value] && value.Value != "" {
+			key.HeadComment = "may contain credentials, review before sharing"
+		}
+	}
+
+	encoder := yaml.NewEncoder(sc.out)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -0,0 +1,46 @@
+package cleaner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// validExtLimits parses max_file_size_by_ext into extLimits. Keys are
+// extensions such as ".log", matched case-insensitively, or "*" for every
+// extension not listed, which replaces max_file_size as the fallback.
+func (c *Config) validExtLimits() error {
+	c.extLimits = make(map[string]int64, len(c.MaxFileSizeByExt))
+	for ext, size := range c.MaxFileSizeByExt {
+		limit, err := parseSize(size)
+		if err != nil {
+			return fmt.Errorf("max_file_size_by_ext %s: %w", ext, err)
+		}
+		c.extLimits[normalizeExt(ext)] = limit
+	}
+	return nil
+}
+
+// normalizeExt lowercases an extension key and gives it a leading dot
+func normalizeExt(ext string) string {
+	if ext == "*" {
+		return ext
+	}
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// largeThreshold returns the size above which a file counts as large, from
+// max_file_size_by_ext for its extension, then "*", then max_file_size
+func (c *Config) largeThreshold(path string) int64 {
+	if limit, ok := c.extLimits[strings.ToLower(filepath.Ext(path))]; ok {
+		return limit
+	}
+	if limit, ok := c.extLimits["*"]; ok {
+		return limit
+	}
+	return c.MaxFileSize
+}
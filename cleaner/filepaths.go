@@ -0,0 +1,37 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+)
+
+// findFilePaths returns the cleanup paths that are regular files rather than
+// directories, a common config mistake, and logs each one
+func (sc *SystemCleaner) findFilePaths() map[string]bool {
+	files := make(map[string]bool)
+	for _, cp := range sc.config.CleanupPaths {
+		info, err := os.Stat(cp.Path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files[cp.Path] = true
+		if sc.config.CleanFilePaths {
+			sc.logger.Printf("Cleanup path %s is a file; cleaning it as a single file (clean_file_paths)", cp.Path)
+		} else {
+			sc.logger.Printf("Cleanup path %s is a file, not a directory; skipping it", cp.Path)
+		}
+	}
+	return files
+}
+
+// skipFilePath reports whether a cleanup path is a file that must be left
+// alone, warning about it the first time it is reached
+func (sc *SystemCleaner) skipFilePath(path string) bool {
+	if !sc.filePaths[path] || sc.config.CleanFilePaths {
+		return false
+	}
+	if _, warned := sc.warnedFiles.LoadOrStore(path, true); !warned {
+		fmt.Fprintf(sc.msg, "⚠️  Skipping %s: it is a file, not a directory (set clean_file_paths: true to clean it)\n", path)
+	}
+	return true
+}
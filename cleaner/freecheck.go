@@ -0,0 +1,123 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+// freedGroup is one filesystem watched by check_freed_space
+type freedGroup struct {
+	dir     string // a cleanup path on the filesystem, used to measure it
+	dev     uint64
+	hasDev  bool
+	before  int64 // free bytes when the clean started
+	deleted atomic.Int64
+}
+
+// freedCheck compares the space a clean's deletions should free with the
+// change in free space of their filesystems
+type freedCheck struct {
+	groups []*freedGroup
+	byPath map[string]*freedGroup
+}
+
+// startFreedCheck measures the free space of every filesystem holding one of
+// paths; it returns nil unless check_freed_space is set
+func (sc *SystemCleaner) startFreedCheck(paths []CleanupPath) *freedCheck {
+	if !sc.config.CheckFreedSpace || sc.dryRun {
+		return nil
+	}
+
+	check := &freedCheck{byPath: make(map[string]*freedGroup)}
+	byDevice := make(map[uint64]*freedGroup)
+	for _, cp := range paths {
+		info, err := os.Stat(cp.Path)
+		if err != nil {
+			continue
+		}
+		dev, ok := deviceID(info)
+		if g := byDevice[dev]; ok && g != nil {
+			check.byPath[cp.Path] = g
+			continue
+		}
+		usage, err := disk.Usage(cp.Path)
+		if err != nil {
+			sc.logger.Printf("Error measuring free space of %s: %v", cp.Path, err)
+			continue
+		}
+		g := &freedGroup{dir: cp.Path, dev: dev, hasDev: ok, before: int64(usage.Free)}
+		check.groups = append(check.groups, g)
+		check.byPath[cp.Path] = g
+		if ok {
+			byDevice[dev] = g
+		}
+	}
+	return check
+}
+
+// deleted records the disk space of a file deleted from a cleanup path
+func (c *freedCheck) deleted(path string, size int64) {
+	if c == nil {
+		return
+	}
+	if g := c.byPath[path]; g != nil {
+		g.deleted.Add(size)
+	}
+}
+
+// written records space the clean itself used, such as its archive, on the
+// filesystem of dir
+func (c *freedCheck) written(dir string, size int64) {
+	if c == nil {
+		return
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return
+	}
+	dev, ok := deviceID(info)
+	if !ok {
+		return
+	}
+	for _, g := range c.groups {
+		if g.hasDev && g.dev == dev {
+			g.deleted.Add(-size)
+			return
+		}
+	}
+}
+
+// finishFreedCheck measures free space again and warns where it changed by
+// much less or more than the deleted files account for: open file handles,
+// hard links and snapshots keep space in use after a delete
+func (sc *SystemCleaner) finishFreedCheck(c *freedCheck) {
+	if c == nil {
+		return
+	}
+
+	var measured int64
+	for _, g := range c.groups {
+		usage, err := disk.Usage(g.dir)
+		if err != nil {
+			sc.logger.Printf("Error measuring free space of %s: %v", g.dir, err)
+			continue
+		}
+		delta := int64(usage.Free) - g.before
+		expected := g.deleted.Load()
+		measured += delta
+		sc.logger.Printf("Freed space check for %s: %d bytes deleted, free space changed by %d bytes", g.dir, expected, delta)
+		fmt.Fprintf(sc.out, "🔬 %s: deleted files held %s, free space grew by %s\n", g.dir, sc.FormatSize(expected), sc.FormatSize(delta))
+
+		diff := delta - expected
+		if diff < 0 {
+			diff = -diff
+		}
+		if tolerance := max(expected/10, 1<<20); diff > tolerance {
+			fmt.Fprintf(sc.out, "⚠️  Free space on %s does not match what was deleted: files may still be open, hard-linked or kept by a snapshot\n", g.dir)
+		}
+	}
+	sc.progress.diskFreed(measured)
+}
@@ -0,0 +1,443 @@
+package cleaner
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+// freePercent returns the percentage of free space on the disk holding path
+func freePercent(path string) (float64, error) {
+	usage, err := disk.Usage(path)
+	if err != nil {
+		return 0, err
+	}
+	if usage.Total == 0 {
+		return 0, fmt.Errorf("disk %s reports zero total size", path)
+	}
+	return float64(usage.Free) / float64(usage.Total) * 100, nil
+}
+
+// checkReserve reports ErrReserve when writing another size bytes into dir
+// would leave its filesystem with less than reserve bytes free
+func checkReserve(dir string, reserve, size int64) error {
+	if reserve <= 0 {
+		return nil
+	}
+	usage, err := disk.Usage(dir)
+	if err != nil {
+		return err
+	}
+	if left := int64(usage.Free) - size; left < reserve {
+		return fmt.Errorf("%w: writing %d bytes to %s would leave %d bytes free, min_free_reserve is %d",
+			ErrReserve, size, dir, left, reserve)
+	}
+	return nil
+}
+
+// freeTargetPaths returns the cleanup paths a free-space clean may take files
+// from: the ones a junk clean would clean, without asking about each
+func (sc *SystemCleaner) freeTargetPaths() []CleanupPath {
+	return sc.sizeLimitedPaths(sc.cooledDownPaths(sc.idlePaths(sc.writablePaths(sc.config.CleanupPaths))))
+}
+
+// reclaimableBytes sums the size of the files a free-space clean could
+// delete: the junk of freeTargetPaths that belongs to a tier, after keep_list
+// and rules_file
+func (sc *SystemCleaner) reclaimableBytes() (int64, error) {
+	if err := sc.loadKeep(); err != nil {
+		return 0, err
+	}
+	paths := sc.freeTargetPaths()
+	tiers := sc.config.freeSpaceTiers()
+	now := time.Now()
+	sizes := make([]int64, len(paths))
+	runParallel(len(paths), sc.config.scanWorkers(), func(i int) {
+		err := sc.walkJunk(paths[i], func(path string, info fs.FileInfo) {
+			for _, tier := range tiers {
+				if tier.matches(path, info, now) {
+					sizes[i] += sc.fileSize(info)
+					return
+				}
+			}
+		})
+		if err != nil {
+			sc.logger.Printf("Error scanning directory %s: %v", paths[i].Path, err)
+		}
+	})
+	var total int64
+	for _, size := range sizes {
+		total += size
+	}
+	return total, nil
+}
+
+// EstimateFreeTarget reports whether cleaning the cleanup paths can free
+// enough space to bring diskPath to targetPercent free, warning if it cannot
+func (sc *SystemCleaner) EstimateFreeTarget(diskPath string, targetPercent float64) (bool, error) {
+	usage, err := disk.Usage(diskPath)
+	if err != nil {
+		return false, &ScanError{Path: diskPath, Err: err}
+	}
+
+	needed := int64(float64(usage.Total)*targetPercent/100) - int64(usage.Free)
+	if needed <= 0 {
+		return true, nil
+	}
+
+	reclaimable, err := sc.reclaimableBytes()
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprintf(sc.msg, "\n📐 Need to free %s, the cleanable files hold %s\n",
+		sc.FormatSize(needed), sc.FormatSize(reclaimable))
+	if reclaimable < needed {
+		fmt.Fprintf(sc.msg, "⚠️  Cleaning will free at most %s but you need %s free\n",
+			sc.FormatSize(reclaimable), sc.FormatSize(needed))
+		return false, nil
+	}
+	return true, nil
+}
+
+// FreeSpaceTier is one step of a tiered free-space clean. Its filters apply on
+// top of the cleanup path rules.
+type FreeSpaceTier struct {
+	Name       string   `yaml:"name" json:"name"`
+	Patterns   []string `yaml:"patterns" json:"patterns,omitempty"` // base-name globs; empty matches every file
+	MinAgeDays int      `yaml:"min_age_days" json:"min_age_days,omitempty"`
+}
+
+// matches reports whether a cleanable file belongs to the tier
+func (t FreeSpaceTier) matches(path string, info fs.FileInfo, now time.Time) bool {
+	if now.Sub(info.ModTime()) < time.Duration(t.MinAgeDays)*24*time.Hour {
+		return false
+	}
+	if len(t.Patterns) == 0 {
+		return true
+	}
+	name := filepath.Base(path)
+	for _, pattern := range t.Patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// freeSpaceTiers returns the configured tiers, or a single tier taking every
+// cleanable file when there are none
+func (c *Config) freeSpaceTiers() []FreeSpaceTier {
+	if len(c.FreeSpaceTiers) == 0 {
+		return []FreeSpaceTier{{Name: "all"}}
+	}
+	return c.FreeSpaceTiers
+}
+
+// collectCandidates lists the cleanable files under paths that belong to
+// tier, ordered by the configured free-space strategy. ruled is called for
+// every file a rules_file rule decides, as in a junk clean.
+func (sc *SystemCleaner) collectCandidates(paths []CleanupPath, tier FreeSpaceTier, ruled func(path string, info fs.FileInfo, rule *junkRule)) []FileInfo {
+	var files []FileInfo
+	now := time.Now()
+	for _, cp := range paths {
+		err := sc.walkJunkWith(cp, walkOptions{ruled: ruled}, func(path string, info fs.FileInfo) {
+			if tier.matches(path, info, now) {
+				files = append(files, FileInfo{Path: path, Size: sc.fileSize(info), ModTime: info.ModTime()})
+			}
+		})
+		if err != nil {
+			sc.logger.Printf("Error accessing path %s: %v", cp.Path, err)
+		}
+	}
+
+	switch sc.config.FreeSpaceStrategy {
+	case "largest":
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].Size > files[j].Size
+		})
+	default:
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].ModTime.Before(files[j].ModTime)
+		})
+	}
+	return files
+}
+
+// plannedTier is a tier a free-space clean reaches, with the files it takes
+// from it
+type plannedTier struct {
+	name  string
+	files []FileInfo
+}
+
+// freePlanner plans the deletions of one free-space clean, remembering across
+// plans the files already taken and what rules_file decided for each file
+type freePlanner struct {
+	sc       *SystemCleaner
+	paths    []CleanupPath
+	seen     map[string]bool // files taken by an earlier tier or plan
+	archived map[string]bool // files an archive rule of rules_file matched
+	ruled    func(path string, info fs.FileInfo, rule *junkRule)
+}
+
+// newFreePlanner returns a planner taking files from paths
+func (sc *SystemCleaner) newFreePlanner(paths []CleanupPath) *freePlanner {
+	p := &freePlanner{sc: sc, paths: paths, seen: make(map[string]bool), archived: make(map[string]bool)}
+	decided := make(map[string]bool)
+	apply := sc.applyRule(p.archived)
+	p.ruled = func(path string, info fs.FileInfo, rule *junkRule) {
+		if !decided[path] { // later tiers and plans walk the same files again
+			decided[path] = true
+			apply(path, info, rule)
+		}
+	}
+	return p
+}
+
+// plan picks the files to delete to free needed bytes, tier by tier,
+// stopping at the tier that covers them. Files taken by an earlier plan are
+// left out, so a plan with no files means the candidates are used up.
+func (p *freePlanner) plan(needed int64) (plan []plannedTier, count int) {
+	var planned int64
+	for _, tier := range p.sc.config.freeSpaceTiers() {
+		if planned >= needed {
+			break
+		}
+		pt := plannedTier{name: tier.Name}
+		for _, file := range p.sc.collectCandidates(p.paths, tier, p.ruled) {
+			if planned >= needed {
+				break
+			}
+			if p.seen[file.Path] {
+				continue // already taken by an earlier tier or plan
+			}
+			p.seen[file.Path] = true
+			pt.files = append(pt.files, file)
+			planned += file.Size
+		}
+		plan = append(plan, pt)
+		count += len(pt.files)
+	}
+	return plan, count
+}
+
+// archives reports whether file is archived before it is deleted
+func (p *freePlanner) archives(file FileInfo) bool {
+	return p.sc.config.ArchiveBeforeDelete || p.archived[file.Path]
+}
+
+// tierNames lists the tiers of a plan
+func tierNames(plan []plannedTier) string {
+	names := make([]string, len(plan))
+	for i, pt := range plan {
+		names[i] = pt.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// previewFreeTarget lists the files of a free-space clean plan without
+// deleting anything
+func (sc *SystemCleaner) previewFreeTarget(planner *freePlanner, plan []plannedTier, needed int64) {
+	var preview *archivePreview
+	if sc.config.ArchiveBeforeDelete || len(planner.archived) > 0 {
+		preview = newArchivePreview(sc.config.ArchiveDir)
+	}
+	var count int
+	var freed int64
+	for _, pt := range plan {
+		for _, file := range pt.files {
+			if info, err := os.Lstat(file.Path); err == nil && preview != nil && planner.archives(file) {
+				fmt.Fprintf(sc.out, "🧪 Would archive %s as %s and delete it (%s)\n", file.Path, preview.add(info, file.Path), sc.FormatSize(file.Size))
+			} else {
+				fmt.Fprintf(sc.out, "🧪 Would delete %s (%s)\n", file.Path, sc.FormatSize(file.Size))
+			}
+			sc.scriptDelete(file.Path, file.Size, "")
+			count++
+			freed += file.Size
+		}
+	}
+
+	fmt.Fprintf(sc.out, "🧪 Dry run: %d files (%s) would be deleted to free %s\n",
+		count, sc.FormatSize(freed), sc.FormatSize(needed))
+	if len(sc.config.FreeSpaceTiers) > 0 {
+		fmt.Fprintf(sc.out, "🪜 Tiers reached: %s\n", tierNames(plan))
+	}
+	if preview != nil {
+		sc.reportArchivePreview(preview)
+	}
+}
+
+// neededBytes returns how many bytes must be freed on the disk holding
+// diskPath to reach targetPercent free, with the current free percentage
+func neededBytes(diskPath string, targetPercent float64) (needed int64, free float64, err error) {
+	usage, err := disk.Usage(diskPath)
+	if err != nil {
+		return 0, 0, err
+	}
+	if usage.Total == 0 {
+		return 0, 0, fmt.Errorf("disk %s reports zero total size", diskPath)
+	}
+	free = float64(usage.Free) / float64(usage.Total) * 100
+	return int64(float64(usage.Total)*targetPercent/100) - int64(usage.Free), free, nil
+}
+
+// CleanToFreeTarget deletes files from the cleanup paths until the disk
+// holding diskPath has at least targetPercent free space. The files are
+// planned first, so max_delete_files is checked before anything is deleted.
+// When a plan is used up short of the target, because deleting freed less
+// than the files' sizes promised, the clean plans again from the files left
+// and checks max_delete_files against the new total. rules_file rules and
+// archive_before_delete apply as in a full clean.
+func (sc *SystemCleaner) CleanToFreeTarget(diskPath string, targetPercent float64) (err error) {
+	fmt.Fprintf(sc.msg, "\n🎯 Cleaning until %s has %.1f%% free (strategy: %s)...\n",
+		diskPath, targetPercent, sc.config.FreeSpaceStrategy)
+
+	needed, free, err := neededBytes(diskPath, targetPercent)
+	if err != nil {
+		return &ScanError{Path: diskPath, Err: err}
+	}
+	if free >= targetPercent {
+		fmt.Fprintf(sc.out, "✅ Target already met: %.1f%% free\n", free)
+		return nil
+	}
+
+	if err := sc.loadKeep(); err != nil {
+		return err
+	}
+	planner := sc.newFreePlanner(sc.freeTargetPaths())
+	plan, count := planner.plan(needed)
+	if sc.dryRun {
+		sc.previewFreeTarget(planner, plan, needed)
+		return nil
+	}
+	if err := sc.checkDeleteCount(count); err != nil {
+		return err
+	}
+	audit, err := sc.openAudit()
+	if err != nil {
+		return err
+	}
+	defer sc.closeAudit(audit)
+
+	var archive *junkArchive
+	if sc.config.ArchiveBeforeDelete || sc.config.archivesByRule() {
+		archive, err = newJunkArchive(sc.config.ArchiveDir, sc.config.MinFreeReserve)
+		if err != nil {
+			sc.logger.Printf("Not cleaning: cannot archive to %s: %v", sc.config.ArchiveDir, err)
+			return &CleanError{Path: sc.config.ArchiveDir, Err: err}
+		}
+		defer func() {
+			if _, closeErr := sc.closeArchive(archive); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}()
+	}
+
+	sc.progress.start(nil)
+	defer sc.progress.finish()
+
+	var deleted int
+	var freed int64
+	var reached []string
+	reachedTier := make(map[string]bool)
+	remover := newFileRemover()
+	defer remover.close()
+	defer sc.startBudget()()
+
+	for planned := count; count > 0 && free < targetPercent; {
+		for _, tier := range plan {
+			if free >= targetPercent {
+				break
+			}
+			if !reachedTier[tier.name] {
+				reachedTier[tier.name] = true
+				reached = append(reached, tier.name)
+				sc.logger.Printf("Free-space clean escalating to tier %s", tier.name)
+			}
+			candidates := tier.files
+
+			for start := 0; start < len(candidates) && free < targetPercent; start += sc.config.FreeSpaceBatch {
+				select {
+				case <-sc.stopChan:
+					return &CleanError{Path: diskPath, Err: ErrInterrupted}
+				case <-sc.budgetSpent():
+					sc.reportBudget(0)
+					return nil
+				default:
+				}
+
+				end := start + sc.config.FreeSpaceBatch
+				if end > len(candidates) {
+					end = len(candidates)
+				}
+				for _, file := range candidates[start:end] {
+					if archive != nil && planner.archives(file) {
+						info, err := os.Lstat(file.Path)
+						if err == nil {
+							err = archive.add(file.Path, info)
+						}
+						if err != nil {
+							sc.logger.Printf("Error archiving file %s, keeping it: %v", file.Path, err)
+							sc.emit(Event{Type: EventError, Path: file.Path, Size: file.Size, Reason: "archiving failed, file kept", Err: err})
+							continue
+						}
+					}
+					if err := sc.removeFile(remover, file.Path); err != nil {
+						sc.logger.Printf("Error removing file %s: %v", file.Path, err)
+						sc.emit(Event{Type: EventError, Path: file.Path, Size: file.Size, Reason: "delete failed", Err: err})
+						continue
+					}
+					sc.audit(audit, file.Path, file.Size)
+					deleted++
+					freed += file.Size
+					sc.progress.deleted(file.Size)
+				}
+
+				free, err = freePercent(diskPath)
+				if err != nil {
+					return &ScanError{Path: diskPath, Err: err}
+				}
+			}
+		}
+		if free >= targetPercent {
+			break
+		}
+
+		// the plan is used up short of the target: plan again from what is left
+		needed, free, err = neededBytes(diskPath, targetPercent)
+		if err != nil {
+			return &ScanError{Path: diskPath, Err: err}
+		}
+		if free >= targetPercent {
+			break
+		}
+		plan, count = planner.plan(needed)
+		if count == 0 {
+			break
+		}
+		sc.logger.Printf("Free-space clean still needs %d bytes, planning %d more files", needed, count)
+		planned += count
+		if err := sc.checkDeleteCount(planned); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(sc.out, "🗑️  Deleted %d files (%s)\n", deleted, sc.FormatSize(freed))
+	sc.reportNotDeleted()
+	if len(sc.config.FreeSpaceTiers) > 0 {
+		fmt.Fprintf(sc.out, "🪜 Tiers reached: %s\n", strings.Join(reached, ", "))
+	}
+	if free < targetPercent {
+		fmt.Fprintf(sc.out, "⚠️  Ran out of candidates: %s is at %.1f%% free (target %.1f%%)\n", diskPath, free, targetPercent)
+		return nil
+	}
+
+	fmt.Fprintf(sc.out, "✅ Target reached: %s is at %.1f%% free\n", diskPath, free)
+	return nil
+}
@@ -0,0 +1,131 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+// TestCleanToFreeTargetReplans checks that a clean whose deletions free less
+// than planned goes on to the remaining candidates instead of giving up
+func TestCleanToFreeTargetReplans(t *testing.T) {
+	root := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	// sparse files promise their apparent size but free next to nothing, and
+	// being the oldest they are planned first
+	var sparse, real []string
+	for i := 0; i < 2; i++ {
+		path := filepath.Join(root, fmt.Sprintf("sparse%d", i))
+		file, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := file.Truncate(64 << 20); err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+		if err := os.Chtimes(path, old, old.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		sparse = append(sparse, path)
+	}
+	for i := 0; i < 16; i++ {
+		path := filepath.Join(root, fmt.Sprintf("real%02d", i))
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", 1<<20)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		real = append(real, path)
+	}
+	sc := newTestCleaner(t, cleanConfig([]string{root}, 1, 1)+"free_space_batch: 1\n")
+
+	usage, err := disk.Usage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allocatedSize(mustLstat(t, sparse[0])) >= 1<<20 {
+		t.Skip("the filesystem does not support sparse files")
+	}
+	// a target 4 MiB above the current free space, which only the real
+	// files can reach
+	target := float64(usage.Free+4<<20) / float64(usage.Total) * 100
+	if err := sc.CleanToFreeTarget(root, target); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range sparse {
+		if _, err := os.Lstat(path); !os.IsNotExist(err) {
+			t.Errorf("%s was not deleted: %v", path, err)
+		}
+	}
+	var deleted int
+	for _, path := range real {
+		if _, err := os.Lstat(path); os.IsNotExist(err) {
+			deleted++
+		}
+	}
+	if deleted == 0 || deleted == len(real) {
+		t.Errorf("deleted %d of %d real files, want enough to reach the target and no more", deleted, len(real))
+	}
+}
+
+func mustLstat(t *testing.T, path string) os.FileInfo {
+	t.Helper()
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func TestReclaimableBytes(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string // added to the config; DIR holds keep.txt and rules.yaml
+		want   []string
+	}{
+		{"every file", "", []string{"a.tmp", "b.log", "c.pdf"}},
+		{"min_size", "min_size: 200\n", []string{"b.log", "c.pdf"}},
+		{"keep_list", "keep_list: DIR/keep.txt\n", []string{"a.tmp", "c.pdf"}},
+		{"rules_file keep and report", "rules_file: DIR/rules.yaml\n", []string{"a.tmp"}},
+		{"tiers", "free_space_tiers:\n  - name: temp\n    patterns: [\"*.tmp\"]\n  - name: logs\n    patterns: [\"*.log\"]\n", []string{"a.tmp", "b.log"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, dir := t.TempDir(), t.TempDir()
+			sizes := map[string]int{"a.tmp": 100, "b.log": 200, "c.pdf": 400}
+			for name, size := range sizes {
+				if err := os.WriteFile(filepath.Join(root, name), []byte(strings.Repeat("x", size)), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			extra := map[string]string{
+				"keep.txt":   filepath.Join(root, "b.log") + "\n",
+				"rules.yaml": "rules:\n  - name: docs\n    match: {ext: [.pdf]}\n    action: keep\n  - name: logs\n    match: {ext: [.log]}\n    action: report\n  - name: rest\n    action: delete\n",
+			}
+			for name, data := range extra {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			config := strings.ReplaceAll(tt.config, "DIR", dir)
+			sc := newTestCleaner(t, cleanConfig([]string{root}, 1, 1)+config)
+
+			got, err := sc.reclaimableBytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want int64
+			for _, name := range tt.want {
+				want += int64(sizes[name])
+			}
+			if got != want {
+				t.Errorf("reclaimable %d bytes, want %d (%v)", got, want, tt.want)
+			}
+		})
+	}
+}
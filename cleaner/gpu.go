@@ -0,0 +1,76 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GPUSample is one reading of a GPU; memory is in bytes
+type GPUSample struct {
+	Index       int     `json:"index"`
+	Name        string  `json:"name"`
+	UtilPercent float64 `json:"util_percent"`
+	MemUsed     int64   `json:"mem_used"`
+	MemTotal    int64   `json:"mem_total"`
+}
+
+// nvidiaSMI reads NVIDIA GPUs through nvidia-smi, which ships with the driver
+// and talks to NVML for us, so the binary needs no cgo or vendor library
+const nvidiaSMI = "nvidia-smi"
+
+// gpuAvailable reports whether GPU readings can be taken on this system
+func gpuAvailable() bool {
+	_, err := exec.LookPath(nvidiaSMI)
+	return err == nil
+}
+
+// readGPUs returns the current utilization and memory use of every GPU
+func readGPUs() ([]GPUSample, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, nvidiaSMI,
+		"--query-gpu=index,name,utilization.gpu,memory.used,memory.total",
+		"--format=csv,noheader,nounits").Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", nvidiaSMI, err)
+	}
+
+	var gpus []GPUSample
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, ",")
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected %s output: %q", nvidiaSMI, line)
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+
+		index, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("unexpected %s output: %q", nvidiaSMI, line)
+		}
+		// utilization is "[N/A]" on some boards; report it as -1 like a disabled metric
+		util, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			util = -1
+		}
+		used, errUsed := strconv.ParseInt(fields[3], 10, 64)
+		total, errTotal := strconv.ParseInt(fields[4], 10, 64)
+		if errUsed != nil || errTotal != nil {
+			return nil, fmt.Errorf("unexpected %s output: %q", nvidiaSMI, line)
+		}
+		gpus = append(gpus, GPUSample{
+			Index:       index,
+			Name:        fields[1],
+			UtilPercent: util,
+			MemUsed:     used << 20, // nvidia-smi reports MiB
+			MemTotal:    total << 20,
+		})
+	}
+	return gpus, nil
+}
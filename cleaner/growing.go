@@ -0,0 +1,49 @@
+package cleaner
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+// growthSampleInterval is how long detect_growing waits between the two size
+// samples of a file
+const growthSampleInterval = time.Second
+
+// junkFile is a file a clean is about to delete
+type junkFile struct {
+	path string
+	info fs.FileInfo
+}
+
+// stableFiles waits growthSampleInterval and drops the files that grew since
+// they were first seen, as something is still writing to them. It returns
+// false if the clean is interrupted while waiting.
+func (sc *SystemCleaner) stableFiles(files []junkFile) ([]junkFile, bool) {
+	if len(files) == 0 {
+		return nil, true
+	}
+	select {
+	case <-sc.stopChan:
+		return nil, false
+	case <-time.After(growthSampleInterval):
+	}
+
+	var stable []junkFile
+	for _, f := range files {
+		info, err := os.Lstat(f.path)
+		if err != nil {
+			sc.logger.Printf("Error accessing path %s: %v", f.path, err)
+			sc.emit(Event{Type: EventError, Path: f.path, Reason: "cannot re-check size", Err: err})
+			continue
+		}
+		if info.Size() > f.info.Size() {
+			sc.logger.Printf("Skipping %s: actively growing (%d to %d bytes in %s)",
+				f.path, f.info.Size(), info.Size(), growthSampleInterval)
+			sc.emit(Event{Type: EventSkipped, Path: f.path, Size: info.Size(), Reason: "actively growing"})
+			continue
+		}
+		stable = append(stable, junkFile{path: f.path, info: info})
+	}
+	return stable, true
+}
@@ -0,0 +1,315 @@
+package cleaner
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	_ "modernc.org/sqlite" // pure-Go driver, registered as "sqlite"
+)
+
+// historySchema creates the history tables; times are Unix seconds
+const historySchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id            INTEGER PRIMARY KEY,
+	started       INTEGER NOT NULL,
+	duration_ms   INTEGER NOT NULL,
+	files_deleted INTEGER NOT NULL,
+	bytes_freed   INTEGER NOT NULL,
+	errors        INTEGER NOT NULL, -- files and paths that failed
+	error         TEXT NOT NULL     -- the clean's own error, "" on success
+);
+CREATE TABLE IF NOT EXISTS run_paths (
+	run_id        INTEGER NOT NULL REFERENCES runs(id),
+	path          TEXT NOT NULL,
+	files_deleted INTEGER NOT NULL,
+	bytes_freed   INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS run_path_sizes (
+	run_id        INTEGER NOT NULL REFERENCES runs(id),
+	path          TEXT NOT NULL,
+	junk_files    INTEGER NOT NULL, -- junk found when the clean walked the path
+	junk_bytes    INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS runs_started ON runs(started);
+`
+
+// errNoHistory is returned by readHistory when nothing was recorded yet
+var errNoHistory = errors.New("no history yet")
+
+// openHistory opens history_db, creating its tables when they are missing
+func openHistory(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// readHistory opens history_db read-only, without creating the file or its
+// tables; it returns errNoHistory when the file or the runs table is missing
+func readHistory(path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil, errNoHistory
+	}
+	db, err := sql.Open("sqlite", "file:"+filepath.ToSlash(path)+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	ok, err := hasTable(db, "runs")
+	if err == nil && !ok {
+		err = errNoHistory
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// hasTable reports whether the database has the named table; a database
+// written by an older version may lack the newer ones
+func hasTable(db *sql.DB, name string) (bool, error) {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&n)
+	return n > 0, err
+}
+
+// recordHistory stores a finished clean in history_db. It is best-effort: a
+// failure is logged and never fails the clean. Dry runs are not recorded.
+func (sc *SystemCleaner) recordHistory(started time.Time, failures int, cleanErr error) {
+	if sc.config.HistoryDB == "" || sc.dryRun {
+		return
+	}
+	if err := sc.writeHistory(started, failures, cleanErr); err != nil {
+		sc.logger.Printf("Error recording run in history_db %s: %v", sc.config.HistoryDB, err)
+	}
+}
+
+// writeHistory does the work of recordHistory in one transaction
+func (sc *SystemCleaner) writeHistory(started time.Time, failures int, cleanErr error) error {
+	db, err := openHistory(sc.config.HistoryDB)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var message string
+	if cleanErr != nil {
+		message = cleanErr.Error()
+	}
+	p := sc.Progress()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT INTO runs (started, duration_ms, files_deleted, bytes_freed, errors, error) VALUES (?, ?, ?, ?, ?, ?)`,
+		started.Unix(), time.Since(started).Milliseconds(), p.FilesDeleted, p.BytesFreed, failures, message)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	for path, t := range sc.progress.pathTotals() {
+		if _, err := tx.Exec(`INSERT INTO run_paths (run_id, path, files_deleted, bytes_freed) VALUES (?, ?, ?, ?)`,
+			id, path, t.files, t.bytes); err != nil {
+			return err
+		}
+	}
+	for path, t := range sc.progress.foundTotals() {
+		if _, err := tx.Exec(`INSERT INTO run_path_sizes (run_id, path, junk_files, junk_bytes) VALUES (?, ?, ?, ?)`,
+			id, path, t.files, t.bytes); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// HistoryDay sums up the cleans of one day
+type HistoryDay struct {
+	Date         string `json:"date"` // YYYY-MM-DD, local time
+	Runs         int    `json:"runs"`
+	FilesDeleted int64  `json:"files_deleted"`
+	BytesFreed   int64  `json:"bytes_freed"`
+	Errors       int64  `json:"errors"`
+}
+
+// HistoryPath sums up what the cleans deleted from one cleanup path, with the
+// junk the latest of them found there
+type HistoryPath struct {
+	Path         string `json:"path"`
+	FilesDeleted int64  `json:"files_deleted"`
+	BytesFreed   int64  `json:"bytes_freed"`
+	JunkFiles    int64  `json:"junk_files"`
+	JunkBytes    int64  `json:"junk_bytes"`
+}
+
+// HistoryReport is what ShowHistory prints
+type HistoryReport struct {
+	Days         int           `json:"days"`
+	Runs         int           `json:"runs"`
+	Failed       int           `json:"failed"` // runs that ended with an error
+	FilesDeleted int64         `json:"files_deleted"`
+	BytesFreed   int64         `json:"bytes_freed"`
+	PerDay       []HistoryDay  `json:"per_day"`
+	Paths        []HistoryPath `json:"paths"`
+}
+
+// ShowHistory reports the cleans recorded in history_db over the last days
+// days: totals, a per-day breakdown and the paths that freed the most space
+func (sc *SystemCleaner) ShowHistory(days int) error {
+	if sc.config.HistoryDB == "" {
+		return &ConfigError{Path: sc.config.source, Err: fmt.Errorf("history_db is not set")}
+	}
+	if days <= 0 {
+		return fmt.Errorf("days must be positive, got %d", days)
+	}
+	report := HistoryReport{Days: days}
+	db, err := readHistory(sc.config.HistoryDB)
+	if errors.Is(err, errNoHistory) {
+		if sc.jsonOutput {
+			return sc.encodeHistory(report)
+		}
+		fmt.Fprintf(sc.out, "\n📜 No history yet in %s\n", sc.config.HistoryDB)
+		return nil
+	}
+	if err != nil {
+		return &ScanError{Path: sc.config.HistoryDB, Err: err}
+	}
+	defer db.Close()
+
+	since := time.Now().AddDate(0, 0, -days).Unix()
+
+	rows, err := db.Query(`SELECT started, files_deleted, bytes_freed, errors, error FROM runs WHERE started >= ? ORDER BY started`, since)
+	if err != nil {
+		return &ScanError{Path: sc.config.HistoryDB, Err: err}
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var started, files, bytes, errs int64
+		var message string
+		if err := rows.Scan(&started, &files, &bytes, &errs, &message); err != nil {
+			return &ScanError{Path: sc.config.HistoryDB, Err: err}
+		}
+		date := time.Unix(started, 0).Format("2006-01-02")
+		if n := len(report.PerDay); n == 0 || report.PerDay[n-1].Date != date {
+			report.PerDay = append(report.PerDay, HistoryDay{Date: date})
+		}
+		day := &report.PerDay[len(report.PerDay)-1]
+		day.Runs++
+		day.FilesDeleted += files
+		day.BytesFreed += bytes
+		day.Errors += errs
+		report.Runs++
+		report.FilesDeleted += files
+		report.BytesFreed += bytes
+		if message != "" {
+			report.Failed++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return &ScanError{Path: sc.config.HistoryDB, Err: err}
+	}
+
+	pathRows, err := db.Query(`SELECT p.path, SUM(p.files_deleted), SUM(p.bytes_freed) FROM run_paths p JOIN runs r ON r.id = p.run_id
+		WHERE r.started >= ? GROUP BY p.path`, since)
+	if err != nil {
+		return &ScanError{Path: sc.config.HistoryDB, Err: err}
+	}
+	defer pathRows.Close()
+	paths := make(map[string]*HistoryPath)
+	for pathRows.Next() {
+		var p HistoryPath
+		if err := pathRows.Scan(&p.Path, &p.FilesDeleted, &p.BytesFreed); err != nil {
+			return &ScanError{Path: sc.config.HistoryDB, Err: err}
+		}
+		paths[p.Path] = &p
+	}
+	if err := pathRows.Err(); err != nil {
+		return &ScanError{Path: sc.config.HistoryDB, Err: err}
+	}
+	if err := readJunkSizes(db, since, paths); err != nil {
+		return &ScanError{Path: sc.config.HistoryDB, Err: err}
+	}
+	for _, p := range paths {
+		report.Paths = append(report.Paths, *p)
+	}
+	sort.Slice(report.Paths, func(i, j int) bool { return report.Paths[i].BytesFreed > report.Paths[j].BytesFreed })
+
+	if sc.jsonOutput {
+		return sc.encodeHistory(report)
+	}
+
+	fmt.Fprintf(sc.out, "\n📜 Last %d days: %d cleans (%d failed), %d files deleted, %s freed\n",
+		days, report.Runs, report.Failed, report.FilesDeleted, sc.FormatSize(report.BytesFreed))
+	for _, day := range report.PerDay {
+		fmt.Fprintf(sc.out, "📅 %s  %s freed, %d files in %d cleans", day.Date, sc.FormatSize(day.BytesFreed), day.FilesDeleted, day.Runs)
+		if day.Errors > 0 {
+			fmt.Fprintf(sc.out, ", %d errors", day.Errors)
+		}
+		fmt.Fprintln(sc.out)
+	}
+	for i, p := range report.Paths {
+		if i == 0 {
+			fmt.Fprintln(sc.out, "\n📂 Space freed per cleanup path:")
+		}
+		fmt.Fprintf(sc.out, "📂 %s → %s in %d files", p.Path, sc.FormatSize(p.BytesFreed), p.FilesDeleted)
+		if p.JunkFiles > 0 {
+			fmt.Fprintf(sc.out, ", %s of junk found by the last clean", sc.FormatSize(p.JunkBytes))
+		}
+		fmt.Fprintln(sc.out)
+	}
+	return nil
+}
+
+// readJunkSizes fills in the junk found in each path by the latest clean
+// since the given time, adding the paths that had junk but none deleted
+func readJunkSizes(db *sql.DB, since int64, paths map[string]*HistoryPath) error {
+	if ok, err := hasTable(db, "run_path_sizes"); err != nil || !ok {
+		return err
+	}
+	rows, err := db.Query(`SELECT s.path, s.junk_files, s.junk_bytes FROM run_path_sizes s JOIN runs r ON r.id = s.run_id
+		WHERE r.started >= ? ORDER BY r.started, r.id`, since)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var path string
+		var files, bytes int64
+		if err := rows.Scan(&path, &files, &bytes); err != nil {
+			return err
+		}
+		p := paths[path]
+		if p == nil && files == 0 {
+			continue
+		}
+		if p == nil {
+			p = &HistoryPath{Path: path}
+			paths[path] = p
+		}
+		p.JunkFiles, p.JunkBytes = files, bytes
+	}
+	return rows.Err()
+}
+
+// encodeHistory prints the report as indented JSON
+func (sc *SystemCleaner) encodeHistory(report HistoryReport) error {
+	encoder := json.NewEncoder(sc.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
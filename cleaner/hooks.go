@@ -0,0 +1,73 @@
+package cleaner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"sync"
+)
+
+// maxHookLine is the longest line of hook output that is logged
+const maxHookLine = 1 << 20
+
+// runHook runs a pre_hook or post_hook shell command, streaming each line it
+// writes to stdout or stderr into the log as it arrives, prefixed with
+// [name], and logging its exit code
+func (sc *SystemCleaner) runHook(name, command string) error {
+	if command == "" {
+		return nil
+	}
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+
+	sc.logger.Printf("[%s] running: %s", name, command)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	var wg sync.WaitGroup
+	for _, stream := range []io.Reader{stdout, stderr} {
+		wg.Add(1)
+		go func(r io.Reader) {
+			defer wg.Done()
+			scanner := bufio.NewScanner(r)
+			scanner.Buffer(nil, maxHookLine)
+			for scanner.Scan() {
+				sc.logger.Printf("[%s] %s", name, scanner.Text())
+			}
+			if err := scanner.Err(); err != nil {
+				sc.logger.Printf("[%s] output not logged past this point: %v", name, err)
+			}
+			// keep draining, or the hook blocks on a full pipe and never exits
+			io.Copy(io.Discard, r)
+		}(stream)
+	}
+	// all output must be read before Wait closes the pipes
+	wg.Wait()
+
+	err = cmd.Wait()
+	code := cmd.ProcessState.ExitCode()
+	sc.logger.Printf("[%s] exited with code %d", name, code)
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("%s exited with code %d", name, code)
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunHookLongLines(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook commands are written for sh")
+	}
+	tests := []struct {
+		name   string
+		length int
+	}{
+		{"short line", 10},
+		{"longer than the default scanner buffer", 100 << 10},
+		{"longer than maxHookLine", 2 * maxHookLine},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			// the hook keeps writing after the long line, so it blocks if
+			// the pipe is not drained
+			script := filepath.Join(dir, "hook.sh")
+			err := os.WriteFile(script, []byte(fmt.Sprintf("head -c %d /dev/zero | tr '\\0' x\necho\nyes | head -n 100000\necho done >&2\n", tt.length)), 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sc := newTestCleaner(t, "")
+
+			done := make(chan error, 1)
+			go func() { done <- sc.runHook("pre_hook", "sh "+script) }()
+			select {
+			case err := <-done:
+				if err != nil {
+					t.Fatal(err)
+				}
+			case <-time.After(30 * time.Second):
+				t.Fatal("runHook did not return")
+			}
+			if err := sc.Close(); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(sc.config.logPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			log := string(data)
+			if !strings.Contains(log, "[pre_hook] done") || !strings.Contains(log, "[pre_hook] exited with code 0") {
+				t.Errorf("log misses the hook's stderr or exit code:\n%.500s", log)
+			}
+			if logged := strings.Contains(log, strings.Repeat("x", tt.length)); logged != (tt.length <= maxHookLine) {
+				t.Errorf("line of %d bytes logged: %v", tt.length, logged)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package cleaner
+
+import (
+	"bufio"
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreFileName is the per-directory file listing patterns to skip
+const ignoreFileName = ".cleanignore"
+
+// ignoreRule is a single pattern read from a .cleanignore file
+type ignoreRule struct {
+	base     string // directory holding the .cleanignore file
+	pattern  string
+	negate   bool // "!pattern" re-includes a previously ignored entry
+	dirOnly  bool // "pattern/" only matches directories
+	anchored bool // patterns containing "/" match the path relative to base
+}
+
+// matches reports whether the rule applies to the given path
+func (r ignoreRule) matches(p string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.anchored {
+		rel, err := filepath.Rel(r.base, p)
+		if err != nil {
+			return false
+		}
+		ok, _ := path.Match(r.pattern, filepath.ToSlash(rel))
+		return ok
+	}
+	ok, _ := path.Match(r.pattern, filepath.Base(p))
+	return ok
+}
+
+// isIgnored applies rules in order; like gitignore, the last match wins
+func isIgnored(rules []ignoreRule, p string, isDir bool) bool {
+	ignored := false
+	for _, r := range rules {
+		if r.matches(p, isDir) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// loadIgnoreFile reads the .cleanignore file in dir, if there is one
+func loadIgnoreFile(dir string) ([]ignoreRule, error) {
+	file, err := os.Open(filepath.Join(dir, ignoreFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: dir}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// skipRelative reports whether p matches skip_paths relative to root
+func (sc *SystemCleaner) skipRelative(root, p string) bool {
+	if len(sc.config.SkipPaths) == 0 {
+		return false
+	}
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return false
+	}
+	return sc.config.skipPath(filepath.ToSlash(rel))
+}
+
+// walk traverses root like filepath.WalkDir, skipping entries matched by the
+// .cleanignore files of their directory and its ancestors within root, and
+// entries whose path relative to root matches skip_paths, and mounts of other
+// filesystems with -one-filesystem or stay_on_filesystem. It stops with
+// ErrInterrupted once the cleaner is stopped.
+func (sc *SystemCleaner) walk(root string, fn fs.WalkDirFunc) error {
+	return sc.walkContext(context.Background(), root, fn)
+}
+
+// walkContext is walk that also gives up with ErrTimeout once ctx is done
+func (sc *SystemCleaner) walkContext(ctx context.Context, root string, fn fs.WalkDirFunc) error {
+	rules := make(map[string][]ignoreRule)
+	boundary := sc.newFSBoundary(root)
+	guard := sc.newWalkGuard(root)
+
+	// callers see paths under root as given, not the extended form walked
+	walked := walkRoot(root)
+	return filepath.WalkDir(walked, func(p string, d fs.DirEntry, err error) error {
+		if walked != root {
+			if p == walked {
+				p = root
+			} else {
+				p = filepath.Join(root, strings.TrimPrefix(p, walked))
+			}
+		}
+		select {
+		case <-sc.stopChan:
+			return ErrInterrupted
+		case <-ctx.Done():
+			return ErrTimeout
+		case <-sc.budgetSpent():
+			return ErrBudget
+		default:
+		}
+		if err != nil {
+			return fn(p, d, err)
+		}
+
+		if p != root && sc.crosses(boundary, p, d) {
+			return filepath.SkipDir
+		}
+		inherited := rules[filepath.Dir(p)]
+		if p != root && (isIgnored(inherited, p, d.IsDir()) || sc.skipRelative(root, p)) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && d.Name() == ignoreFileName {
+			return nil
+		}
+
+		if d.IsDir() {
+			if err := sc.checkDir(guard, p, d); err != nil {
+				return err
+			}
+			own, err := loadIgnoreFile(p)
+			if err != nil {
+				sc.logger.Printf("Error reading %s in %s: %v", ignoreFileName, p, err)
+			}
+			rules[p] = append(inherited[:len(inherited):len(inherited)], own...)
+		}
+
+		return fn(p, d, nil)
+	})
+}
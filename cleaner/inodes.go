@@ -0,0 +1,91 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// inodeHeavyShare is the share of a filesystem's used inodes from which a
+// cleanup path's junk files are flagged as inode pressure
+const inodeHeavyShare = 0.10
+
+// lowInodeShare is the share of free inodes under which a filesystem is
+// reported as running out of them
+const lowInodeShare = 0.10
+
+// FilesystemInodes is the inode table of a filesystem holding cleanup paths
+type FilesystemInodes struct {
+	Paths      []string `json:"paths"` // the cleanup paths on it
+	Inodes     uint64   `json:"inodes"`
+	FreeInodes uint64   `json:"free_inodes"`
+}
+
+// SetInodes makes ShowJunkUsage count the junk files of every cleanup path and
+// report the free inodes of their filesystems
+func (sc *SystemCleaner) SetInodes(enabled bool) {
+	sc.inodes = enabled
+}
+
+// addInodes fills in the filesystems of a junk report and the inode share of
+// every path. Filesystems without a fixed inode table (and platforms without
+// statfs) are left out.
+func (sc *SystemCleaner) addInodes(report *JunkReport) {
+	byDevice := make(map[uint64]int) // index into report.Filesystems, -1 for none
+	for i := range report.Paths {
+		usage := &report.Paths[i]
+		info, err := os.Stat(usage.Path)
+		if err != nil {
+			continue
+		}
+
+		dev, hasDev := deviceID(info)
+		index, seen := byDevice[dev]
+		if !hasDev || !seen {
+			index = -1
+			mount, err := statMount(usage.Path)
+			if err != nil {
+				sc.logger.Printf("Error reading inode counts of %s: %v", usage.Path, err)
+			} else if mount.inodes > 0 {
+				report.Filesystems = append(report.Filesystems, FilesystemInodes{Inodes: mount.inodes, FreeInodes: mount.freeInodes})
+				index = len(report.Filesystems) - 1
+			}
+			if hasDev {
+				byDevice[dev] = index
+			}
+		}
+		if index < 0 {
+			continue
+		}
+
+		fs := &report.Filesystems[index]
+		fs.Paths = append(fs.Paths, usage.Path)
+		if used := fs.Inodes - fs.FreeInodes; used > 0 {
+			usage.InodeShare = float64(usage.Files) / float64(used)
+			usage.InodeHeavy = usage.InodeShare >= inodeHeavyShare
+		}
+	}
+}
+
+// listInodes prints the free inodes of every filesystem and the cleanup paths
+// that hold a large share of the used ones
+func (sc *SystemCleaner) listInodes(report JunkReport) {
+	if len(report.Filesystems) == 0 {
+		fmt.Fprintln(sc.out, "\nℹ️  Inode counts are not available for these filesystems")
+		return
+	}
+
+	fmt.Fprintln(sc.out, "\n🗂️  Inodes:")
+	for _, fs := range report.Filesystems {
+		free := float64(fs.FreeInodes) / float64(fs.Inodes)
+		fmt.Fprintf(sc.out, "   %s → %d free of %d (%.1f%%)\n", strings.Join(fs.Paths, ", "), fs.FreeInodes, fs.Inodes, free*100)
+		if free < lowInodeShare {
+			fmt.Fprintln(sc.out, "   🚨 This filesystem is running out of inodes: writes can fail while bytes are still free")
+		}
+	}
+	for _, usage := range report.Paths {
+		if usage.InodeHeavy {
+			fmt.Fprintf(sc.out, "⚠️  %s holds %d junk files, %.0f%% of its filesystem's used inodes\n", usage.Path, usage.Files, usage.InodeShare*100)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package cleaner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Job is a named set of cleanup paths. Its rules apply to those of its paths
+// that do not set their own, in place of the global defaults. The top-level
+// recipes and dedupe do not apply to jobs; a job runs only its own.
+type Job struct {
+	Name            string        `yaml:"name" json:"name"`
+	CleanupPaths    []CleanupPath `yaml:"cleanup_paths" json:"cleanup_paths"`
+	MinAgeDays      *int          `yaml:"min_age_days" json:"min_age_days,omitempty"`
+	ExcludePatterns []string      `yaml:"exclude_patterns" json:"exclude_patterns,omitempty"`
+	MinSize         *int64        `yaml:"min_size" json:"min_size,omitempty"`
+	KeepRecent      *int          `yaml:"keep_recent" json:"keep_recent,omitempty"`
+	ContentTypes    []string      `yaml:"content_types" json:"content_types,omitempty"`
+	PathRegex       []string      `yaml:"path_regex" json:"path_regex,omitempty"`
+	Recipes         []string      `yaml:"recipes" json:"recipes,omitempty"`
+	Dedupe          bool          `yaml:"dedupe" json:"dedupe,omitempty"` // deduplicate across the job's paths
+}
+
+// paths returns the job's cleanup paths with the job's rules filled in
+func (j Job) paths() []CleanupPath {
+	paths := make([]CleanupPath, len(j.CleanupPaths))
+	for i, cp := range j.CleanupPaths {
+		if cp.MinAgeDays == nil {
+			cp.MinAgeDays = j.MinAgeDays
+		}
+		if cp.ExcludePatterns == nil {
+			cp.ExcludePatterns = j.ExcludePatterns
+		}
+		if cp.MinSize == nil {
+			cp.MinSize = j.MinSize
+		}
+		if cp.KeepRecent == nil {
+			cp.KeepRecent = j.KeepRecent
+		}
+		if cp.ContentTypes == nil {
+			cp.ContentTypes = j.ContentTypes
+		}
+		if cp.PathRegex == nil {
+			cp.PathRegex = j.PathRegex
+		}
+		paths[i] = cp
+	}
+	return paths
+}
+
+// validJobs checks that every job has a unique name and at least one path
+func (c *Config) validJobs() error {
+	seen := make(map[string]bool)
+	for i, job := range c.Jobs {
+		switch {
+		case job.Name == "":
+			return fmt.Errorf("jobs[%d] has no name", i)
+		case strings.Contains(job.Name, ","):
+			return fmt.Errorf("job name %q must not contain a comma", job.Name)
+		case seen[job.Name]:
+			return fmt.Errorf("duplicate job name %q", job.Name)
+		case len(job.CleanupPaths) == 0:
+			return fmt.Errorf("job %s has no cleanup_paths", job.Name)
+		}
+		for _, name := range job.Recipes {
+			if err := validRecipe(name); err != nil {
+				return fmt.Errorf("job %s: %w", job.Name, err)
+			}
+		}
+		seen[job.Name] = true
+	}
+	return nil
+}
+
+// JobNames returns the names of the configured jobs in config order
+func (c *Config) JobNames() []string {
+	names := make([]string, len(c.Jobs))
+	for i, job := range c.Jobs {
+		names[i] = job.Name
+	}
+	return names
+}
+
+// CleanJob runs CleanJunk over the cleanup paths of one named job instead of
+// the top-level cleanup_paths, with the job's recipes and dedupe in place of
+// the top-level ones
+func (sc *SystemCleaner) CleanJob(name string) error {
+	var job *Job
+	for i := range sc.config.Jobs {
+		if sc.config.Jobs[i].Name == name {
+			job = &sc.config.Jobs[i]
+		}
+	}
+	if job == nil {
+		return fmt.Errorf("unknown job %q (configured: %s)", name, strings.Join(sc.config.JobNames(), ", "))
+	}
+
+	saved, recipes, dedupe := sc.config.CleanupPaths, sc.config.Recipes, sc.config.Dedupe
+	sc.config.CleanupPaths, sc.config.Recipes, sc.config.Dedupe = job.paths(), job.Recipes, job.Dedupe
+	sc.ownFiles, sc.filePaths = sc.findOwnFiles(), sc.findFilePaths()
+	defer func() {
+		sc.config.CleanupPaths, sc.config.Recipes, sc.config.Dedupe = saved, recipes, dedupe
+		sc.ownFiles, sc.filePaths = sc.findOwnFiles(), sc.findFilePaths()
+	}()
+
+	sc.job = job.Name
+	defer func() { sc.job = "" }()
+
+	fmt.Fprintf(sc.msg, "\n🧰 Job %s\n", job.Name)
+	return sc.CleanJunk()
+}
+
+// CleanWithin cleans the part of the cleanup paths at or below dir, each path
+// with its own rules. dir has to be a cleanup path, hold some or be inside
+// one, so that nothing outside the configured paths is ever cleaned. For the
+// same reason recipes and deduplication do not run.
+func (sc *SystemCleaner) CleanWithin(dir string) error {
+	paths, ok := sc.config.pathsWithin(dir)
+	if !ok {
+		return &CleanError{Path: dir, Err: fmt.Errorf("not within any cleanup path")}
+	}
+
+	saved, recipes, dedupe := sc.config.CleanupPaths, sc.config.Recipes, sc.config.Dedupe
+	sc.config.CleanupPaths, sc.config.Recipes, sc.config.Dedupe = paths, nil, false
+	sc.ownFiles, sc.filePaths = sc.findOwnFiles(), sc.findFilePaths()
+	defer func() {
+		sc.config.CleanupPaths, sc.config.Recipes, sc.config.Dedupe = saved, recipes, dedupe
+		sc.ownFiles, sc.filePaths = sc.findOwnFiles(), sc.findFilePaths()
+	}()
+	return sc.CleanJunk()
+}
@@ -0,0 +1,104 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCleanWithin(t *testing.T) {
+	tests := []struct {
+		name     string
+		ignore   string // .cleanignore in the cleanup root
+		skip     string // skip_paths pattern, relative to the cleanup root
+		dir      string // cleaned, relative to the cleanup root
+		relative bool   // pass dir relative to the working directory
+		wantGone []string
+		wantKept []string
+	}{
+		{
+			name:     "subdirectory only",
+			dir:      "cache",
+			wantGone: []string{"cache/a.tmp", "cache/sub/b.tmp"},
+			wantKept: []string{"top.tmp", "keep/precious", "other/c.tmp"},
+		},
+		{
+			name:     "cleanignore in the root",
+			ignore:   "keep/\n",
+			dir:      "keep",
+			wantKept: []string{"keep/precious", "top.tmp", "cache/a.tmp"},
+		},
+		{
+			name:     "skip_paths relative to the root",
+			skip:     "cache/sub",
+			dir:      "cache",
+			wantGone: []string{"cache/a.tmp"},
+			wantKept: []string{"cache/sub/b.tmp", "top.tmp"},
+		},
+		{
+			name:     "relative dir",
+			dir:      "cache",
+			relative: true,
+			wantGone: []string{"cache/a.tmp", "cache/sub/b.tmp"},
+			wantKept: []string{"top.tmp", "other/c.tmp"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, name := range []string{"top.tmp", "keep/precious", "cache/a.tmp", "cache/sub/b.tmp", "other/c.tmp"} {
+				path := filepath.Join(root, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte("junk"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.ignore != "" {
+				if err := os.WriteFile(filepath.Join(root, ".cleanignore"), []byte(tt.ignore), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			config := cleanConfig([]string{root}, 1, 1)
+			if tt.skip != "" {
+				config += "skip_paths: [" + tt.skip + "]\n"
+			}
+			sc := newTestCleaner(t, config)
+
+			dir := filepath.Join(root, filepath.FromSlash(tt.dir))
+			if tt.relative {
+				wd, err := os.Getwd()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chdir(root); err != nil {
+					t.Fatal(err)
+				}
+				defer os.Chdir(wd)
+				dir = tt.dir
+			}
+			if err := sc.CleanWithin(dir); err != nil {
+				t.Fatalf("CleanWithin(%s): %v", dir, err)
+			}
+
+			for _, name := range tt.wantGone {
+				if _, err := os.Lstat(filepath.Join(root, filepath.FromSlash(name))); !os.IsNotExist(err) {
+					t.Errorf("%s was not deleted: %v", name, err)
+				}
+			}
+			for _, name := range tt.wantKept {
+				if _, err := os.Lstat(filepath.Join(root, filepath.FromSlash(name))); err != nil {
+					t.Errorf("%s was deleted: %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestCleanWithinOutsideCleanupPaths(t *testing.T) {
+	sc := newTestCleaner(t, cleanConfig([]string{t.TempDir()}, 1, 1))
+	if err := sc.CleanWithin(t.TempDir()); err == nil {
+		t.Error("cleaned a directory outside every cleanup path")
+	}
+}
@@ -0,0 +1,191 @@
+package cleaner
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Actions a rule of rules_file can take on the files it matches
+const (
+	ruleDelete  = "delete"
+	ruleArchive = "archive"
+	ruleReport  = "report"
+	ruleKeep    = "keep"
+)
+
+// ruleMatch is the matchers of a rule; every one that is set has to match,
+// and a rule without any matches every file
+type ruleMatch struct {
+	Glob       []string `yaml:"glob"` // relative to the cleanup path, ** for any depth
+	Ext        []string `yaml:"ext"`
+	MinAgeDays *int     `yaml:"min_age_days"`
+	MaxAgeDays *int     `yaml:"max_age_days"`
+	MinSize    string   `yaml:"min_size"` // e.g. "10MB"
+	MaxSize    string   `yaml:"max_size"`
+	MIME       []string `yaml:"mime"` // sniffed content types, e.g. image/*
+
+	exts             map[string]bool
+	minSize, maxSize int64
+}
+
+// junkRule is one named rule of rules_file
+type junkRule struct {
+	Name   string    `yaml:"name"`
+	Match  ruleMatch `yaml:"match"`
+	Action string    `yaml:"action"`
+}
+
+// rulesFile is the layout of rules_file
+type rulesFile struct {
+	Rules []junkRule `yaml:"rules"`
+}
+
+// loadJunkRules reads and checks rules_file. An empty name means no rules.
+func loadJunkRules(name string) ([]junkRule, error) {
+	if name == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("rules_file: %w", err)
+	}
+	var file rulesFile
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, fmt.Errorf("rules_file %s: %w", name, err)
+	}
+	if len(file.Rules) == 0 {
+		return nil, fmt.Errorf("rules_file %s has no rules", name)
+	}
+	seen := make(map[string]bool)
+	for i := range file.Rules {
+		rule := &file.Rules[i]
+		if rule.Name == "" {
+			return nil, fmt.Errorf("rules_file %s: rules[%d] has no name", name, i)
+		}
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("rules_file %s: duplicate rule name %q", name, rule.Name)
+		}
+		seen[rule.Name] = true
+		if err := rule.compile(); err != nil {
+			return nil, fmt.Errorf("rules_file %s: rule %s: %w", name, rule.Name, err)
+		}
+	}
+	return file.Rules, nil
+}
+
+// compile checks a rule and prepares its matchers
+func (r *junkRule) compile() error {
+	switch r.Action {
+	case ruleDelete, ruleArchive, ruleReport, ruleKeep:
+	default:
+		return fmt.Errorf("invalid action %q (want delete, archive, report or keep)", r.Action)
+	}
+	m := &r.Match
+	for _, pattern := range m.Glob {
+		if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil {
+			return fmt.Errorf("invalid glob %q", pattern)
+		}
+	}
+	if len(m.Ext) > 0 {
+		m.exts = make(map[string]bool)
+		for _, ext := range m.Ext {
+			m.exts[normalizeExt(ext)] = true
+		}
+	}
+	for _, pattern := range m.MIME {
+		if _, err := path.Match(pattern, ""); err != nil || !strings.Contains(pattern, "/") {
+			return fmt.Errorf("invalid mime pattern %q (want e.g. application/zip or image/*)", pattern)
+		}
+	}
+	if (m.MinAgeDays != nil && *m.MinAgeDays < 0) || (m.MaxAgeDays != nil && *m.MaxAgeDays < 0) {
+		return fmt.Errorf("min_age_days and max_age_days must not be negative")
+	}
+	var err error
+	if m.MinSize != "" {
+		if m.minSize, err = parseSize(m.MinSize); err != nil {
+			return fmt.Errorf("min_size: %w", err)
+		}
+	}
+	m.maxSize = -1
+	if m.MaxSize != "" {
+		if m.maxSize, err = parseSize(m.MaxSize); err != nil {
+			return fmt.Errorf("max_size: %w", err)
+		}
+	}
+	return nil
+}
+
+// matches reports whether a file found under root matches the rule. The
+// content type is only sniffed once every cheaper matcher has passed.
+func (m *ruleMatch) matches(root, p string, info fs.FileInfo, now time.Time) (bool, error) {
+	if len(m.Glob) > 0 {
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return false, nil
+		}
+		rel = filepath.ToSlash(rel)
+		found := false
+		for _, pattern := range m.Glob {
+			if matchPathGlob(pattern, rel) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	if m.exts != nil && !m.exts[strings.ToLower(filepath.Ext(p))] {
+		return false, nil
+	}
+	age := now.Sub(info.ModTime())
+	if m.MinAgeDays != nil && age < time.Duration(*m.MinAgeDays)*24*time.Hour {
+		return false, nil
+	}
+	if m.MaxAgeDays != nil && age > time.Duration(*m.MaxAgeDays)*24*time.Hour {
+		return false, nil
+	}
+	if info.Size() < m.minSize || (m.maxSize >= 0 && info.Size() > m.maxSize) {
+		return false, nil
+	}
+	if len(m.MIME) > 0 {
+		mimeType, err := sniffType(p)
+		if err != nil {
+			return false, err
+		}
+		return matchesType(mimeType, m.MIME), nil
+	}
+	return true, nil
+}
+
+// ruleFor returns the first rule of rules_file matching a file, or nil
+func (c *Config) ruleFor(root, p string, info fs.FileInfo, now time.Time) (*junkRule, error) {
+	for i := range c.junkRules {
+		rule := &c.junkRules[i]
+		ok, err := rule.Match.matches(root, p, info, now)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return rule, nil
+		}
+	}
+	return nil, nil
+}
+
+// archivesByRule reports whether any rule of rules_file archives its files
+func (c *Config) archivesByRule() bool {
+	for _, rule := range c.junkRules {
+		if rule.Action == ruleArchive {
+			return true
+		}
+	}
+	return false
+}
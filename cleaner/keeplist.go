@@ -0,0 +1,102 @@
+package cleaner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// keepList is the set of resolved absolute paths that are never deleted
+type keepList map[string]bool
+
+// resolvePath makes path absolute and resolves its symlinks; a path that does
+// not exist is only made absolute and cleaned
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		return resolved, nil
+	}
+	return abs, nil
+}
+
+// loadKeepList reads keep_list: one absolute path per line, with blank lines
+// and lines starting with # ignored. An empty name means no keep list.
+func loadKeepList(name string) (keepList, error) {
+	if name == "" {
+		return nil, nil
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	keep := make(keepList)
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			return nil, fmt.Errorf("%s:%d: %q is not an absolute path", name, n, line)
+		}
+		resolved, err := resolvePath(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, n, err)
+		}
+		keep[resolved] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return keep, nil
+}
+
+// loadKeep loads keep_list before a clean deletes anything. Every mode that
+// deletes files calls it: cleaning without the list could delete the files
+// it protects.
+func (sc *SystemCleaner) loadKeep() error {
+	keep, err := loadKeepList(sc.config.KeepList)
+	if err != nil {
+		sc.logger.Printf("Not cleaning: cannot read keep_list %s: %v", sc.config.KeepList, err)
+		return &CleanError{Path: sc.config.KeepList, Err: err}
+	}
+	sc.keep = keep
+	return nil
+}
+
+// keepChecker returns a function reporting whether a file found by a walk of
+// root is on the keep list. The walk does not follow symlinks below root, so
+// resolving root once resolves every path under it.
+func (k keepList) keepChecker(root string) func(path string) bool {
+	if len(k) == 0 {
+		return func(string) bool { return false }
+	}
+	resolvedRoot, err := resolvePath(root)
+	if err != nil {
+		resolvedRoot = root
+	}
+	return func(path string) bool {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return false
+		}
+		return k[filepath.Join(resolvedRoot, rel)]
+	}
+}
+
+// protected reports whether a file is on the keep list, logging it when it is
+func (sc *SystemCleaner) protected(kept func(string) bool, path string) bool {
+	if !kept(path) {
+		return false
+	}
+	sc.logger.Printf("Protected %s: listed in keep_list", path)
+	sc.emit(Event{Type: EventSkipped, Path: path, Reason: "keep_list"})
+	return true
+}
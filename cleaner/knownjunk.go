@@ -0,0 +1,314 @@
+package cleaner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// junkSignature recognizes one kind of file that is disposable wherever it
+// is. name matches the base name; when magic is set the file has to start
+// with it as well, so a name alone is never trusted for it.
+type junkSignature struct {
+	kind        string
+	description string
+	name        *regexp.Regexp
+	magic       func(head []byte) bool
+}
+
+// knownJunkSignatures are the files -clean-known-junk looks for. Add an entry
+// to recognize another kind.
+var knownJunkSignatures = []junkSignature{
+	{kind: "core-dump", description: "Core dumps", name: regexp.MustCompile(`^(core|core\.\d+|.+\.core)$`), magic: isELFCore},
+	{kind: "crash-report", description: "Crash reports", name: regexp.MustCompile(`(?i)\.(crash|ips|dmp|mdmp)$`)},
+	{kind: "ds-store", description: "macOS .DS_Store files", name: regexp.MustCompile(`^\.DS_Store$`)},
+	{kind: "thumbs-db", description: "Windows Thumbs.db thumbnail caches", name: regexp.MustCompile(`(?i)^thumbs\.db$`)},
+	{kind: "editor-backup", description: "Editor swap and backup files", name: regexp.MustCompile(`^(.+~|\..+\.sw[o-p]|#.+#)$`)},
+}
+
+// magicLen is how many bytes of a file are read for magic checks
+const magicLen = 18
+
+// isELFCore reports whether a file starts like an ELF core file: the ELF
+// magic, then e_type ET_CORE (4) at offset 16 in either byte order
+func isELFCore(head []byte) bool {
+	if len(head) < magicLen || !bytes.HasPrefix(head, []byte("\x7fELF")) {
+		return false
+	}
+	if head[5] == 2 { // big endian
+		return head[16] == 0 && head[17] == 4
+	}
+	return head[16] == 4 && head[17] == 0
+}
+
+// readHead returns up to magicLen bytes from the start of a file
+func readHead(path string) ([]byte, error) {
+	file, err := os.Open(longPath(path))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	head := make([]byte, magicLen)
+	n, err := io.ReadFull(file, head)
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		err = nil
+	}
+	return head[:n], err
+}
+
+// matchSignature returns the signature a regular file matches, or nil
+func matchSignature(path string) (*junkSignature, error) {
+	base := filepath.Base(path)
+	for i := range knownJunkSignatures {
+		sig := &knownJunkSignatures[i]
+		if !sig.name.MatchString(base) {
+			continue
+		}
+		if sig.magic == nil {
+			return sig, nil
+		}
+		head, err := readHead(path)
+		if err != nil {
+			return nil, err
+		}
+		if sig.magic(head) {
+			return sig, nil
+		}
+	}
+	return nil, nil
+}
+
+// KnownJunkFile is a file matching a known-junk signature
+type KnownJunkFile struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	Size int64  `json:"size"`
+	root string // directory it was found in
+	info fs.FileInfo
+}
+
+// KnownJunkCount sums up the files of one signature
+type KnownJunkCount struct {
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+	Files       int    `json:"files"`
+	Size        int64  `json:"size"`
+}
+
+// KnownJunkReport lists the known junk found under the cleanup paths
+type KnownJunkReport struct {
+	Roots  []string         `json:"roots"`
+	Files  []KnownJunkFile  `json:"files"`
+	Counts []KnownJunkCount `json:"counts"` // in the order of the signatures, only those found
+	Total  int64            `json:"total"`
+}
+
+// knownJunkRoots returns the cleanup paths, or the home directory when there
+// are none
+func (sc *SystemCleaner) knownJunkRoots() []CleanupPath {
+	if len(sc.config.CleanupPaths) > 0 {
+		return sc.config.CleanupPaths
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []CleanupPath{{Path: home}}
+}
+
+// findKnownJunk walks the roots for files matching knownJunkSignatures. Junk
+// rules do not apply, but exclude patterns, skip_paths, .cleanignore,
+// keep_list and the tool's own files do.
+func (sc *SystemCleaner) findKnownJunk() (*KnownJunkReport, error) {
+	keep, err := loadKeepList(sc.config.KeepList)
+	if err != nil {
+		return nil, &ScanError{Path: sc.config.KeepList, Err: err}
+	}
+	report := &KnownJunkReport{}
+	counts := make(map[string]*KnownJunkCount)
+	seen := make(map[string]bool) // nested cleanup paths reach files twice
+	for _, cp := range sc.knownJunkRoots() {
+		root := cp.Path
+		report.Roots = append(report.Roots, root)
+		if sc.skipFilePath(root) {
+			continue
+		}
+		rules := sc.config.rulesFor(cp)
+		own := sc.ownFiles[root]
+		kept := keep.keepChecker(root)
+		err := sc.walkContext(context.Background(), root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == root {
+					return err
+				}
+				sc.logger.Printf("Error accessing path %s: %v", path, err)
+				sc.emit(Event{Type: EventError, Path: path, Reason: "cannot read", Err: err})
+				return nil
+			}
+			if own[path] || (path != root && rules.excluded(path)) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || seen[path] {
+				return nil
+			}
+			seen[path] = true
+			sig, err := matchSignature(path)
+			if err != nil {
+				sc.logger.Printf("Error reading file %s: %v", path, err)
+				sc.emit(Event{Type: EventError, Path: path, Reason: "cannot read", Err: err})
+				return nil
+			}
+			if sig == nil || sc.protected(kept, path) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				sc.logger.Printf("Error accessing path %s: %v", path, err)
+				sc.emit(Event{Type: EventError, Path: path, Reason: "cannot read file info", Err: err})
+				return nil
+			}
+			size := sc.fileSize(info)
+			report.Files = append(report.Files, KnownJunkFile{Path: path, Kind: sig.kind, Size: size, root: root, info: info})
+			report.Total += size
+			c := counts[sig.kind]
+			if c == nil {
+				c = &KnownJunkCount{Kind: sig.kind, Description: sig.description}
+				counts[sig.kind] = c
+			}
+			c.Files++
+			c.Size += size
+			return nil
+		})
+		if err != nil {
+			return nil, &ScanError{Path: root, Err: err}
+		}
+	}
+	for _, sig := range knownJunkSignatures {
+		if c := counts[sig.kind]; c != nil {
+			report.Counts = append(report.Counts, *c)
+		}
+	}
+	sort.Slice(report.Files, func(i, j int) bool { return report.Files[i].Path < report.Files[j].Path })
+	return report, nil
+}
+
+// ScanKnownJunk reports the core dumps, crash reports, .DS_Store and
+// Thumbs.db files and editor leftovers under the cleanup paths, or the home
+// directory when there are none, with a count per kind. Nothing is changed;
+// pass the report to CleanKnownJunk to delete the files.
+func (sc *SystemCleaner) ScanKnownJunk() (*KnownJunkReport, error) {
+	var stop chan bool
+	if !sc.jsonOutput {
+		var roots []string
+		for _, cp := range sc.knownJunkRoots() {
+			roots = append(roots, cp.Path)
+		}
+		fmt.Fprintf(sc.msg, "\n🔎 Looking for known junk in %s...\n", strings.Join(roots, ", "))
+		stop = sc.startLoading("Matching signatures...")
+	}
+	report, err := sc.findKnownJunk()
+	if stop != nil {
+		stop <- true
+		<-stop
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if sc.jsonOutput {
+		encoder := json.NewEncoder(sc.out)
+		encoder.SetIndent("", "  ")
+		return report, encoder.Encode(report)
+	}
+
+	if len(report.Files) == 0 {
+		fmt.Fprintln(sc.out, "\n✨ No known junk found")
+		return report, nil
+	}
+	fmt.Fprintf(sc.out, "\n🔎 %d known junk files, %s:\n", len(report.Files), sc.FormatSize(report.Total))
+	for _, c := range report.Counts {
+		fmt.Fprintf(sc.out, "   %s: %d files, %s\n", c.Description, c.Files, sc.FormatSize(c.Size))
+	}
+	for _, f := range report.Files {
+		fmt.Fprintf(sc.out, "📄 %s (%s, %s)\n", f.Path, f.Kind, sc.FormatSize(f.Size))
+	}
+	return report, nil
+}
+
+// CleanKnownJunk deletes the files of a ScanKnownJunk report, or lists them
+// in a dry run. max_delete_files, verify_deletion and the audit log apply as
+// for a junk clean.
+func (sc *SystemCleaner) CleanKnownJunk(report *KnownJunkReport) error {
+	if len(report.Files) == 0 {
+		return nil
+	}
+	if err := sc.checkDeleteCount(len(report.Files)); err != nil {
+		return err
+	}
+	audit, err := sc.openAudit()
+	if err != nil {
+		return err
+	}
+	defer sc.closeAudit(audit)
+
+	sc.progress.start(report.Roots)
+	defer sc.progress.finish()
+	defer sc.startBudget()()
+
+	remover := newFileRemover()
+	defer remover.close()
+	deleted := make(map[string]int)
+	var files int
+	var reclaimed int64
+	for _, f := range report.Files {
+		select {
+		case <-sc.stopChan:
+			return &CleanError{Path: f.Path, Err: ErrInterrupted}
+		case <-sc.budgetSpent():
+			sc.logger.Printf("Known junk clean stopped: %v", ErrBudget)
+			return nil
+		default:
+		}
+
+		if sc.dryRun {
+			sc.emit(Event{Type: EventSkipped, Path: f.Path, Size: f.Size, Reason: "dry run"})
+			sc.scriptDelete(f.Path, f.Size, "")
+			fmt.Fprintf(sc.out, "🧪 Would delete %s (%s, %s)\n", f.Path, f.Kind, sc.FormatSize(f.Size))
+		} else {
+			if err := sc.removeFile(remover, f.Path); err != nil {
+				sc.logger.Printf("Error removing file %s: %v", f.Path, err)
+				sc.emit(Event{Type: EventError, Path: f.Path, Size: f.Size, Reason: "delete failed", Err: err})
+				continue
+			}
+			sc.logger.Printf("Deleted %s: %s", f.Kind, f.Path)
+			sc.emit(Event{Type: EventDeleted, Path: f.Path, Size: f.Size})
+			sc.audit(audit, f.Path, f.Size)
+		}
+		sc.progress.deletedFrom(f.root, f.Size)
+		deleted[f.Kind]++
+		files++
+		reclaimed += f.Size
+	}
+
+	for _, c := range report.Counts {
+		sc.logger.Printf("Known junk %s: %d of %d files deleted", c.Kind, deleted[c.Kind], c.Files)
+	}
+	if sc.dryRun {
+		fmt.Fprintf(sc.out, "🧪 Would free %s by deleting %d known junk files\n", sc.FormatSize(reclaimed), files)
+	} else {
+		fmt.Fprintf(sc.out, "🗑️  Freed %s by deleting %d known junk files\n", sc.FormatSize(reclaimed), files)
+		sc.reportNotDeleted()
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package cleaner
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// LinkInfo represents a symbolic link found during a scan
+type LinkInfo struct {
+	Path   string
+	Target string
+	Broken bool
+}
+
+// ScanLinks finds and reports symbolic links in a directory, separating
+// broken links (whose target is missing) from valid ones
+func (sc *SystemCleaner) ScanLinks(directory string) error {
+	fmt.Fprintln(sc.msg, "\n🔗 Scanning for symbolic links in:", directory)
+
+	stop := sc.startLoading("Checking links...")
+
+	var links []LinkInfo
+	err := sc.walk(directory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			sc.logger.Printf("Error accessing path %s: %v", path, err)
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		target, err := os.Readlink(path)
+		if err != nil {
+			sc.logger.Printf("Error reading link %s: %v", path, err)
+			return nil
+		}
+		_, statErr := os.Stat(path)
+		links = append(links, LinkInfo{Path: path, Target: target, Broken: os.IsNotExist(statErr)})
+		return nil
+	})
+
+	stop <- true
+	<-stop
+
+	if err != nil {
+		return &ScanError{Path: directory, Err: err}
+	}
+
+	var broken int
+	for _, link := range links {
+		if link.Broken {
+			broken++
+			fmt.Fprintf(sc.out, "💔 %s → %s (broken)\n", link.Path, link.Target)
+		} else {
+			fmt.Fprintf(sc.out, "🔗 %s → %s\n", link.Path, link.Target)
+		}
+	}
+
+	fmt.Fprintf(sc.out, "\n📊 %d links found, %d broken\n", len(links), broken)
+	return nil
+}
@@ -0,0 +1,72 @@
+package cleaner
+
+import (
+	"bufio"
+	"os"
+	"sync"
+	"time"
+)
+
+// logFlushInterval is how often buffered log lines are written to disk
+const logFlushInterval = time.Second
+
+// bufferedLog buffers writes to the log file so per-file error logging does
+// not block the delete loop; it is flushed periodically and on Close
+type bufferedLog struct {
+	mu   sync.Mutex
+	file *os.File
+	buf  *bufio.Writer
+	done chan struct{}
+	once sync.Once
+}
+
+// newBufferedLog wraps file and starts the periodic flusher
+func newBufferedLog(file *os.File) *bufferedLog {
+	l := &bufferedLog{
+		file: file,
+		buf:  bufio.NewWriterSize(file, 64*1024),
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		ticker := time.NewTicker(logFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.done:
+				return
+			case <-ticker.C:
+				l.Flush()
+			}
+		}
+	}()
+
+	return l
+}
+
+func (l *bufferedLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+// Flush writes any buffered log lines to the file
+func (l *bufferedLog) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Flush()
+}
+
+// Close stops the flusher, flushes and closes the file
+func (l *bufferedLog) Close() error {
+	var err error
+	l.once.Do(func() {
+		close(l.done)
+		if err = l.Flush(); err != nil {
+			l.file.Close()
+			return
+		}
+		err = l.file.Close()
+	})
+	return err
+}
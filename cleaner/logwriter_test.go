@@ -0,0 +1,165 @@
+package cleaner
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// openTestLog creates a log file in a temporary directory
+func openTestLog(tb testing.TB) (*os.File, string) {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "cleaner.log")
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return file, path
+}
+
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBufferedLogConcurrentWrites(t *testing.T) {
+	tests := []struct {
+		name             string
+		writers, perEach int
+	}{
+		{"one writer", 1, 100},
+		{"many writers", 16, 500},
+		{"more than the buffer", 8, 5000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, path := openTestLog(t)
+			l := newBufferedLog(file)
+			logger := log.New(l, "", 0)
+
+			var wg sync.WaitGroup
+			for w := 0; w < tt.writers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < tt.perEach; i++ {
+						logger.Printf("writer %d line %d", w, i)
+					}
+				}(w)
+			}
+			wg.Wait()
+			if err := l.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Close flushed every line, and no two lines were interleaved
+			seen := make(map[string]bool)
+			for _, line := range strings.Split(strings.TrimSuffix(readLog(t, path), "\n"), "\n") {
+				var w, i int
+				if _, err := fmt.Sscanf(line, "writer %d line %d", &w, &i); err != nil {
+					t.Fatalf("mangled line %q", line)
+				}
+				seen[line] = true
+			}
+			if len(seen) != tt.writers*tt.perEach {
+				t.Errorf("log has %d distinct lines, want %d", len(seen), tt.writers*tt.perEach)
+			}
+		})
+	}
+}
+
+func TestBufferedLogFlushes(t *testing.T) {
+	tests := []struct {
+		name  string
+		flush func(l *bufferedLog) error
+	}{
+		{"explicitly", (*bufferedLog).Flush},
+		{"on close", (*bufferedLog).Close},
+		{"periodically", func(l *bufferedLog) error {
+			// wait for the ticker, allowing a busy machine a few intervals
+			for deadline := time.Now().Add(5 * logFlushInterval); time.Now().Before(deadline); {
+				if info, err := os.Stat(l.file.Name()); err == nil && info.Size() > 0 {
+					break
+				}
+				time.Sleep(logFlushInterval / 10)
+			}
+			return nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, path := openTestLog(t)
+			l := newBufferedLog(file)
+			defer l.Close()
+
+			fmt.Fprintln(l, "first line")
+			if got := readLog(t, path); got != "" {
+				t.Fatalf("line written before a flush: %q", got)
+			}
+			if err := tt.flush(l); err != nil {
+				t.Fatal(err)
+			}
+			if got := readLog(t, path); got != "first line\n" {
+				t.Errorf("log holds %q, want the flushed line", got)
+			}
+		})
+	}
+}
+
+func TestBufferedLogCloseTwice(t *testing.T) {
+	file, path := openTestLog(t)
+	l := newBufferedLog(file)
+	fmt.Fprintln(l, "last line")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// the interrupt handler and the deferred Close may both close the log
+	if err := l.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if got := readLog(t, path); got != "last line\n" {
+		t.Errorf("log holds %q, want the last line once", got)
+	}
+}
+
+// BenchmarkLog compares logging a permission error per file to the bare log
+// file with logging through bufferedLog, from several deletion workers
+func BenchmarkLog(b *testing.B) {
+	tests := []struct {
+		name     string
+		buffered bool
+	}{
+		{"file", false},
+		{"buffered", true},
+	}
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			file, _ := openTestLog(b)
+			var logger *log.Logger
+			if tt.buffered {
+				l := newBufferedLog(file)
+				defer l.Close()
+				logger = log.New(l, "", log.LstdFlags)
+			} else {
+				defer file.Close()
+				logger = log.New(file, "", log.LstdFlags)
+			}
+			err := &os.PathError{Op: "unlinkat", Path: "/var/cache/app/entry", Err: os.ErrPermission}
+			b.SetParallelism(4)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					logger.Printf("Error removing file %s: %v", err.Path, err)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package cleaner
+
+// longPath returns path unchanged; only Windows has a MAX_PATH limit
+func longPath(path string) string {
+	return path
+}
+
+// walkRoot returns root unchanged; only Windows needs it extended
+func walkRoot(root string) string {
+	return root
+}
@@ -0,0 +1,48 @@
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCleanDeepTree cleans files whose paths are well over MAX_PATH, which
+// fail with the plain Windows APIs
+func TestCleanDeepTree(t *testing.T) {
+	tests := []struct {
+		name  string
+		depth int
+	}{
+		{"just over MAX_PATH", 260/len("deep_directory_name") + 1},
+		{"far over MAX_PATH", 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			dir := filepath.Join(root, strings.Repeat("deep_directory_name"+string(filepath.Separator), tt.depth))
+			if err := os.MkdirAll(longPath(dir), 0755); err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(dir, "junk.tmp")
+			if len(path) <= 260 {
+				t.Fatalf("test path is only %d characters long", len(path))
+			}
+			if err := os.WriteFile(longPath(path), []byte("junk"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			sc := newTestCleaner(t, cleanConfig([]string{root}, 1, 1))
+			deleted, _ := recordEvents(sc, EventDeleted)
+			if err := sc.CleanJunk(); err != nil {
+				t.Fatalf("CleanJunk: %v", err)
+			}
+			if deleted[path] != 1 || sc.failures.count() != 0 {
+				t.Errorf("deleted %v with %d errors, want %s deleted once", deleted, sc.failures.count(), path)
+			}
+			if _, err := os.Lstat(longPath(path)); !os.IsNotExist(err) {
+				t.Errorf("%s still exists: %v", path, err)
+			}
+		})
+	}
+}
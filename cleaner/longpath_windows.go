@@ -0,0 +1,43 @@
+package cleaner
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// maxPath is MAX_PATH, the longest path Windows accepts without the
+// extended-length \\?\ prefix
+const maxPath = 260
+
+// extendedPath turns path into its extended-length \\?\ form, which lifts the
+// MAX_PATH limit on volumes that support long names (NTFS, ReFS, most SMB
+// shares). Such paths are not normalized by Windows, so path is made absolute
+// and cleaned first.
+func extendedPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
+
+// longPath returns path in extended-length form when it is too long for the
+// plain Windows APIs
+func longPath(path string) string {
+	if len(path) < maxPath {
+		return path
+	}
+	return extendedPath(path)
+}
+
+// walkRoot is the root filepath.WalkDir is given for root. It is always
+// extended, so that every path below it keeps working however deep it goes.
+func walkRoot(root string) string {
+	return extendedPath(root)
+}
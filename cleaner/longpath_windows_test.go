@@ -0,0 +1,48 @@
+package cleaner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtendedPath(t *testing.T) {
+	cwd, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, path, want string
+	}{
+		{"drive path", `C:\Users\me\AppData`, `\\?\C:\Users\me\AppData`},
+		{"cleaned first", `C:\Users\me\..\me\.\AppData\`, `\\?\C:\Users\me\AppData`},
+		{"forward slashes", `C:/Users/me/AppData`, `\\?\C:\Users\me\AppData`},
+		{"UNC share", `\\server\share\cache`, `\\?\UNC\server\share\cache`},
+		{"already extended", `\\?\C:\Users\me`, `\\?\C:\Users\me`},
+		{"relative", `cache\dir`, `\\?\` + filepath.Join(cwd, `cache\dir`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extendedPath(tt.path); got != tt.want {
+				t.Errorf("extendedPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLongPath(t *testing.T) {
+	long := `C:\` + strings.Repeat(`deep\`, maxPath/len(`deep\`)+1) + "junk.tmp"
+	tests := []struct {
+		name, path, want string
+	}{
+		{"short path unchanged", `C:\Temp\junk.tmp`, `C:\Temp\junk.tmp`},
+		{"long path extended", long, `\\?\` + long},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := longPath(tt.path); got != tt.want {
+				t.Errorf("longPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
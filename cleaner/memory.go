@@ -0,0 +1,124 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/shirou/gopsutil/mem"
+	"golang.org/x/term"
+)
+
+// errSudoPassword is returned by dropCaches when sudo -n exits because it
+// would have to ask for a password
+var errSudoPassword = errors.New("sudo cannot ask for a password outside a terminal")
+
+// reportMemory prints the current memory pressure and returns an estimate of
+// how much an optimization could reclaim. Linux reports the page cache and
+// buffers; macOS reports neither, so its inactive memory is used instead.
+func (sc *SystemCleaner) reportMemory() (uint64, error) {
+	v, err := mem.VirtualMemory()
+	if err != nil {
+		return 0, err
+	}
+
+	reclaimable := v.Cached + v.Buffers
+	if reclaimable == 0 {
+		reclaimable = v.Inactive
+	}
+
+	fmt.Fprintf(sc.out, "🏋️ RAM: %s used of %s (%.2f%%), %s available\n",
+		sc.FormatSize(int64(v.Used)), sc.FormatSize(int64(v.Total)), v.UsedPercent, sc.FormatSize(int64(v.Available)))
+	fmt.Fprintf(sc.out, "🗄️  Cached: %s  Buffers: %s  Inactive: %s\n",
+		sc.FormatSize(int64(v.Cached)), sc.FormatSize(int64(v.Buffers)), sc.FormatSize(int64(v.Inactive)))
+	fmt.Fprintf(sc.out, "♻️  Estimated reclaimable: %s\n", sc.FormatSize(int64(reclaimable)))
+	return reclaimable, nil
+}
+
+// OptimizeMemory performs memory optimization based on the OS:
+//
+//   - darwin: runs purge to flush the disk cache
+//   - linux: drops the page cache, dentries and inodes via vm.drop_caches
+//   - anything else (FreeBSD, OpenBSD, NetBSD, ...): these kernels expose no
+//     interface to drop caches, so only this process returns its unused
+//     memory to the OS and the user is told system-level reclaim isn't supported
+//
+// Current memory pressure is reported first. Nothing is done in dry-run mode
+// or when there is nothing worth reclaiming. When stdin is not a terminal sudo
+// runs with -n, and if it would need a password the optimization is skipped
+// with a warning instead of hanging.
+func (sc *SystemCleaner) OptimizeMemory() error {
+	fmt.Fprintln(sc.msg, "\n🚀 Optimizing Memory...")
+
+	reclaimable, err := sc.reportMemory()
+	if err != nil {
+		sc.logger.Printf("Error getting memory info: %v", err)
+	}
+	if sc.dryRun {
+		fmt.Fprintln(sc.out, "🧪 Dry run: memory was not optimized")
+		return nil
+	}
+	if err == nil && reclaimable == 0 {
+		fmt.Fprintln(sc.out, "✅ Nothing worth reclaiming, skipping optimization")
+		return nil
+	}
+
+	nonInteractive := !term.IsTerminal(int(os.Stdin.Fd()))
+	if nonInteractive {
+		sc.logger.Printf("Not running on a terminal, memory optimization uses sudo -n")
+	}
+	supported, err := dropCaches(nonInteractive)
+	if errors.Is(err, errSudoPassword) {
+		sc.logger.Printf("Skipping memory optimization: %v", err)
+		fmt.Fprintf(sc.msg, "⚠️  Skipping memory optimization: %v\n", err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !supported {
+		fmt.Fprintf(sc.out, "⚠️  System-level memory reclaim isn't supported on %s; released this process's unused memory only\n", runtime.GOOS)
+		return nil
+	}
+
+	fmt.Fprintln(sc.out, "✅ Memory optimization complete!")
+	return nil
+}
+
+// dropCaches asks the OS to release its caches. Where that isn't supported
+// only this process's unused memory is released and supported is false.
+// With nonInteractive set sudo fails instead of asking for a password, and
+// that failure, told apart by sudo's message, wraps errSudoPassword.
+func dropCaches(nonInteractive bool) (supported bool, err error) {
+	var args []string
+	switch runtime.GOOS {
+	case "darwin":
+		args = []string{"purge"}
+	case "linux":
+		args = []string{"sysctl", "-w", "vm.drop_caches=3"}
+	default:
+		debug.FreeOSMemory()
+		return false, nil
+	}
+	if nonInteractive {
+		args = append([]string{"-n"}, args...)
+	}
+
+	out, err := exec.Command("sudo", args...).CombinedOutput()
+	if err == nil {
+		return true, nil
+	}
+	msg := strings.TrimSpace(string(out))
+	var exit *exec.ExitError
+	if nonInteractive && errors.As(err, &exit) && strings.Contains(msg, "password is required") {
+		return true, fmt.Errorf("%w: %s", errSudoPassword, msg)
+	}
+	if msg != "" {
+		return true, fmt.Errorf("memory optimization failed: %w: %s", err, msg)
+	}
+	return true, fmt.Errorf("memory optimization failed: %w", err)
+}
@@ -0,0 +1,218 @@
+package cleaner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metrics configures pushing the results of every clean to a StatsD server
+// or an OpenTelemetry collector
+type Metrics struct {
+	Protocol string        `yaml:"protocol" json:"protocol"`         // "statsd" or "otlp"
+	Endpoint string        `yaml:"endpoint" json:"endpoint"`         // host:port for statsd, the OTLP/HTTP metrics URL for otlp
+	Prefix   string        `yaml:"prefix" json:"prefix"`             // metric name prefix, default "cleanpc"
+	Timeout  time.Duration `yaml:"timeout" json:"timeout,omitempty"` // default 2s
+}
+
+// validMetrics checks the metrics section and fills in its defaults
+func (c *Config) validMetrics() error {
+	m := &c.Metrics
+	switch m.Protocol {
+	case "":
+		if m.Endpoint != "" {
+			return fmt.Errorf("metrics.endpoint needs metrics.protocol (statsd or otlp)")
+		}
+		return nil
+	case "statsd":
+		if _, _, err := net.SplitHostPort(m.Endpoint); err != nil {
+			return fmt.Errorf("metrics.endpoint %q must be host:port for statsd", m.Endpoint)
+		}
+	case "otlp":
+		if !strings.HasPrefix(m.Endpoint, "http://") && !strings.HasPrefix(m.Endpoint, "https://") {
+			return fmt.Errorf("metrics.endpoint %q must be an http(s) URL for otlp", m.Endpoint)
+		}
+	default:
+		return fmt.Errorf("invalid metrics.protocol %q (want statsd or otlp)", m.Protocol)
+	}
+	if m.Timeout < 0 {
+		return fmt.Errorf("metrics.timeout must not be negative")
+	}
+	if m.Timeout == 0 {
+		m.Timeout = 2 * time.Second
+	}
+	if m.Prefix == "" {
+		m.Prefix = "cleanpc"
+	}
+	return nil
+}
+
+// runMetrics are the numbers pushed for one clean
+type runMetrics struct {
+	started      time.Time
+	duration     time.Duration
+	filesDeleted int
+	bytesFreed   int64
+	errors       int
+	tags         [][2]string // name and value
+}
+
+// pushMetrics sends the numbers of a finished clean to the configured
+// endpoint. It is best-effort: it gives up after metrics.timeout and a
+// failure is only logged. Dry runs are not pushed.
+func (sc *SystemCleaner) pushMetrics(started time.Time, failures int) {
+	if sc.config.Metrics.Protocol == "" || sc.dryRun {
+		return
+	}
+	host, _ := os.Hostname()
+	job := sc.job
+	if job == "" {
+		job = "default"
+	}
+	p := sc.Progress()
+	m := runMetrics{
+		started:      started,
+		duration:     time.Since(started),
+		filesDeleted: p.FilesDeleted,
+		bytesFreed:   p.BytesFreed,
+		errors:       failures,
+		tags:         [][2]string{{"host", host}, {"job", job}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sc.config.Metrics.Timeout)
+	defer cancel()
+	var err error
+	if sc.config.Metrics.Protocol == "statsd" {
+		err = sendStatsD(ctx, sc.config.Metrics, m)
+	} else {
+		err = sendOTLP(ctx, sc.config.Metrics, m)
+	}
+	if err != nil {
+		sc.logger.Printf("Error pushing metrics to %s: %v", sc.config.Metrics.Endpoint, err)
+		return
+	}
+	sc.logger.Printf("Pushed metrics to %s over %s", sc.config.Metrics.Endpoint, sc.config.Metrics.Protocol)
+}
+
+// sendStatsD sends the metrics in one UDP packet, with DogStatsD-style tags
+func sendStatsD(ctx context.Context, cfg Metrics, m runMetrics) error {
+	tags := make([]string, len(m.tags))
+	for i, t := range m.tags {
+		tags[i] = t[0] + ":" + strings.NewReplacer(",", "_", "|", "_", ":", "_").Replace(t[1])
+	}
+	suffix := "|#" + strings.Join(tags, ",")
+	lines := []string{
+		fmt.Sprintf("%s.files_deleted:%d|c%s", cfg.Prefix, m.filesDeleted, suffix),
+		fmt.Sprintf("%s.bytes_freed:%d|c%s", cfg.Prefix, m.bytesFreed, suffix),
+		fmt.Sprintf("%s.errors:%d|c%s", cfg.Prefix, m.errors, suffix),
+		fmt.Sprintf("%s.duration:%d|ms%s", cfg.Prefix, m.duration.Milliseconds(), suffix),
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", cfg.Endpoint)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetWriteDeadline(deadline)
+	}
+	_, err = conn.Write([]byte(strings.Join(lines, "\n")))
+	return err
+}
+
+// OTLP/HTTP JSON encoding of the metrics, see opentelemetry-proto. Integers
+// are strings, as protobuf JSON requires for 64-bit values.
+type (
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpAttr struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpPoint struct {
+		Attributes        []otlpAttr `json:"attributes,omitempty"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano,omitempty"`
+		TimeUnixNano      string     `json:"timeUnixNano"`
+		AsInt             string     `json:"asInt,omitempty"`
+		AsDouble          *float64   `json:"asDouble,omitempty"`
+	}
+	otlpSum struct {
+		AggregationTemporality int         `json:"aggregationTemporality"` // 1 = delta
+		IsMonotonic            bool        `json:"isMonotonic"`
+		DataPoints             []otlpPoint `json:"dataPoints"`
+	}
+	otlpGauge struct {
+		DataPoints []otlpPoint `json:"dataPoints"`
+	}
+	otlpMetric struct {
+		Name  string     `json:"name"`
+		Unit  string     `json:"unit,omitempty"`
+		Sum   *otlpSum   `json:"sum,omitempty"`
+		Gauge *otlpGauge `json:"gauge,omitempty"`
+	}
+)
+
+// sendOTLP posts the metrics to an OTLP/HTTP endpoint as JSON
+func sendOTLP(ctx context.Context, cfg Metrics, m runMetrics) error {
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+	start := strconv.FormatInt(m.started.UnixNano(), 10)
+	var attrs []otlpAttr
+	for _, t := range m.tags {
+		attrs = append(attrs, otlpAttr{Key: t[0], Value: otlpValue{StringValue: t[1]}})
+	}
+	counter := func(name, unit string, value int64) otlpMetric {
+		return otlpMetric{Name: cfg.Prefix + "." + name, Unit: unit, Sum: &otlpSum{
+			AggregationTemporality: 1,
+			IsMonotonic:            true,
+			DataPoints:             []otlpPoint{{Attributes: attrs, StartTimeUnixNano: start, TimeUnixNano: now, AsInt: strconv.FormatInt(value, 10)}},
+		}}
+	}
+	seconds := m.duration.Seconds()
+	metrics := []otlpMetric{
+		counter("files_deleted", "1", int64(m.filesDeleted)),
+		counter("bytes_freed", "By", m.bytesFreed),
+		counter("errors", "1", int64(m.errors)),
+		{Name: cfg.Prefix + ".duration", Unit: "s", Gauge: &otlpGauge{
+			DataPoints: []otlpPoint{{Attributes: attrs, TimeUnixNano: now, AsDouble: &seconds}},
+		}},
+	}
+	body, err := json.Marshal(map[string]any{
+		"resourceMetrics": []any{map[string]any{
+			"resource": map[string]any{"attributes": []otlpAttr{
+				{Key: "service.name", Value: otlpValue{StringValue: "cleanpc"}},
+				{Key: "host.name", Value: otlpValue{StringValue: m.tags[0][1]}},
+			}},
+			"scopeMetrics": []any{map[string]any{
+				"scope":   map[string]string{"name": "cleanpc"},
+				"metrics": metrics,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package cleaner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/cpu"
+	"github.com/shirou/gopsutil/mem"
+)
+
+// maxMetricFailures is how many consecutive failed readings disable a metric
+const maxMetricFailures = 3
+
+// monitorInterval is how often the system monitor takes and shows a reading
+const monitorInterval = 2 * time.Second
+
+// validCPUSampling checks cpu_sample_interval, which has to fit in one
+// monitor tick
+func (c *Config) validCPUSampling() error {
+	if c.CPUSampleInterval == nil {
+		return nil
+	}
+	if d := *c.CPUSampleInterval; d < 0 || d >= monitorInterval {
+		return fmt.Errorf("cpu_sample_interval must be at least 0 and below %s, got %s", monitorInterval, d)
+	}
+	return nil
+}
+
+// cpuSampleInterval returns cpu_sample_interval, defaulting to one second
+func (c *Config) cpuSampleInterval() time.Duration {
+	if c.CPUSampleInterval == nil {
+		return time.Second
+	}
+	return *c.CPUSampleInterval
+}
+
+// readCPU returns the overall CPU percent and, with perCore, the percent of
+// every core, whose mean is then the overall percent
+func readCPU(interval time.Duration, perCore bool) (float64, []float64, error) {
+	percents, err := cpu.Percent(interval, perCore)
+	if err == nil && len(percents) == 0 {
+		err = fmt.Errorf("no CPU readings returned")
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	if !perCore {
+		return percents[0], nil, nil
+	}
+	var total float64
+	for _, p := range percents {
+		total += p
+	}
+	return total / float64(len(percents)), percents, nil
+}
+
+// formatCores renders per-core percents as a compact list, e.g. "[12 40 8 3]"
+func formatCores(percents []float64) string {
+	cores := make([]string, len(percents))
+	for i, p := range percents {
+		cores[i] = fmt.Sprintf("%.0f", p)
+	}
+	return "[" + strings.Join(cores, " ") + "]"
+}
+
+// monitorMetric tracks the health of one value shown by the system monitor
+type monitorMetric struct {
+	name     string
+	failures int
+	disabled bool
+}
+
+// record notes the outcome of a reading. Only the first failure of a streak is
+// logged; after maxMetricFailures in a row the metric is disabled for good.
+func (sc *SystemCleaner) record(m *monitorMetric, err error) bool {
+	if err == nil {
+		m.failures = 0
+		return true
+	}
+
+	m.failures++
+	if m.failures == 1 {
+		sc.logger.Printf("Error getting %s info: %v", m.name, err)
+	}
+	if m.failures >= maxMetricFailures {
+		m.disabled = true
+		sc.logger.Printf("Disabling %s monitoring after %d consecutive failures", m.name, m.failures)
+		fmt.Fprintf(sc.msg, "\n⚠️  %s metrics are unavailable on this system, hiding them\n", m.name)
+	}
+	return false
+}
+
+// SystemMonitor provides real-time system monitoring until ctx is done, or
+// until q is pressed when SetMonitorKeys is in effect. Pausing with the space
+// bar only stops the display; readings and auto optimization go on.
+func (sc *SystemCleaner) SystemMonitor(ctx context.Context) {
+	keys, stopKeys := sc.readKeys()
+	defer stopKeys()
+	if keys != nil {
+		fmt.Fprintln(sc.msg, "\n📊 Live System Monitor (space pauses, q quits the monitor, Ctrl+C exits)")
+	} else {
+		fmt.Fprintln(sc.msg, "\n📊 Live System Monitor (Press Ctrl+C to exit)")
+	}
+
+	ticker := time.NewTicker(monitorInterval)
+	defer ticker.Stop()
+
+	cpuMetric := &monitorMetric{name: "CPU"}
+	memMetric := &monitorMetric{name: "memory"}
+	gpuMetric := &monitorMetric{name: "GPU", disabled: !sc.gpu}
+	if sc.gpu && !gpuAvailable() {
+		sc.logger.Printf("GPU monitoring requested but %s was not found", nvidiaSMI)
+		fmt.Fprintf(sc.msg, "⚠️  No NVIDIA GPU tools (%s) found, GPU monitoring is off\n", nvidiaSMI)
+		gpuMetric.disabled = true
+	}
+	auto := sc.config.newAutoOptimizer()
+	disks := sc.newDiskTrackers()
+	encoder := json.NewEncoder(sc.out)
+	paused := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case key := <-keys:
+			switch key {
+			case ' ', 'p':
+				paused = !paused
+				if paused {
+					fmt.Fprintln(sc.msg, "\n⏸️  Paused, sampling goes on (space resumes, q quits)")
+				} else {
+					fmt.Fprintln(sc.msg, "▶️  Resumed")
+				}
+			case 'q', 'Q':
+				fmt.Fprintln(sc.msg, "\n⏹️  Leaving the system monitor")
+				return
+			}
+		case <-ticker.C:
+			if cpuMetric.disabled && memMetric.disabled {
+				fmt.Fprintln(sc.msg, "\n❌ No system metrics are available, stopping the monitor")
+				return
+			}
+
+			var parts []string
+			sample := MonitorSample{Time: time.Now(), CPUPercent: -1, MemPercent: -1}
+			if !cpuMetric.disabled {
+				cpuPercent, cores, err := readCPU(sc.config.cpuSampleInterval(), sc.config.CPUPerCore)
+				if sc.record(cpuMetric, err) {
+					sample.CPUPercent, sample.CPUPerCore = cpuPercent, cores
+					part := fmt.Sprintf("🖥️ CPU Usage: %.2f%%", cpuPercent)
+					if cores != nil {
+						part += " " + formatCores(cores)
+					}
+					parts = append(parts, part)
+				}
+			}
+			if !memMetric.disabled {
+				v, err := mem.VirtualMemory()
+				if sc.record(memMetric, err) {
+					sample.MemPercent = v.UsedPercent
+					sample.MemUsed, sample.MemTotal = int64(v.Used), int64(v.Total)
+					parts = append(parts, fmt.Sprintf("🏋️ RAM Usage: %.2f%%  (%s used of %s)",
+						v.UsedPercent, sc.FormatSize(int64(v.Used)), sc.FormatSize(int64(v.Total))))
+					if auto.due(v.UsedPercent, sample.Time) {
+						sc.autoOptimize(v)
+					}
+				}
+			}
+			if !gpuMetric.disabled {
+				gpus, err := readGPUs()
+				if sc.record(gpuMetric, err) {
+					sample.GPUs = gpus
+					for _, g := range gpus {
+						util := "n/a"
+						if g.UtilPercent >= 0 {
+							util = fmt.Sprintf("%.0f%%", g.UtilPercent)
+						}
+						parts = append(parts, fmt.Sprintf("🎮 GPU%d: %s  (%s used of %s)",
+							g.Index, util, sc.FormatSize(g.MemUsed), sc.FormatSize(g.MemTotal)))
+					}
+				}
+			}
+
+			for _, d := range disks {
+				s, err := sc.sampleDisk(d, sample.Time)
+				if err != nil {
+					continue
+				}
+				sample.Disks = append(sample.Disks, s)
+				sc.reportFill(d, s, sample.Time)
+				parts = append(parts, fmt.Sprintf("💾 %s: %s", s.Path, fillText(s)))
+			}
+
+			if paused {
+				continue
+			}
+			if sc.jsonOutput {
+				if err := encoder.Encode(sample); err != nil {
+					sc.logger.Printf("Error writing monitor sample: %v", err)
+				}
+				continue
+			}
+
+			if sc.template != nil {
+				if err := sc.render(Report{Kind: "monitor", Monitor: &sample}); err != nil {
+					sc.logger.Printf("Error rendering monitor template: %v", err)
+				}
+				continue
+			}
+			if len(parts) > 0 {
+				fmt.Fprintf(sc.out, "\r%s  ", strings.Join(parts, "  "))
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package cleaner
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// SetMonitorKeys lets SystemMonitor be paused and resumed with the space bar
+// and left with q, reading the keys from in. It reports whether keys will be
+// read, which needs in to be a terminal on Linux, macOS or FreeBSD. While the
+// monitor runs the terminal takes single keys without echo; its settings are
+// restored when the monitor returns. nil turns keys off.
+func (sc *SystemCleaner) SetMonitorKeys(in *os.File) bool {
+	if in == nil || !cbreakSupported || !term.IsTerminal(int(in.Fd())) {
+		sc.keys = nil
+		return false
+	}
+	sc.keys = in
+	return true
+}
+
+// readKeys starts delivering keypresses from the SetMonitorKeys terminal. The
+// returned function stops reading and restores the terminal; it waits for the
+// reader so that no later input is taken from whoever reads the terminal next.
+// Without a terminal the channel is nil and never delivers.
+func (sc *SystemCleaner) readKeys() (<-chan byte, func()) {
+	if sc.keys == nil {
+		return nil, func() {}
+	}
+	restore, err := cbreak(int(sc.keys.Fd()))
+	if err != nil {
+		sc.logger.Printf("Error reading keys for the system monitor: %v", err)
+		return nil, func() {}
+	}
+
+	keys := make(chan byte)
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		buf := make([]byte, 1)
+		for {
+			n, err := sc.keys.Read(buf)
+			if n == 1 {
+				select {
+				case keys <- buf[0]:
+				case <-done:
+					return
+				}
+			}
+			select {
+			case <-done:
+				return
+			default:
+			}
+			// cbreak reads time out as io.EOF when no key was pressed
+			if err != nil && !errors.Is(err, io.EOF) {
+				sc.logger.Printf("Error reading keys for the system monitor: %v", err)
+				return
+			}
+		}
+	}()
+
+	return keys, func() {
+		close(done)
+		<-exited
+		if err := restore(); err != nil {
+			sc.logger.Printf("Error restoring the terminal: %v", err)
+		}
+	}
+}
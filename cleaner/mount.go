@@ -0,0 +1,16 @@
+package cleaner
+
+// mountInfo describes the filesystem a path lives on
+type mountInfo struct {
+	readOnly bool
+	network  bool // NFS, SMB and similar
+
+	inodes     uint64 // 0 when the filesystem has no fixed inode table
+	freeInodes uint64
+}
+
+// isNetworkPath reports whether path is on a network filesystem
+func isNetworkPath(path string) bool {
+	info, err := statMount(path)
+	return err == nil && info.network
+}
@@ -0,0 +1,27 @@
+//go:build darwin || freebsd
+
+package cleaner
+
+import "golang.org/x/sys/unix"
+
+// networkTypes lists the names of network filesystems
+var networkTypes = map[string]bool{
+	"nfs":    true,
+	"smbfs":  true,
+	"afpfs":  true,
+	"webdav": true,
+}
+
+// statMount describes the filesystem holding path
+func statMount(path string) (mountInfo, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return mountInfo{}, err
+	}
+	return mountInfo{
+		readOnly:   st.Flags&unix.MNT_RDONLY != 0,
+		network:    networkTypes[unix.ByteSliceToString(st.Fstypename[:])],
+		inodes:     uint64(st.Files),
+		freeInodes: uint64(st.Ffree),
+	}, nil
+}
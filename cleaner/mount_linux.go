@@ -0,0 +1,27 @@
+package cleaner
+
+import "golang.org/x/sys/unix"
+
+// networkMagic lists the statfs filesystem types of network filesystems
+var networkMagic = map[uint32]bool{
+	unix.NFS_SUPER_MAGIC:  true,
+	unix.SMB_SUPER_MAGIC:  true,
+	unix.SMB2_SUPER_MAGIC: true,
+	unix.CIFS_SUPER_MAGIC: true,
+	unix.AFS_SUPER_MAGIC:  true,
+	unix.CEPH_SUPER_MAGIC: true,
+}
+
+// statMount describes the filesystem holding path
+func statMount(path string) (mountInfo, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return mountInfo{}, err
+	}
+	return mountInfo{
+		readOnly:   st.Flags&unix.ST_RDONLY != 0,
+		network:    networkMagic[uint32(st.Type)],
+		inodes:     uint64(st.Files),
+		freeInodes: uint64(st.Ffree),
+	}, nil
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd
+
+package cleaner
+
+// statMount is not supported on this platform; every path is treated as a
+// writable local filesystem
+func statMount(string) (mountInfo, error) {
+	return mountInfo{}, nil
+}
@@ -0,0 +1,94 @@
+package cleaner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// fileOrders are the orders ScanOldestFiles can list its files in
+var fileOrders = map[string]func(a, b FileInfo) bool{
+	"age":  byAge,
+	"size": bySize,
+	"path": func(a, b FileInfo) bool { return a.Path < b.Path },
+}
+
+// formatAge renders how long ago a file was modified
+func formatAge(d time.Duration) string {
+	days := int(d.Hours() / 24)
+	switch {
+	case days >= 365:
+		return fmt.Sprintf("%.1f years", float64(days)/365)
+	case days >= 1:
+		return fmt.Sprintf("%d days", days)
+	default:
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	}
+}
+
+// ScanOldestFiles lists the n least recently modified files in a directory
+// (every file when n <= 0), ordered by age, size or path
+func (sc *SystemCleaner) ScanOldestFiles(directory string, n int, order string) error {
+	sortBy, ok := fileOrders[order]
+	if !ok {
+		return fmt.Errorf("invalid sort order %q (want age, size or path)", order)
+	}
+	fmt.Fprintln(sc.msg, "\n🔎 Scanning for the oldest files in:", directory)
+
+	stop := sc.startLoading("Analyzing files...")
+
+	top := newTopFiles(n, byAge)
+	err := runWithTimeout(sc.config.ScanTimeout, func(ctx context.Context) error {
+		return sc.walkContext(ctx, directory, sc.capped(func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				sc.logger.Printf("Error accessing path %s: %v", path, err)
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				sc.logger.Printf("Error accessing path %s: %v", path, err)
+				return nil
+			}
+			top.add(FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+			return nil
+		}))
+	})
+
+	stop <- true
+	<-stop
+
+	if sc.checkTruncated(directory, &err) {
+		defer sc.warnTruncated()
+	}
+	if err != nil {
+		return &ScanError{Path: directory, Err: err}
+	}
+
+	files := top.sorted()
+	sort.SliceStable(files, func(i, j int) bool { return sortBy(files[i], files[j]) })
+	if sc.jsonOutput {
+		encoder := json.NewEncoder(sc.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(files)
+	}
+	if sc.template != nil {
+		return sc.render(Report{Kind: "scan", Scan: &ScanReport{Directory: directory, Files: files}})
+	}
+
+	if n > 0 {
+		fmt.Fprintf(sc.out, "\n📂 %d oldest files:\n", len(files))
+	} else {
+		fmt.Fprintf(sc.out, "\n📂 All %d files, oldest first:\n", len(files))
+	}
+	now := time.Now()
+	for _, file := range files {
+		fmt.Fprintf(sc.out, "📄 %s → %s old, %s\n", file.Path, formatAge(now.Sub(file.ModTime)), sc.FormatSize(file.Size))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package cleaner
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SetOneFilesystem keeps every walk on the filesystem of the path it starts
+// from, like find -xdev, as stay_on_filesystem does for the whole config
+func (sc *SystemCleaner) SetOneFilesystem(enabled bool) {
+	sc.oneFilesystem = enabled
+}
+
+// fsBoundary tells the directories of a walk that are on another filesystem
+// than its root. The zero value (and one for a root whose device cannot be
+// read) crosses everywhere.
+type fsBoundary struct {
+	dev uint64
+	ok  bool
+}
+
+// newFSBoundary returns the boundary of a walk of root, or the zero value
+// when neither -one-filesystem nor stay_on_filesystem is in effect
+func (sc *SystemCleaner) newFSBoundary(root string) fsBoundary {
+	if !sc.oneFilesystem && !sc.config.StayOnFilesystem {
+		return fsBoundary{}
+	}
+	info, err := os.Stat(longPath(root))
+	if err != nil {
+		return fsBoundary{}
+	}
+	dev, ok := deviceID(info)
+	if !ok {
+		sc.logger.Printf("Cannot tell filesystems apart here, walking %s across mounts", root)
+	}
+	return fsBoundary{dev: dev, ok: ok}
+}
+
+// crosses reports whether the directory d at path is a mount of another
+// filesystem, logging the boundary when it is
+func (sc *SystemCleaner) crosses(b fsBoundary, path string, d fs.DirEntry) bool {
+	if !b.ok || !d.IsDir() {
+		return false
+	}
+	info, err := d.Info()
+	if err != nil {
+		return false
+	}
+	if dev, ok := deviceID(info); !ok || dev == b.dev {
+		return false
+	}
+	sc.logger.Printf("Not crossing into %s: it is on another filesystem", path)
+	sc.emit(Event{Type: EventSkipped, Path: path, Reason: "other filesystem"})
+	return true
+}
+
+// pathsWithin returns the cleanup paths at or below dir, and the cleanup path
+// dir is inside of with that path's rules, limited to dir. The limited path
+// keeps its root, so .cleanignore files above dir and skip_paths still apply.
+// ok is false when dir is not covered by any cleanup path.
+func (c *Config) pathsWithin(dir string) (paths []CleanupPath, ok bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, false
+	}
+	for _, cp := range c.CleanupPaths {
+		root, err := filepath.Abs(cp.Path)
+		if err != nil {
+			continue
+		}
+		switch {
+		case within(root, dir):
+			paths = append(paths, cp)
+		case within(dir, root):
+			// the limit is written like the walk's paths, relative if cp.Path is
+			rel, _ := filepath.Rel(root, dir)
+			cp.limit = filepath.Join(cp.Path, rel)
+			paths = append(paths, cp)
+		}
+	}
+	return paths, len(paths) > 0
+}
+
+// limited reports whether any of the paths is limited to part of its tree
+func limited(paths []CleanupPath) bool {
+	for _, cp := range paths {
+		if cp.limit != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// within reports whether path is dir or below it
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,59 @@
+package cleaner
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ownPaths returns the files and directories the tool itself writes to
+func (c *Config) ownPaths() []string {
+	var paths []string
+	logFile := c.LogFile
+	if c.logPath != "" {
+		logFile = c.logPath
+	}
+	for _, p := range []string{logFile, c.ArchiveDir, c.AuditLog, c.CheckpointFile, c.StateFile, c.KeepList, c.HistoryDB, c.RulesFile} {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if c.CheckpointFile != "" {
+		paths = append(paths, c.CheckpointFile+".tmp")
+	}
+	if c.StateFile != "" {
+		paths = append(paths, c.StateFile+".tmp")
+	}
+	if c.HistoryDB != "" {
+		paths = append(paths, c.HistoryDB+"-journal")
+	}
+	return paths
+}
+
+// findOwnFiles works out which of the tool's own files lie under a cleanup
+// path, keyed by cleanup path and spelled the way a walk of it reaches them,
+// so that cleaning never deletes the log or archive it is writing
+func (sc *SystemCleaner) findOwnFiles() map[string]map[string]bool {
+	own := make(map[string]map[string]bool)
+	for _, cp := range sc.config.CleanupPaths {
+		root, err := filepath.Abs(cp.Path)
+		if err != nil {
+			continue
+		}
+		for _, p := range sc.config.ownPaths() {
+			abs, err := filepath.Abs(p)
+			if err != nil {
+				continue
+			}
+			rel, err := filepath.Rel(root, abs)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			if own[cp.Path] == nil {
+				own[cp.Path] = make(map[string]bool)
+			}
+			own[cp.Path][filepath.Join(cp.Path, rel)] = true
+			sc.logger.Printf("Excluding %s from cleaning: the tool writes to it and it is under cleanup path %s", p, cp.Path)
+		}
+	}
+	return own
+}
@@ -0,0 +1,104 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// matchPathGlob matches a slash-separated relative path against a pattern in
+// which "**" stands for any number of path segments (including none) and
+// every other segment is a path.Match pattern
+func matchPathGlob(pattern, rel string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+			return false
+		}
+		pattern, segments = pattern[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+// skipPath reports whether a path relative to the walk root matches one of
+// the configured skip_paths patterns
+func (c *Config) skipPath(rel string) bool {
+	for _, pattern := range c.SkipPaths {
+		if matchPathGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPathGlob reports whether a cleanup path is a filepath.Glob pattern
+func isPathGlob(p string) bool {
+	return strings.ContainsAny(p, "*?[")
+}
+
+// expandPathGlobs replaces every cleanup path that is a glob pattern, such as
+// "~/projects/*/node_modules", with the directories it matches, each keeping
+// the entry's rules. Plain paths are kept as they are. A pattern matching no
+// directory is dropped with a warning.
+func (c *Config) expandPathGlobs(paths []CleanupPath) ([]CleanupPath, error) {
+	var expanded []CleanupPath
+	for _, cp := range paths {
+		if !isPathGlob(cp.Path) {
+			expanded = append(expanded, cp)
+			continue
+		}
+
+		matches, err := filepath.Glob(expandHome(cp.Path))
+		if err != nil {
+			return nil, fmt.Errorf("cleanup path %q: %w", cp.Path, err)
+		}
+		var dirs []string
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.IsDir() {
+				dirs = append(dirs, match)
+			}
+		}
+		if len(dirs) == 0 {
+			c.notes = append(c.notes, fmt.Sprintf("Cleanup path pattern %s matches no directories", cp.Path))
+			continue
+		}
+		c.logs = append(c.logs, fmt.Sprintf("Cleanup path pattern %s matches %d directories: %s", cp.Path, len(dirs), strings.Join(dirs, ", ")))
+		for _, dir := range dirs {
+			match := cp
+			match.Path = dir
+			expanded = append(expanded, match)
+		}
+	}
+	return expanded, nil
+}
+
+// expandAllPathGlobs expands the patterns of the top-level cleanup paths and
+// of every job
+func (c *Config) expandAllPathGlobs() error {
+	var err error
+	if c.CleanupPaths, err = c.expandPathGlobs(c.CleanupPaths); err != nil {
+		return err
+	}
+	for i := range c.Jobs {
+		if c.Jobs[i].CleanupPaths, err = c.expandPathGlobs(c.Jobs[i].CleanupPaths); err != nil {
+			return fmt.Errorf("job %s: %w", c.Jobs[i].Name, err)
+		}
+	}
+	return nil
+}
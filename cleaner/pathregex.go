@@ -0,0 +1,83 @@
+package cleaner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// validPathRegex compiles every path_regex pattern once, so that a bad one
+// fails the config instead of a clean
+func (c *Config) validPathRegex() error {
+	c.regexes = make(map[string]*regexp.Regexp)
+	compile := func(patterns []string) error {
+		for _, pattern := range patterns {
+			if _, ok := c.regexes[pattern]; ok {
+				continue
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid path_regex %q: %w", pattern, err)
+			}
+			c.regexes[pattern] = re
+		}
+		return nil
+	}
+	if err := compile(c.PathRegex); err != nil {
+		return err
+	}
+	for _, cp := range c.CleanupPaths {
+		if err := compile(cp.PathRegex); err != nil {
+			return fmt.Errorf("%s: %w", cp.Path, err)
+		}
+	}
+	for _, job := range c.Jobs {
+		if err := compile(job.PathRegex); err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+		for _, cp := range job.CleanupPaths {
+			if err := compile(cp.PathRegex); err != nil {
+				return fmt.Errorf("job %s: %s: %w", job.Name, cp.Path, err)
+			}
+		}
+	}
+	return nil
+}
+
+// compiledRegex returns the compiled form of path_regex patterns
+func (c *Config) compiledRegex(patterns []string) []*regexp.Regexp {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if re, ok := c.regexes[pattern]; ok {
+			compiled = append(compiled, re)
+		}
+	}
+	return compiled
+}
+
+// matchRegex returns the first pattern matching the full path and its
+// submatches, or nil when none does
+func (r pathRules) matchRegex(path string) (*regexp.Regexp, []string) {
+	for _, re := range r.regexes {
+		if m := re.FindStringSubmatch(path); m != nil {
+			return re, m
+		}
+	}
+	return nil, nil
+}
+
+// formatGroups renders the capture groups of a match as name=value pairs,
+// numbering the groups that have no name
+func formatGroups(re *regexp.Regexp, match []string) string {
+	var groups []string
+	for i, name := range re.SubexpNames() {
+		if i == 0 {
+			continue
+		}
+		if name == "" {
+			name = fmt.Sprint(i)
+		}
+		groups = append(groups, fmt.Sprintf("%s=%q", name, match[i]))
+	}
+	return strings.Join(groups, " ")
+}
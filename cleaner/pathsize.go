@@ -0,0 +1,49 @@
+package cleaner
+
+import "fmt"
+
+// SetForce lets cleans go ahead with cleanup paths whose junk exceeds
+// max_path_size
+func (sc *SystemCleaner) SetForce(enabled bool) {
+	sc.force = enabled
+}
+
+// overPathSize reports whether a cleanup path with this much junk is over
+// max_path_size
+func (c *Config) overPathSize(size int64) bool {
+	return c.MaxPathSize > 0 && size > c.MaxPathSize
+}
+
+// sizeLimitedPaths measures the junk of every cleanup path and drops those
+// over max_path_size, which usually means a path was configured by mistake,
+// such as the whole home directory. With SetForce they are kept, with a
+// warning.
+func (sc *SystemCleaner) sizeLimitedPaths(paths []CleanupPath) []CleanupPath {
+	if sc.config.MaxPathSize <= 0 {
+		return paths
+	}
+
+	var kept []CleanupPath
+	for _, cp := range paths {
+		size, err := sc.getDirSize(cp)
+		if err != nil {
+			sc.logger.Printf("Error scanning directory %s: %v", cp.Path, err)
+		}
+		if !sc.config.overPathSize(size) {
+			kept = append(kept, cp)
+			continue
+		}
+		limit := sc.FormatSize(sc.config.MaxPathSize)
+		if sc.force {
+			sc.logger.Printf("Cleaning %s despite %d bytes of junk over max_path_size %d: forced", cp.Path, size, sc.config.MaxPathSize)
+			fmt.Fprintf(sc.msg, "⚠️  %s holds %s of junk, over max_path_size %s; cleaning it anyway\n", cp.Path, sc.FormatSize(size), limit)
+			kept = append(kept, cp)
+			continue
+		}
+		sc.logger.Printf("Skipping %s: %d bytes of junk exceeds max_path_size %d", cp.Path, size, sc.config.MaxPathSize)
+		fmt.Fprintf(sc.msg, "🚨 Skipping %s: it holds %s of junk, over max_path_size %s. Check that it is the path you meant, or pass -force to clean it anyway 🚨\n",
+			cp.Path, sc.FormatSize(size), limit)
+		sc.emit(Event{Type: EventSkipped, Path: cp.Path, Size: size, Reason: "over max_path_size"})
+	}
+	return kept
+}
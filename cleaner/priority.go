@@ -0,0 +1,50 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+)
+
+// errPriorityUnsupported is returned where a priority cannot be set
+var errPriorityUnsupported = errors.New("not supported on this platform")
+
+// ioClasses are the accepted io_class names
+var ioClasses = []string{"idle", "best-effort"}
+
+// validPriority checks nice_level and io_class
+func (c *Config) validPriority() error {
+	if c.NiceLevel < -20 || c.NiceLevel > 19 {
+		return fmt.Errorf("nice_level must be between -20 and 19, got %d", c.NiceLevel)
+	}
+	if c.IOClass == "" {
+		return nil
+	}
+	for _, class := range ioClasses {
+		if c.IOClass == class {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid io_class %q (want idle or best-effort)", c.IOClass)
+}
+
+// applyPriority lowers the CPU and IO priority of the process as configured.
+// A priority that cannot be set is logged and shown, and the run goes on at
+// the normal priority.
+func (sc *SystemCleaner) applyPriority() {
+	if n := sc.config.NiceLevel; n != 0 {
+		if err := setNice(n); err != nil {
+			sc.logger.Printf("Could not set nice_level %d: %v", n, err)
+			fmt.Fprintf(sc.msg, "⚠️  Could not set nice_level %d, running at normal CPU priority: %v\n", n, err)
+		} else {
+			sc.logger.Printf("Running with nice level %d", n)
+		}
+	}
+	if class := sc.config.IOClass; class != "" {
+		if err := setIOClass(class); err != nil {
+			sc.logger.Printf("Could not set io_class %s: %v", class, err)
+			fmt.Fprintf(sc.msg, "⚠️  Could not set io_class %s, running at normal IO priority: %v\n", class, err)
+		} else {
+			sc.logger.Printf("Running with IO class %s", class)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package cleaner
+
+import (
+	"errors"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// ioprio_set arguments, from linux/ioprio.h
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+	ioprioClassBE    = 2
+	ioprioClassIdle  = 3
+	ioprioLowestBE   = 7 // the lowest priority within the best-effort class
+)
+
+// setNice sets the nice level of every thread of the process. Linux keeps
+// priorities per thread, and threads started later inherit them.
+func setNice(level int) error {
+	return eachThread(func(tid int) error {
+		return unix.Setpriority(unix.PRIO_PROCESS, tid, level)
+	})
+}
+
+// setIOClass sets the IO scheduling class of every thread of the process
+func setIOClass(class string) error {
+	prio := ioprioClassBE<<ioprioClassShift | ioprioLowestBE
+	if class == "idle" {
+		prio = ioprioClassIdle << ioprioClassShift
+	}
+	return eachThread(func(tid int) error {
+		_, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), uintptr(prio))
+		if errno != 0 {
+			return errno
+		}
+		return nil
+	})
+}
+
+// eachThread calls set for every thread listed in /proc/self/task, going on
+// past threads that exited meanwhile
+func eachThread(set func(tid int) error) error {
+	entries, err := os.ReadDir("/proc/self/task")
+	if err != nil {
+		return set(0)
+	}
+	for _, entry := range entries {
+		tid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		if err := set(tid); err != nil && !errors.Is(err, unix.ESRCH) {
+			return err
+		}
+	}
+	return nil
+}
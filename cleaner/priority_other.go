@@ -0,0 +1,13 @@
+//go:build !linux
+
+package cleaner
+
+// setNice is only implemented on Linux
+func setNice(level int) error {
+	return errPriorityUnsupported
+}
+
+// setIOClass is only implemented on Linux
+func setIOClass(class string) error {
+	return errPriorityUnsupported
+}
@@ -0,0 +1,178 @@
+package cleaner
+
+import "sync"
+
+// Progress is a snapshot of how far the current clean has got
+type Progress struct {
+	Running      bool
+	FilesDeleted int
+	BytesFreed   int64
+	NotDeleted   int   // files verify_deletion found still in place
+	DiskFreed    int64 // measured growth of free space, with check_freed_space
+	PathsDone    []string
+	PathsPending []string
+}
+
+// progressTracker keeps running counters for a clean; it is updated by the
+// clean workers and read from the interrupt handler
+type progressTracker struct {
+	mu           sync.Mutex
+	running      bool
+	filesDeleted int
+	bytesFreed   int64
+	notDeleted   int
+	measured     int64
+	paths        []string
+	done         map[string]bool
+	byPath       map[string]*pathTotals // what was deleted from each cleanup path
+	found        map[string]*pathTotals // the junk found in each cleanup path
+}
+
+// pathTotals counts the files deleted from, or found in, one cleanup path
+type pathTotals struct {
+	files int
+	bytes int64
+}
+
+// reset zeroes the counters, so that a clean that stops before it starts
+// deleting does not report the previous one
+func (p *progressTracker) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.filesDeleted = 0
+	p.bytesFreed = 0
+	p.notDeleted = 0
+	p.measured = 0
+	p.paths = nil
+	p.done = make(map[string]bool)
+	p.byPath = make(map[string]*pathTotals)
+	p.found = make(map[string]*pathTotals)
+}
+
+// start resets the counters for a clean over the given paths
+func (p *progressTracker) start(paths []string) {
+	p.reset()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running = true
+	p.paths = paths
+}
+
+// finish marks the clean as no longer running
+func (p *progressTracker) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running = false
+}
+
+// deleted records one deleted file
+func (p *progressTracker) deleted(size int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.filesDeleted++
+	p.bytesFreed += size
+}
+
+// deletedFrom is deleted for a file of the cleanup path root
+func (p *progressTracker) deletedFrom(root string, size int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.filesDeleted++
+	p.bytesFreed += size
+	addTotal(p.byPath, root, size)
+}
+
+// foundIn records a junk file found in the cleanup path root, deleted or not
+func (p *progressTracker) foundIn(root string, size int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	addTotal(p.found, root, size)
+}
+
+// walked records that the cleanup path root was walked to the end, so a path
+// without junk is recorded with zero files
+func (p *progressTracker) walked(root string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.found[root] == nil {
+		p.found[root] = &pathTotals{}
+	}
+}
+
+// addTotal counts one file of size bytes in the totals of root
+func addTotal(totals map[string]*pathTotals, root string, size int64) {
+	t := totals[root]
+	if t == nil {
+		t = &pathTotals{}
+		totals[root] = t
+	}
+	t.files++
+	t.bytes += size
+}
+
+// pathTotals returns a copy of the per-path counters of deleted files
+func (p *progressTracker) pathTotals() map[string]pathTotals {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return copyTotals(p.byPath)
+}
+
+// foundTotals returns a copy of the per-path counters of junk found
+func (p *progressTracker) foundTotals() map[string]pathTotals {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return copyTotals(p.found)
+}
+
+// copyTotals copies per-path counters so they can be read without the lock
+func copyTotals(totals map[string]*pathTotals) map[string]pathTotals {
+	copied := make(map[string]pathTotals, len(totals))
+	for path, t := range totals {
+		copied[path] = *t
+	}
+	return copied
+}
+
+// stillExists records a file that verify_deletion found after removing it
+func (p *progressTracker) stillExists() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.notDeleted++
+}
+
+// diskFreed records the free space a clean was measured to release
+func (p *progressTracker) diskFreed(size int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.measured = size
+}
+
+// pathDone records that a cleanup path was fully processed
+func (p *progressTracker) pathDone(path string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done[path] = true
+}
+
+// Progress returns a snapshot of the running (or last) clean
+func (sc *SystemCleaner) Progress() Progress {
+	p := &sc.progress
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	snapshot := Progress{
+		Running:      p.running,
+		FilesDeleted: p.filesDeleted,
+		BytesFreed:   p.bytesFreed,
+		NotDeleted:   p.notDeleted,
+		DiskFreed:    p.measured,
+	}
+	for _, path := range p.paths {
+		if p.done[path] {
+			snapshot.PathsDone = append(snapshot.PathsDone, path)
+		} else {
+			snapshot.PathsPending = append(snapshot.PathsPending, path)
+		}
+	}
+	return snapshot
+}
@@ -0,0 +1,62 @@
+package cleaner
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Prompter asks the user questions. The cleaner asks its extra confirmations
+// through one, so a test can feed scripted answers and another frontend (a
+// TUI, an HTTP service) can ask in its own way.
+type Prompter interface {
+	// Confirm asks a yes/no question
+	Confirm(question string) bool
+	// Ask shows prompt and returns a line of text; ok is false once there is
+	// no more input
+	Ask(prompt string) (answer string, ok bool)
+}
+
+// StdPrompter asks on a terminal, writing questions to one stream and reading
+// answers from another, a line each
+type StdPrompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// NewStdPrompter returns a StdPrompter reading from in and writing to out.
+// All prompts of a program should share one, since input buffered by one
+// reader is lost to the next.
+func NewStdPrompter(in io.Reader, out io.Writer) *StdPrompter {
+	return &StdPrompter{in: bufio.NewReader(in), out: out}
+}
+
+// Confirm accepts "yes" in any case; anything else, or no input, is a no
+func (p *StdPrompter) Confirm(question string) bool {
+	answer, _ := p.Ask("\n⚠️  " + question + " (yes/no): ")
+	return strings.ToLower(answer) == "yes"
+}
+
+// Ask returns the next line with surrounding space trimmed
+func (p *StdPrompter) Ask(prompt string) (string, bool) {
+	fmt.Fprint(p.out, prompt)
+	line, err := p.in.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if err != nil && line == "" {
+		return "", false
+	}
+	return line, true
+}
+
+// SetPrompter sets who the cleaner asks for extra confirmation, e.g. when a
+// clean exceeds max_delete_files. By default it asks on stdin and stderr; with
+// nil nobody can answer and such operations are refused.
+func (sc *SystemCleaner) SetPrompter(p Prompter) {
+	sc.prompter = p
+}
+
+// confirmExtra asks the prompter, if there is one
+func (sc *SystemCleaner) confirmExtra(question string) bool {
+	return sc.prompter != nil && sc.prompter.Confirm(question)
+}
@@ -0,0 +1,437 @@
+package cleaner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// recipe is a built-in cleanup for space that is reclaimed by a system tool
+// rather than by deleting files from a cleanup path
+type recipe interface {
+	// Name is the name used in the recipes config list
+	Name() string
+	// Description is shown in reports, e.g. "apt package cache"
+	Description() string
+	// Available reports whether the recipe can run on this system
+	Available() bool
+	// Size returns the space the recipe would reclaim
+	Size() (int64, error)
+	// Commands returns the commands that reclaim the space
+	Commands() ([][]string, error)
+}
+
+// partedRecipe is implemented by recipes that reclaim space from several
+// sources, such as one per browser, that reports list one by one
+type partedRecipe interface {
+	Parts() ([]RecipeUsage, error)
+}
+
+// fileRecipe is implemented by recipes whose space is held by files the
+// cleaner can list itself. They are deleted like junk, through the same
+// safeguards, instead of by a command.
+type fileRecipe interface {
+	Files() ([]recipeFile, error)
+}
+
+// recipeFile is a file a fileRecipe reclaims, found under root. part names
+// the part of a partedRecipe it belongs to, such as its browser.
+type recipeFile struct {
+	path, root, part string
+	size             int64
+}
+
+// recipeFactories builds the known recipes; add new ones here
+var recipeFactories = map[string]func(c *Config) recipe{
+	"apt": func(*Config) recipe {
+		return cacheRecipe{"apt", "apt package cache", "/var/cache/apt/archives", []string{"apt-get", "clean"}}
+	},
+	"dnf": func(*Config) recipe {
+		return cacheRecipe{"dnf", "dnf package cache", "/var/cache/dnf", []string{"dnf", "clean", "packages"}}
+	},
+	"journal": func(c *Config) recipe { return journalRecipe{vacuum: c.JournalVacuum} },
+	"snap":    func(*Config) recipe { return snapRecipe{} },
+	"docker":  func(*Config) recipe { return newDockerRecipe() },
+	"browser": func(*Config) recipe { return browserRecipe{} },
+}
+
+// validRecipe reports an error for unknown recipe names
+func validRecipe(name string) error {
+	if _, ok := recipeFactories[name]; !ok {
+		names := make([]string, 0, len(recipeFactories))
+		for known := range recipeFactories {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("invalid recipe %q (want one of %s)", name, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// EnableRecipes turns on built-in cleanup recipes in addition to the
+// configured ones
+func (c *Config) EnableRecipes(names ...string) error {
+	for _, name := range names {
+		if err := validRecipe(name); err != nil {
+			return err
+		}
+		c.Recipes = append(c.Recipes, name)
+	}
+	return nil
+}
+
+// enabledRecipes returns the configured recipes that can run on this system,
+// logging the ones that cannot
+func (sc *SystemCleaner) enabledRecipes() []recipe {
+	var enabled []recipe
+	for _, name := range sc.config.Recipes {
+		r := recipeFactories[name](sc.config)
+		if !r.Available() {
+			sc.logger.Printf("Recipe %s is not available on this system, skipping it", name)
+			continue
+		}
+		enabled = append(enabled, r)
+	}
+	return enabled
+}
+
+// haveCommand reports whether we are on Linux and name is on the PATH
+func haveCommand(name string) bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// treeSize sums the sizes of the regular files under root that match keep
+func treeSize(root string, keep func(path string) bool) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || (keep != nil && !keep(path)) {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// cacheRecipe empties a package manager's download cache
+type cacheRecipe struct {
+	name, description, dir string
+	command                []string
+}
+
+func (r cacheRecipe) Name() string        { return r.name }
+func (r cacheRecipe) Description() string { return r.description }
+func (r cacheRecipe) Available() bool     { return haveCommand(r.command[0]) }
+func (r cacheRecipe) Size() (int64, error) {
+	return treeSize(r.dir, nil)
+}
+func (r cacheRecipe) Commands() ([][]string, error) {
+	return [][]string{r.command}, nil
+}
+
+// journalRecipe vacuums archived systemd journal files
+type journalRecipe struct {
+	vacuum string // journalctl --vacuum-time argument
+}
+
+func (journalRecipe) Name() string        { return "journal" }
+func (journalRecipe) Description() string { return "archived systemd journals" }
+func (journalRecipe) Available() bool     { return haveCommand("journalctl") }
+
+// Size counts archived journal files (named system@….journal); the active
+// journals are never vacuumed
+func (journalRecipe) Size() (int64, error) {
+	return treeSize("/var/log/journal", func(path string) bool {
+		name := filepath.Base(path)
+		return strings.Contains(name, "@") && strings.Contains(name, ".journal")
+	})
+}
+func (r journalRecipe) Commands() ([][]string, error) {
+	return [][]string{{"journalctl", "--vacuum-time=" + r.vacuum}}, nil
+}
+
+// snapRecipe removes disabled (superseded) snap revisions
+type snapRecipe struct{}
+
+func (snapRecipe) Name() string        { return "snap" }
+func (snapRecipe) Description() string { return "disabled snap revisions" }
+func (snapRecipe) Available() bool     { return haveCommand("snap") }
+
+// disabled lists the disabled revisions as name/revision pairs
+func (snapRecipe) disabled() ([][2]string, error) {
+	out, err := exec.Command("snap", "list", "--all").Output()
+	if err != nil {
+		return nil, err
+	}
+	var revisions [][2]string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// Name Version Rev Tracking Publisher Notes
+		if len(fields) >= 6 && strings.Contains(fields[len(fields)-1], "disabled") {
+			revisions = append(revisions, [2]string{fields[0], fields[2]})
+		}
+	}
+	return revisions, nil
+}
+
+func (r snapRecipe) Size() (int64, error) {
+	revisions, err := r.disabled()
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, rev := range revisions {
+		if info, err := os.Stat(fmt.Sprintf("/var/lib/snapd/snaps/%s_%s.snap", rev[0], rev[1])); err == nil {
+			size += info.Size()
+		}
+	}
+	return size, nil
+}
+func (r snapRecipe) Commands() ([][]string, error) {
+	revisions, err := r.disabled()
+	if err != nil {
+		return nil, err
+	}
+	var commands [][]string
+	for _, rev := range revisions {
+		commands = append(commands, []string{"snap", "remove", rev[0], "--revision=" + rev[1]})
+	}
+	return commands, nil
+}
+
+// recipeUsage measures every enabled recipe
+func (sc *SystemCleaner) recipeUsage() []RecipeUsage {
+	var usage []RecipeUsage
+	for _, r := range sc.enabledRecipes() {
+		size, err := r.Size()
+		if err != nil {
+			sc.logger.Printf("Error measuring %s: %v", r.Description(), err)
+		}
+		u := RecipeUsage{Name: r.Name(), Description: r.Description(), Size: size}
+		if p, ok := r.(partedRecipe); ok {
+			u.Parts, _ = p.Parts()
+		}
+		usage = append(usage, u)
+	}
+	return usage
+}
+
+// runRecipes reclaims the space of every enabled recipe. Commands need root,
+// so they run through sudo; a failing recipe is logged and the others still
+// run.
+func (sc *SystemCleaner) runRecipes() {
+	for _, r := range sc.enabledRecipes() {
+		if f, ok := r.(fileRecipe); ok {
+			sc.cleanRecipeFiles(r, f)
+			continue
+		}
+		if p, ok := r.(pruner); ok {
+			sc.prune(r, p)
+			continue
+		}
+
+		size, _ := r.Size()
+		commands, err := r.Commands()
+		if err != nil {
+			sc.logger.Printf("Error preparing %s: %v", r.Description(), err)
+			fmt.Fprintf(sc.msg, "❌ Could not clean %s: %v\n", r.Description(), err)
+			continue
+		}
+
+		failed := false
+		for _, args := range commands {
+			if sc.dryRun {
+				fmt.Fprintf(sc.out, "🧪 Would run: sudo %s\n", strings.Join(args, " "))
+				continue
+			}
+			cmd := exec.Command("sudo", args...)
+			if out, err := cmd.CombinedOutput(); err != nil {
+				sc.logger.Printf("Error running %s: %v: %s", strings.Join(args, " "), err, out)
+				fmt.Fprintf(sc.msg, "❌ Could not clean %s: %v\n", r.Description(), err)
+				failed = true
+				break
+			}
+		}
+		if !failed && !sc.dryRun {
+			fmt.Fprintf(sc.out, "🧰 Cleaned %s (%s)\n", r.Description(), sc.FormatSize(size))
+		}
+	}
+}
+
+// RunRecipes reclaims the space of the top-level recipes on its own. CleanJob
+// leaves them out, so a run of several jobs calls this once after them.
+func (sc *SystemCleaner) RunRecipes() error {
+	if err := sc.loadKeep(); err != nil {
+		return err
+	}
+	defer sc.startBudget()()
+	sc.runRecipes()
+	return nil
+}
+
+// prune runs a recipe that reclaims its space through an API
+func (sc *SystemCleaner) prune(r recipe, p pruner) {
+	if sc.dryRun {
+		size, _ := r.Size()
+		fmt.Fprintf(sc.out, "🧪 Would prune %s (%s)\n", r.Description(), sc.FormatSize(size))
+		return
+	}
+	reclaimed, err := p.Prune()
+	if err != nil {
+		sc.logger.Printf("Error pruning %s: %v", r.Description(), err)
+		fmt.Fprintf(sc.msg, "❌ Could not clean %s: %v\n", r.Description(), err)
+		if reclaimed == 0 {
+			return
+		}
+	}
+	fmt.Fprintf(sc.out, "🧰 Cleaned %s (%s)\n", r.Description(), sc.FormatSize(reclaimed))
+}
+
+// cleanRecipeFiles deletes the files of a fileRecipe with the safeguards of
+// a junk clean: keep_list, max_delete_files, verify_deletion, the audit log,
+// events, Ctrl+C, the time budget and -script-out. Subdirectories left empty
+// are removed too; the roots themselves are kept.
+func (sc *SystemCleaner) cleanRecipeFiles(r recipe, fr fileRecipe) {
+	listed, err := fr.Files()
+	if err != nil {
+		sc.logger.Printf("Error listing %s: %v", r.Description(), err)
+		sc.emit(Event{Type: EventError, Path: r.Name(), Reason: "cannot read", Err: err})
+	}
+	checkers := make(map[string]func(string) bool)
+	var files []recipeFile
+	var size int64
+	for _, f := range listed {
+		kept, ok := checkers[f.root]
+		if !ok {
+			kept = sc.keep.keepChecker(f.root)
+			checkers[f.root] = kept
+		}
+		if !sc.protected(kept, f.path) {
+			files = append(files, f)
+			size += f.size
+		}
+	}
+	if len(files) == 0 {
+		return
+	}
+	if err := sc.checkDeleteCount(len(files)); err != nil {
+		sc.logger.Printf("Not cleaning %s: %v", r.Description(), err)
+		fmt.Fprintf(sc.msg, "❌ Could not clean %s: %v\n", r.Description(), err)
+		return
+	}
+
+	if sc.dryRun {
+		var parts []string
+		partSizes := make(map[string]int64)
+		for _, f := range files {
+			sc.emit(Event{Type: EventSkipped, Path: f.path, Size: f.size, Reason: "dry run"})
+			sc.scriptDelete(f.path, f.size, "")
+			if _, ok := partSizes[f.part]; !ok && f.part != "" {
+				parts = append(parts, f.part)
+			}
+			partSizes[f.part] += f.size
+		}
+		fmt.Fprintf(sc.out, "🧪 Would delete %d files of %s (%s)\n", len(files), r.Description(), sc.FormatSize(size))
+		sort.Strings(parts)
+		for _, part := range parts {
+			fmt.Fprintf(sc.out, "   %s → %s\n", part, sc.FormatSize(partSizes[part]))
+		}
+		return
+	}
+
+	audit, err := sc.openAudit()
+	if err != nil {
+		sc.logger.Printf("Not cleaning %s: %v", r.Description(), err)
+		fmt.Fprintf(sc.msg, "❌ Could not clean %s: %v\n", r.Description(), err)
+		return
+	}
+	defer sc.closeAudit(audit)
+	remover := newFileRemover()
+	defer remover.close()
+
+	var deleted, failed int
+	var reclaimed int64
+	roots := make(map[string]bool)
+	stopped := false
+	for _, f := range files {
+		select {
+		case <-sc.stopChan:
+			sc.logger.Printf("Clean of %s interrupted", r.Description())
+			stopped = true
+		case <-sc.budgetSpent():
+			sc.logger.Printf("Clean of %s stopped: %v", r.Description(), ErrBudget)
+			stopped = true
+		default:
+		}
+		if stopped {
+			break
+		}
+		if err := sc.removeFile(remover, f.path); err != nil {
+			sc.logger.Printf("Error removing file %s: %v", f.path, err)
+			sc.emit(Event{Type: EventError, Path: f.path, Size: f.size, Reason: "delete failed", Err: err})
+			failed++
+			continue
+		}
+		sc.emit(Event{Type: EventDeleted, Path: f.path, Size: f.size})
+		sc.audit(audit, f.path, f.size)
+		sc.progress.deleted(f.size)
+		roots[f.root] = true
+		deleted++
+		reclaimed += f.size
+	}
+	for root := range roots {
+		sc.removeEmptiedDirs(root)
+	}
+
+	sc.logger.Printf("Cleaned %s: %d of %d files deleted, %d bytes", r.Description(), deleted, len(files), reclaimed)
+	fmt.Fprintf(sc.out, "🧰 Cleaned %s (%s)\n", r.Description(), sc.FormatSize(reclaimed))
+	if failed > 0 {
+		fmt.Fprintf(sc.msg, "⚠️  %d files of %s could not be deleted (see the log)\n", failed, r.Description())
+	}
+}
+
+// removeEmptiedDirs removes the empty directories below root, deepest first,
+// so that directories emptied by the removal go as well. root is kept.
+func (sc *SystemCleaner) removeEmptiedDirs(root string) {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path != root {
+			dirs = append(dirs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		sc.logger.Printf("Error accessing path %s: %v", root, err)
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if entries, err := os.ReadDir(dirs[i]); err != nil || len(entries) > 0 {
+			continue
+		}
+		if err := os.Remove(dirs[i]); err != nil {
+			sc.logger.Printf("Error removing directory %s: %v", dirs[i], err)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package cleaner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// remoteTimeout bounds connecting to a remote host
+const remoteTimeout = 10 * time.Second
+
+// RemoteHost is a machine whose junk usage is reported over SSH
+type RemoteHost struct {
+	Host         string   `yaml:"host" json:"host"` // host or host:port
+	User         string   `yaml:"user" json:"user,omitempty"`
+	IdentityFile string   `yaml:"identity_file" json:"identity_file,omitempty"`
+	KnownHosts   string   `yaml:"known_hosts" json:"known_hosts,omitempty"`     // default ~/.ssh/known_hosts
+	CleanupPaths []string `yaml:"cleanup_paths" json:"cleanup_paths,omitempty"` // default: the local cleanup paths
+}
+
+// HostUsage is the junk usage of one remote host
+type HostUsage struct {
+	Host  string
+	Paths []string
+	Sizes map[string]int64 // bytes per path; missing paths are absent
+	Total int64
+	Err   error
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// remoteShellWord quotes a configured path for the remote shell, leaving it
+// to expand a leading ~ to the remote $HOME, $VAR and ${VAR} references and
+// the glob characters * ? [ ]; everything else is quoted literally
+func remoteShellWord(path string) string {
+	var b strings.Builder
+	rest := path
+	if rest == "~" || strings.HasPrefix(rest, "~/") {
+		b.WriteString(`"$HOME"`)
+		rest = rest[1:]
+	}
+	literal := func(s string) {
+		if s != "" {
+			b.WriteString(shellQuote(s))
+		}
+	}
+	start := 0
+	for i := 0; i < len(rest); i++ {
+		switch c := rest[i]; {
+		case strings.IndexByte("*?[]", c) >= 0:
+			literal(rest[start:i])
+			b.WriteByte(c)
+			start = i + 1
+		case c == '$':
+			name, n := envReference(rest[i:])
+			if name == "" {
+				continue
+			}
+			literal(rest[start:i])
+			b.WriteString(`"${` + name + `}"`)
+			i += n - 1
+			start = i + 1
+		}
+	}
+	literal(rest[start:])
+	return b.String()
+}
+
+// envReference parses the $NAME or ${NAME} reference at the start of s,
+// returning the name and the length of the reference; the name is empty when
+// s does not start with one
+func envReference(s string) (string, int) {
+	if strings.HasPrefix(s, "${") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 || !validEnvName(s[2:end]) {
+			return "", 0
+		}
+		return s[2:end], end + 1
+	}
+	n := 1
+	for n < len(s) && (s[n] == '_' || s[n] >= 'a' && s[n] <= 'z' || s[n] >= 'A' && s[n] <= 'Z' || n > 1 && s[n] >= '0' && s[n] <= '9') {
+		n++
+	}
+	if n == 1 {
+		return "", 0
+	}
+	return s[1:n], n
+}
+
+// validEnvName reports whether s is a shell variable name
+func validEnvName(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for _, c := range s {
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// expandHome replaces a leading ~ with the local home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
+
+// sshConfig builds the client configuration for a host, authenticating with
+// its identity file and the running SSH agent. The returned connection to the
+// agent, if any, must be closed once the client is done with it.
+func sshConfig(h RemoteHost) (*ssh.ClientConfig, net.Conn, error) {
+	var auth []ssh.AuthMethod
+	if h.IdentityFile != "" {
+		key, err := os.ReadFile(expandHome(h.IdentityFile))
+		if err != nil {
+			return nil, nil, err
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("identity file %s: %w", h.IdentityFile, err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	var agentConn net.Conn
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			agentConn = conn
+			auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	if len(auth) == 0 {
+		return nil, nil, errors.New("no identity_file and no SSH agent available")
+	}
+
+	knownHostsFile := h.KnownHosts
+	if knownHostsFile == "" {
+		knownHostsFile = "~/.ssh/known_hosts"
+	}
+	hostKeys, err := knownhosts.New(expandHome(knownHostsFile))
+	if err != nil {
+		if agentConn != nil {
+			agentConn.Close()
+		}
+		return nil, nil, err
+	}
+
+	user := h.User
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: hostKeys,
+		Timeout:         remoteTimeout,
+	}, agentConn, nil
+}
+
+// remoteUsage measures the given paths on a remote host with du, which is
+// available on every Unix; per-path cleanup rules are not applied remotely.
+// The paths are expanded by the remote shell, so ~, environment references and
+// glob patterns refer to the remote host, and sizes are reported under the
+// paths as configured.
+func remoteUsage(h RemoteHost, paths []string) HostUsage {
+	usage := HostUsage{Host: h.Host, Paths: paths, Sizes: make(map[string]int64)}
+
+	config, agentConn, err := sshConfig(h)
+	if err != nil {
+		usage.Err = err
+		return usage
+	}
+	if agentConn != nil {
+		defer agentConn.Close()
+	}
+	addr := h.Host
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "22")
+	}
+	client, err := ssh.Dial("tcp", addr, config)
+	if err != nil {
+		usage.Err = err
+		return usage
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		usage.Err = err
+		return usage
+	}
+	defer session.Close()
+
+	// one du per path, summed by awk so a glob matching several directories
+	// is reported as one line "index<TAB>kb"; du fails on a path that is
+	// missing and awk then prints nothing for it
+	commands := make([]string, len(paths))
+	for i, p := range paths {
+		commands[i] = fmt.Sprintf("du -sk -- %s 2>/dev/null | awk '{kb += $1} END {if (NR) print %d \"\\t\" kb}'", remoteShellWord(p), i)
+	}
+	out, err := session.Output(strings.Join(commands, "; "))
+	var exitErr *ssh.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		usage.Err = err
+		return usage
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		index, kb, ok := strings.Cut(scanner.Text(), "\t")
+		if !ok {
+			continue
+		}
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 || i >= len(paths) {
+			continue
+		}
+		n, err := strconv.ParseInt(kb, 10, 64)
+		if err != nil {
+			continue
+		}
+		usage.Sizes[paths[i]] = n * 1024
+		usage.Total += n * 1024
+	}
+	return usage
+}
+
+// ShowRemoteJunkUsage reports the junk usage of every remote host and a
+// combined total. A failing host is reported and does not stop the others.
+func (sc *SystemCleaner) ShowRemoteJunkUsage() error {
+	hosts := sc.config.RemoteHosts
+	if len(hosts) == 0 {
+		return errors.New("no remote_hosts configured")
+	}
+	fmt.Fprintf(sc.msg, "\n🌐 Scanning junk files on %d hosts...\n", len(hosts))
+
+	usages := make([]HostUsage, len(hosts))
+	runParallel(len(hosts), sc.config.scanWorkers(), func(i int) {
+		paths := hosts[i].CleanupPaths
+		if len(paths) == 0 {
+			paths = sc.config.configuredDirs
+		}
+		usages[i] = remoteUsage(hosts[i], paths)
+	})
+
+	var total int64
+	var failed int
+	for _, usage := range usages {
+		fmt.Fprintf(sc.out, "\n🖥️  %s\n", usage.Host)
+		if usage.Err != nil {
+			failed++
+			sc.logger.Printf("Error scanning %s: %v", usage.Host, usage.Err)
+			fmt.Fprintf(sc.out, "   ❌ %v\n", usage.Err)
+			continue
+		}
+		for _, p := range usage.Paths {
+			if size, ok := usage.Sizes[p]; ok {
+				fmt.Fprintf(sc.out, "   📂 %s → %s\n", p, sc.FormatSize(size))
+			} else {
+				sc.logger.Printf("Path %s not found on %s", p, usage.Host)
+				fmt.Fprintf(sc.out, "   📂 %s → not found\n", p)
+			}
+		}
+		fmt.Fprintf(sc.out, "   Total: %s\n", sc.FormatSize(usage.Total))
+		total += usage.Total
+	}
+
+	fmt.Fprintf(sc.out, "\n🚨 Combined Junk Size: %s across %d hosts 🚨\n", sc.FormatSize(total), len(hosts)-failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d hosts could not be scanned", failed, len(hosts))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package cleaner
+
+import (
+	"io/fs"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// fileRemover deletes files with unlinkat relative to a held descriptor of
+// their directory, so a directory with a million entries is not resolved from
+// / a million times. Walks reach files grouped by directory, so one descriptor
+// serves a whole directory. A fileRemover is not safe for concurrent use.
+type fileRemover struct {
+	dir string
+	fd  int
+}
+
+func newFileRemover() *fileRemover {
+	return &fileRemover{fd: -1}
+}
+
+// remove deletes one file
+func (r *fileRemover) remove(path string) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		// a bare name, from a relative cleanup path such as "."
+		dir = "."
+	}
+	if dir != r.dir || r.fd < 0 {
+		r.close()
+		fd, err := unix.Open(dir, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+		if err != nil {
+			return &fs.PathError{Op: "unlinkat", Path: path, Err: err}
+		}
+		r.dir, r.fd = dir, fd
+	}
+	if err := unix.Unlinkat(r.fd, name, 0); err != nil {
+		return &fs.PathError{Op: "unlinkat", Path: path, Err: err}
+	}
+	return nil
+}
+
+// close releases the held directory descriptor
+func (r *fileRemover) close() {
+	if r.fd >= 0 {
+		unix.Close(r.fd)
+		r.fd = -1
+	}
+	r.dir = ""
+}
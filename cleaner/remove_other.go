@@ -0,0 +1,30 @@
+//go:build !linux
+
+package cleaner
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// fileRemover deletes files one os.Remove at a time; only Linux has the
+// unlinkat fast path
+type fileRemover struct{}
+
+func newFileRemover() *fileRemover {
+	return &fileRemover{}
+}
+
+// remove deletes one file, going around MAX_PATH on Windows
+func (r *fileRemover) remove(path string) error {
+	err := os.Remove(longPath(path))
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		pathErr.Path = path
+	}
+	return err
+}
+
+// close is a no-op
+func (r *fileRemover) close() {}
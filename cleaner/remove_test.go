@@ -0,0 +1,140 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileRemover(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    []string // created, then removed in this order
+		relative bool     // remove them by paths relative to the working directory
+	}{
+		{"one file", []string{"a"}, false},
+		{"bare names", []string{"a", "b", "d/c", "e"}, true},
+		{"relative paths", []string{"d/a", "d/b", "e/a"}, true},
+		{"one directory", []string{"d/a", "d/b", "d/c"}, false},
+		{"switching directories", []string{"d/a", "e/a", "d/b", "d/e/a", "e/b"}, false},
+		{"deep directory", []string{"1/2/3/4/5/6/7/8/a", "1/2/3/4/5/6/7/8/b"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			if tt.relative {
+				wd, err := os.Getwd()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Chdir(root); err != nil {
+					t.Fatal(err)
+				}
+				defer os.Chdir(wd)
+				root = ""
+			}
+			var paths []string
+			for _, name := range tt.files {
+				path := filepath.Join(root, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte("junk"), 0644); err != nil {
+					t.Fatal(err)
+				}
+				paths = append(paths, path)
+			}
+
+			r := newFileRemover()
+			defer r.close()
+			for _, path := range paths {
+				if err := r.remove(path); err != nil {
+					t.Fatalf("remove %s: %v", path, err)
+				}
+				if _, err := os.Lstat(path); !os.IsNotExist(err) {
+					t.Errorf("%s still exists: %v", path, err)
+				}
+			}
+		})
+	}
+}
+
+func TestFileRemoverErrors(t *testing.T) {
+	root := t.TempDir()
+	kept := filepath.Join(root, "kept")
+	if err := os.WriteFile(kept, []byte("junk"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"missing file", filepath.Join(root, "missing")},
+		{"missing directory", filepath.Join(root, "missing", "file")},
+	}
+	r := newFileRemover()
+	defer r.close()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := r.remove(tt.path)
+			if !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("remove %s: got %v, want a not-exist error", tt.path, err)
+			}
+			// errors name the path as given, not a descriptor or extended form
+			var pathErr *fs.PathError
+			if !errors.As(err, &pathErr) || pathErr.Path != tt.path {
+				t.Errorf("remove %s: got %#v, want a *fs.PathError for the path", tt.path, err)
+			}
+		})
+	}
+
+	// a failure leaves the remover usable
+	if err := r.remove(kept); err != nil {
+		t.Errorf("remove after a failure: %v", err)
+	}
+}
+
+// BenchmarkRemove compares a fileRemover with one os.Remove per path on a
+// large flat directory
+func BenchmarkRemove(b *testing.B) {
+	tests := []struct {
+		name   string
+		remove func(r *fileRemover, path string) error
+	}{
+		{"os.Remove", func(_ *fileRemover, path string) error { return os.Remove(path) }},
+		{"fileRemover", (*fileRemover).remove},
+	}
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				dir := filepath.Join(b.TempDir(), "a", "b", "c", "d", "e", "f", "g", "h")
+				if err := os.MkdirAll(dir, 0755); err != nil {
+					b.Fatal(err)
+				}
+				paths := make([]string, 10000)
+				for f := range paths {
+					paths[f] = filepath.Join(dir, fmt.Sprintf("file%05d", f))
+					file, err := os.Create(paths[f])
+					if err != nil {
+						b.Fatal(err)
+					}
+					file.Close()
+				}
+				b.StartTimer()
+
+				r := newFileRemover()
+				for _, path := range paths {
+					if err := tt.remove(r, path); err != nil {
+						b.Fatal(err)
+					}
+				}
+				r.close()
+			}
+		})
+	}
+}
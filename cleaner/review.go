@@ -0,0 +1,35 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+)
+
+// reviewJunk lists the files a clean would delete, hands them to the review
+// hook and returns the paths it kept selected
+func (sc *SystemCleaner) reviewJunk(paths []CleanupPath) (map[string]bool, error) {
+	fmt.Fprintln(sc.msg, "🔍 Listing files for review...")
+
+	var files []FileInfo
+	for _, cp := range paths {
+		err := sc.walkJunk(cp, func(path string, info fs.FileInfo) {
+			files = append(files, FileInfo{Path: path, Size: sc.fileSize(info), ModTime: info.ModTime()})
+		})
+		if errors.Is(err, ErrInterrupted) {
+			return nil, &CleanError{Path: cp.Path, Err: err}
+		}
+		if err != nil {
+			sc.logger.Printf("Error accessing path %s: %v", cp.Path, err)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	selected := make(map[string]bool)
+	for _, f := range sc.review(files) {
+		selected[f.Path] = true
+	}
+	sc.logger.Printf("Review kept %d of %d files selected", len(selected), len(files))
+	return selected, nil
+}
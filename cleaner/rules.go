@@ -0,0 +1,278 @@
+package cleaner
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// CleanupPath is a directory to clean together with optional rules that
+// override the global defaults. In YAML it may be written as a plain string.
+type CleanupPath struct {
+	Path            string   `yaml:"path" json:"path"`
+	MinAgeDays      *int     `yaml:"min_age_days" json:"min_age_days,omitempty"`
+	ExcludePatterns []string `yaml:"exclude_patterns" json:"exclude_patterns,omitempty"`
+	MinSize         *int64   `yaml:"min_size" json:"min_size,omitempty"` // in bytes
+	KeepRecent      *int     `yaml:"keep_recent" json:"keep_recent,omitempty"`
+	ContentTypes    []string `yaml:"content_types" json:"content_types,omitempty"`
+	PathRegex       []string `yaml:"path_regex" json:"path_regex,omitempty"`
+
+	limit string // when set, only this directory of the path is cleaned, see CleanWithin
+}
+
+// outsideLimit reports whether path is neither inside the directory a
+// limited cleanup path is restricted to nor on the way down to it
+func (cp CleanupPath) outsideLimit(path string) bool {
+	return cp.limit != "" && !within(path, cp.limit) && !within(cp.limit, path)
+}
+
+// UnmarshalYAML accepts either a bare path string or a rule object
+func (cp *CleanupPath) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var path string
+	if err := unmarshal(&path); err == nil {
+		*cp = CleanupPath{Path: path}
+		return nil
+	}
+
+	type plain CleanupPath
+	return unmarshal((*plain)(cp))
+}
+
+// pathRules are the effective rules for one cleanup path
+type pathRules struct {
+	minAge     time.Duration
+	minSize    int64
+	excludes   []string
+	keepRecent int              // newest files per directory that are never cleaned
+	types      []string         // MIME type patterns a file's content must match
+	regexes    []*regexp.Regexp // one of which must match the full path, if any
+	before     time.Time        // files must be modified before this; zero for no limit
+	after      time.Time        // files must be modified at or after this; zero for no limit
+}
+
+// rulesFor resolves the rules of a cleanup path, falling back to the global
+// defaults for anything it leaves unset
+func (c *Config) rulesFor(cp CleanupPath) pathRules {
+	rules := pathRules{
+		minAge:     time.Duration(c.MinAgeDays) * 24 * time.Hour,
+		minSize:    c.MinSize,
+		excludes:   c.ExcludePatterns,
+		keepRecent: c.KeepRecent,
+		types:      c.ContentTypes,
+		regexes:    c.compiledRegex(c.PathRegex),
+	}
+	if cp.MinAgeDays != nil {
+		rules.minAge = time.Duration(*cp.MinAgeDays) * 24 * time.Hour
+	}
+	if cp.MinSize != nil {
+		rules.minSize = *cp.MinSize
+	}
+	if cp.ExcludePatterns != nil {
+		rules.excludes = cp.ExcludePatterns
+	}
+	if cp.KeepRecent != nil {
+		rules.keepRecent = *cp.KeepRecent
+	}
+	if cp.ContentTypes != nil {
+		rules.types = cp.ContentTypes
+	}
+	if cp.PathRegex != nil {
+		rules.regexes = c.compiledRegex(cp.PathRegex)
+	}
+	return rules
+}
+
+// excluded reports whether the base name of path matches an exclude pattern
+func (r pathRules) excluded(path string) bool {
+	name := filepath.Base(path)
+	for _, pattern := range r.excludes {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allows reports whether a file is old and large enough to be cleaned
+func (r pathRules) allows(info fs.FileInfo, now time.Time) bool {
+	if info.Size() < r.minSize {
+		return false
+	}
+	if !r.before.IsZero() && !info.ModTime().Before(r.before) {
+		return false
+	}
+	if !r.after.IsZero() && info.ModTime().Before(r.after) {
+		return false
+	}
+	return now.Sub(info.ModTime()) >= r.minAge
+}
+
+// walkJunk calls fn for every file under a cleanup path that passes its rules.
+// Errors below the root are logged and skipped; a root error is returned.
+func (sc *SystemCleaner) walkJunk(cp CleanupPath, fn func(path string, info fs.FileInfo)) error {
+	return sc.walkJunkWith(cp, walkOptions{}, fn)
+}
+
+// walkOptions adjust a walkJunkWith walk; the zero value is a plain walkJunk
+type walkOptions struct {
+	ctx     context.Context                                      // gives up with ErrTimeout once done
+	skip    func(dir string) bool                                // directories to leave out
+	kept    func(path string)                                    // called for files protected by keep_recent
+	sniffed func(path, mimeType string)                          // called with the type of files passing content_types
+	matched func(path string, re *regexp.Regexp, match []string) // called for files matching path_regex
+	empty   func(path string)                                    // called for zero-byte junk, also when SetEmptyFiles leaves it out
+	ruled   func(path string, info fs.FileInfo, rule *junkRule)  // called for every file a rules_file rule decides
+}
+
+// walkJunkWith is walkJunk with extra options
+func (sc *SystemCleaner) walkJunkWith(cp CleanupPath, opts walkOptions, fn func(path string, info fs.FileInfo)) error {
+	if sc.skipFilePath(cp.Path) {
+		return nil
+	}
+	rules := sc.config.rulesFor(cp)
+	if sc.config.OnlyBeforeLastClean && sc.state != nil {
+		rules.before = sc.state.lastCleanOf(cp.Path)
+	}
+	rules.after = sc.sinceBoot
+	now := time.Now()
+	ctx := opts.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	recent := make(map[string]bool)
+	own := sc.ownFiles[cp.Path]
+	kept := sc.keep.keepChecker(cp.Path)
+
+	return sc.walkContext(ctx, cp.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == cp.Path {
+				return err
+			}
+			sc.logger.Printf("Error accessing path %s: %v", path, err)
+			sc.emit(Event{Type: EventError, Path: path, Reason: "cannot read", Err: err})
+			return nil
+		}
+		if cp.outsideLimit(path) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if own[path] || (path != cp.Path && rules.excluded(path)) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path != cp.Path && opts.skip != nil && opts.skip(path) {
+				return filepath.SkipDir
+			}
+			if rules.keepRecent > 0 {
+				sc.markRecent(path, rules.keepRecent, recent)
+			}
+			return nil
+		}
+		if recent[path] {
+			if opts.kept != nil {
+				opts.kept(path)
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			sc.logger.Printf("Error accessing path %s: %v", path, err)
+			sc.emit(Event{Type: EventError, Path: path, Reason: "cannot read file info", Err: err})
+			return nil
+		}
+		if !rules.allows(info, now) {
+			return nil
+		}
+		if info.Size() == 0 && opts.empty != nil {
+			opts.empty(path)
+		}
+		if sc.leftOutBySize(info) {
+			return nil
+		}
+		if len(rules.regexes) > 0 {
+			re, match := rules.matchRegex(path)
+			if re == nil {
+				return nil
+			}
+			if opts.matched != nil {
+				opts.matched(path, re, match)
+			}
+		}
+		if len(rules.types) > 0 {
+			// sniffing reads the file, so it only runs once the cheap rules pass
+			mimeType, err := sniffType(path)
+			if err != nil {
+				sc.logger.Printf("Error reading file %s: %v", path, err)
+				sc.emit(Event{Type: EventError, Path: path, Reason: "cannot sniff content type", Err: err})
+				return nil
+			}
+			if !matchesType(mimeType, rules.types) {
+				return nil
+			}
+			if opts.sniffed != nil {
+				opts.sniffed(path, mimeType)
+			}
+		}
+		if sc.protected(kept, path) {
+			return nil
+		}
+		if len(sc.config.junkRules) > 0 {
+			rule, err := sc.config.ruleFor(cp.Path, path, info, now)
+			if err != nil {
+				sc.logger.Printf("Error reading file %s: %v", path, err)
+				sc.emit(Event{Type: EventError, Path: path, Reason: "cannot evaluate rule", Err: err})
+				return nil
+			}
+			if rule == nil {
+				return nil
+			}
+			if opts.ruled != nil {
+				opts.ruled(path, info, rule)
+			}
+			if rule.Action == ruleReport || rule.Action == ruleKeep {
+				return nil
+			}
+		}
+		fn(path, info)
+		return nil
+	})
+}
+
+// markRecent adds the n most recently modified files directly inside dir to
+// recent, so that they survive the clean whatever the other rules say
+func (sc *SystemCleaner) markRecent(dir string, n int, recent map[string]bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		sc.logger.Printf("Error reading directory %s: %v", dir, err)
+		sc.emit(Event{Type: EventError, Path: dir, Reason: "cannot read", Err: err})
+		return
+	}
+
+	var files []FileInfo
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || entry.Name() == ignoreFileName {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, FileInfo{Path: filepath.Join(dir, entry.Name()), ModTime: info.ModTime()})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime.After(files[j].ModTime)
+	})
+	for i := 0; i < n && i < len(files); i++ {
+		recent[files[i].Path] = true
+	}
+}
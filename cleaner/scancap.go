@@ -0,0 +1,48 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// errScanCap stops a scan walk that has examined max-files entries
+var errScanCap = errors.New("scan entry cap reached")
+
+// SetMaxFiles caps the entries (files and directories) a large-file or
+// oldest-file scan examines before it stops with partial results; 0 means no cap
+func (sc *SystemCleaner) SetMaxFiles(n int) {
+	sc.maxFiles = n
+}
+
+// capped wraps the walk function of a scan so that the walk stops with
+// errScanCap once it has examined the configured number of entries
+func (sc *SystemCleaner) capped(fn fs.WalkDirFunc) fs.WalkDirFunc {
+	if sc.maxFiles <= 0 {
+		return fn
+	}
+	var seen int
+	return func(path string, d fs.DirEntry, err error) error {
+		seen++
+		if seen > sc.maxFiles {
+			return errScanCap
+		}
+		return fn(path, d, err)
+	}
+}
+
+// checkTruncated turns errScanCap into a warning that the scan of directory
+// is incomplete; it reports whether truncation happened
+func (sc *SystemCleaner) checkTruncated(directory string, err *error) bool {
+	if !errors.Is(*err, errScanCap) {
+		return false
+	}
+	*err = nil
+	sc.logger.Printf("Scan of %s truncated after %d entries (max-files); results are incomplete", directory, sc.maxFiles)
+	return true
+}
+
+// warnTruncated tells the user that the results just shown are partial
+func (sc *SystemCleaner) warnTruncated() {
+	fmt.Fprintf(sc.msg, "⚠️  Scan stopped after examining %d entries (-max-files): the results are incomplete\n", sc.maxFiles)
+}
@@ -0,0 +1,72 @@
+package cleaner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// deleteScript writes the deletions of a dry run as a shell script
+type deleteScript struct {
+	mu     sync.Mutex // delete workers write concurrently
+	w      io.Writer
+	files  int
+	err    error // the first write error; later writes are skipped
+	warned bool  // err has been logged
+}
+
+// SetScriptOut makes cleans write everything they would delete to w as a
+// shell script of rm -f commands, and delete nothing. It implies a dry run.
+// Errors writing to w are logged once; buffer w and check its flush to catch
+// them. nil turns the script off again.
+func (sc *SystemCleaner) SetScriptOut(w io.Writer) {
+	if w == nil {
+		sc.script = nil
+		return
+	}
+	sc.script = &deleteScript{w: w}
+	sc.dryRun = true
+	sc.script.printf("#!/bin/sh\n# Files cleanpc would delete, listed on %s.\n# Review and edit before running it with sh.\n\n",
+		time.Now().Format("2006-01-02 15:04:05"))
+}
+
+// ScriptFiles returns how many rm commands the script of SetScriptOut holds
+func (sc *SystemCleaner) ScriptFiles() int {
+	if sc.script == nil {
+		return 0
+	}
+	sc.script.mu.Lock()
+	defer sc.script.mu.Unlock()
+	return sc.script.files
+}
+
+// scriptDelete adds the rm command for a file to the script, if there is one.
+// note is written as a comment line above it.
+func (sc *SystemCleaner) scriptDelete(path string, size int64, note string) {
+	s := sc.script
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if note != "" {
+		// a file name in the note may hold a newline, which would end the comment
+		s.printf("# %s\n", strings.ReplaceAll(note, "\n", "\\n"))
+	}
+	s.printf("rm -f -- %s # %s\n", shellQuote(path), sc.FormatSize(size))
+	if s.err == nil {
+		s.files++
+	} else if !s.warned {
+		sc.logger.Printf("Error writing deletion script: %v", s.err)
+		s.warned = true
+	}
+}
+
+// printf writes to the script unless a write has failed before
+func (s *deleteScript) printf(format string, args ...any) {
+	if s.err == nil {
+		_, s.err = fmt.Fprintf(s.w, format, args...)
+	}
+}
@@ -0,0 +1,326 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setupCategory is a kind of junk the setup wizard offers
+type setupCategory struct {
+	name        string
+	description string
+	on          bool // the default answer
+}
+
+// setupCategories are offered in this order; knownLocations and
+// browserLocations name theirs in Category
+var setupCategories = []setupCategory{
+	{"browser", "Browser caches", true},
+	{"cache", "Application caches, logs and thumbnails", true},
+	{"temp", "Temporary files", true},
+	{"crash", "Crash reports", true},
+	{"trash", "Trash", false},
+	{"dev", "Developer build and package caches", false},
+	{"downloads", "Old files in Downloads", false},
+}
+
+// browserLocations are the browser caches of each GOOS. Unlike
+// knownLocations they may lie inside another location, such as
+// ~/Library/Caches; setup then keeps only the outer one.
+var browserLocations = map[string][]knownLocation{
+	"darwin": {
+		{"~/Library/Caches/Google/Chrome", "Chrome cache", "browser"},
+		{"~/Library/Caches/Firefox", "Firefox cache", "browser"},
+		{"~/Library/Caches/com.apple.Safari", "Safari cache", "browser"},
+		{"~/Library/Caches/com.microsoft.edgemac", "Edge cache", "browser"},
+	},
+	"linux": {
+		{"~/.cache/mozilla", "Firefox cache", "browser"},
+		{"~/.cache/google-chrome", "Chrome cache", "browser"},
+		{"~/.cache/chromium", "Chromium cache", "browser"},
+		{"~/.cache/BraveSoftware", "Brave cache", "browser"},
+		{"~/.cache/microsoft-edge", "Edge cache", "browser"},
+	},
+	"freebsd": {
+		{"~/.cache/mozilla", "Firefox cache", "browser"},
+		{"~/.cache/chromium", "Chromium cache", "browser"},
+	},
+	"windows": {
+		{"${LOCALAPPDATA}/Google/Chrome/User Data/Default/Cache", "Chrome cache", "browser"},
+		{"${LOCALAPPDATA}/Microsoft/Edge/User Data/Default/Cache", "Edge cache", "browser"},
+		{"${LOCALAPPDATA}/Mozilla/Firefox/Profiles", "Firefox cache", "browser"},
+	},
+}
+
+// downloadsLocation is the only location of the downloads category; its
+// files are junk after their own retention instead of min_age_days
+var downloadsLocation = knownLocation{"~/Downloads", "Downloads", "downloads"}
+
+// SetupOptions answers questions of Setup ahead of time, for scripted
+// setups. Questions left nil are asked, or get their default when there is
+// no prompter.
+type SetupOptions struct {
+	Categories    []string // browser, cache, temp, crash, trash, dev or downloads
+	MinAgeDays    *int
+	MinSize       string // e.g. "4KB"; "" asks
+	DownloadsDays *int   // age in days after which downloads are junk
+	Force         bool   // replace an existing config file without asking
+}
+
+// setupEntry is a cleanup path chosen in the wizard
+type setupEntry struct {
+	path        string
+	description string
+	minAgeDays  *int // only set for downloads
+}
+
+// Setup is a first-run wizard: it asks which kinds of junk to clean, finds
+// their directories on this OS, asks for the age and size thresholds, shows
+// the paths with their junk sizes and writes a commented config to path.
+// Questions go through the prompter; see SetupOptions to answer them ahead.
+func (sc *SystemCleaner) Setup(path string, opts SetupOptions) error {
+	if path == "" || path == "-" || isConfigURL(path) {
+		return fmt.Errorf("setup writes the config to a file, %q is not one", path)
+	}
+	if _, err := os.Stat(path); err == nil && !opts.Force {
+		if !sc.setupConfirm(fmt.Sprintf("%s already exists. Replace it?", path), false) {
+			return fmt.Errorf("%s already exists; pass -force to replace it", path)
+		}
+	}
+	var givenSize *int64
+	if opts.MinSize != "" {
+		size, err := parseSize(opts.MinSize)
+		if err != nil {
+			return fmt.Errorf("min size: %w", err)
+		}
+		givenSize = &size
+	}
+	chosen, err := sc.chooseCategories(opts.Categories)
+	if err != nil {
+		return err
+	}
+
+	minAge := sc.setupInt(opts.MinAgeDays, "⏳ Only treat files as junk once unmodified for how many days?", 7)
+	minSize := sc.setupSize(givenSize, "📏 Leave files smaller than what alone (e.g. 4KB, 0 for none)?", 0)
+	var entries []setupEntry
+	for _, cat := range setupCategories {
+		if !chosen[cat.name] {
+			continue
+		}
+		for _, loc := range setupLocations(cat.name) {
+			p, ok := findLocation(loc)
+			if !ok {
+				continue
+			}
+			entry := setupEntry{path: p, description: loc.Description}
+			if cat.name == "downloads" {
+				days := sc.setupInt(opts.DownloadsDays, "📥 Clean downloads older than how many days?", 30)
+				entry.minAgeDays = &days
+			}
+			entries = append(entries, entry)
+		}
+	}
+	entries = outermostEntries(entries)
+	if len(entries) == 0 {
+		return fmt.Errorf("none of the chosen kinds of junk has a directory on this system")
+	}
+
+	fmt.Fprintln(sc.msg, "\n🔍 Measuring the chosen directories...")
+	var total int64
+	for _, e := range entries {
+		cp := CleanupPath{Path: e.path, MinAgeDays: &minAge, MinSize: &minSize}
+		if e.minAgeDays != nil {
+			cp.MinAgeDays = e.minAgeDays
+		}
+		size, err := sc.getDirSize(cp)
+		if err != nil {
+			sc.logger.Printf("Error scanning directory %s: %v", e.path, err)
+		}
+		total += size
+		fmt.Fprintf(sc.out, "📂 %s → %s  %s\n", e.path, sc.FormatSize(size), e.description)
+	}
+	fmt.Fprintf(sc.out, "🧹 %d cleanup paths, %s of junk today\n", len(entries), sc.FormatSize(total))
+
+	if !sc.setupConfirm(fmt.Sprintf("Write this config to %s?", path), true) {
+		fmt.Fprintln(sc.msg, "ℹ️  Nothing written")
+		return nil
+	}
+	data := setupYAML(entries, minAge, minSize)
+	if _, err := parseConfig(path, data); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	sc.logger.Printf("Setup wrote %s with %d cleanup paths", path, len(entries))
+	fmt.Fprintf(sc.msg, "\n✅ Wrote %s. Run with -dry-run clean to see what a clean would delete.\n", path)
+	return nil
+}
+
+// setupLocations returns the locations of a category on this OS
+func setupLocations(category string) []knownLocation {
+	if category == "downloads" {
+		return []knownLocation{downloadsLocation}
+	}
+	var locs []knownLocation
+	for _, loc := range append(browserLocations[runtime.GOOS], knownLocations[runtime.GOOS]...) {
+		if loc.Category == category {
+			locs = append(locs, loc)
+		}
+	}
+	return locs
+}
+
+// chooseCategories returns the chosen categories: those named, or else the
+// answers to one question per category that has a directory here
+func (sc *SystemCleaner) chooseCategories(names []string) (map[string]bool, error) {
+	chosen := make(map[string]bool)
+	if len(names) > 0 {
+		known := make(map[string]bool)
+		var all []string
+		for _, cat := range setupCategories {
+			known[cat.name] = true
+			all = append(all, cat.name)
+		}
+		for _, name := range names {
+			if !known[name] {
+				return nil, fmt.Errorf("unknown category %q (want %s)", name, strings.Join(all, ", "))
+			}
+			chosen[name] = true
+		}
+		return chosen, nil
+	}
+
+	fmt.Fprintf(sc.msg, "\n🧭 Setting up the cleaner for %s. Press Enter to take the answer in capitals.\n", runtime.GOOS)
+	for _, cat := range setupCategories {
+		var found []string
+		for _, loc := range setupLocations(cat.name) {
+			if p, ok := findLocation(loc); ok {
+				found = append(found, p)
+			}
+		}
+		if len(found) == 0 {
+			fmt.Fprintf(sc.msg, "ℹ️  %s: none found here\n", cat.description)
+			continue
+		}
+		fmt.Fprintf(sc.msg, "\n📂 %s: %s\n", cat.description, strings.Join(found, ", "))
+		chosen[cat.name] = sc.setupConfirm("Clean them?", cat.on)
+	}
+	return chosen, nil
+}
+
+// setupAsk asks the prompter; with no prompter, no input or an empty answer
+// it returns def
+func (sc *SystemCleaner) setupAsk(question, def string) string {
+	if sc.prompter == nil {
+		return def
+	}
+	answer, ok := sc.prompter.Ask(fmt.Sprintf("%s [%s]: ", question, def))
+	if !ok || answer == "" {
+		return def
+	}
+	return answer
+}
+
+// setupConfirm asks a yes/no question whose answer is def by default
+func (sc *SystemCleaner) setupConfirm(question string, def bool) bool {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		switch strings.ToLower(sc.setupAsk(question, hint)) {
+		case "y/n":
+			return def
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+		fmt.Fprintln(sc.msg, "❌ Please answer yes or no")
+	}
+}
+
+// setupInt returns the answer given ahead, or asks for a number of days
+func (sc *SystemCleaner) setupInt(given *int, question string, def int) int {
+	if given != nil {
+		return *given
+	}
+	for {
+		n, err := strconv.Atoi(sc.setupAsk(question, strconv.Itoa(def)))
+		if err == nil && n >= 0 {
+			return n
+		}
+		fmt.Fprintln(sc.msg, "❌ Please enter a whole number of days")
+	}
+}
+
+// setupSize returns the answer given ahead, or asks for a size
+func (sc *SystemCleaner) setupSize(given *int64, question string, def int64) int64 {
+	if given != nil {
+		return *given
+	}
+	for {
+		n, err := parseSize(sc.setupAsk(question, strconv.FormatInt(def, 10)))
+		if err == nil {
+			return n
+		}
+		fmt.Fprintln(sc.msg, "❌", err)
+	}
+}
+
+// outermostEntries drops the entries that lie inside another one, so no file
+// is counted twice
+func outermostEntries(entries []setupEntry) []setupEntry {
+	var kept []setupEntry
+	for i, e := range entries {
+		inside := false
+		for j, other := range entries {
+			if i != j && e.path != other.path && within(e.path, other.path) {
+				inside = true
+				break
+			}
+			if j < i && e.path == other.path {
+				inside = true // a duplicate
+				break
+			}
+		}
+		if !inside {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// setupYAML renders the config written by Setup
+func setupYAML(entries []setupEntry, minAge int, minSize int64) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Written by cleanpc setup on %s. Every option is described in the\n", time.Now().Format("2006-01-02"))
+	b.WriteString("# README; run cleanpc -dry-run clean to see what a clean would delete.\n\n")
+	b.WriteString("# Directories whose junk files are cleaned\n")
+	b.WriteString("cleanup_paths:\n")
+	for _, e := range entries {
+		if e.minAgeDays != nil {
+			fmt.Fprintf(&b, "  - path: %q # %s\n", filepath.ToSlash(e.path), e.description)
+			fmt.Fprintf(&b, "    min_age_days: %d # downloads are kept this long instead\n", *e.minAgeDays)
+			continue
+		}
+		fmt.Fprintf(&b, "  - %q # %s\n", filepath.ToSlash(e.path), e.description)
+	}
+	b.WriteString("\n# A file is junk once it has not been modified for this many days\n")
+	fmt.Fprintf(&b, "min_age_days: %d\n", minAge)
+	b.WriteString("\n# Files smaller than this many bytes are left alone (0 = none)\n")
+	fmt.Fprintf(&b, "min_size: %d\n", minSize)
+	b.WriteString("\n# Refuse a clean that would delete more files than this without asking again\n")
+	b.WriteString("max_delete_files: 10000\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,30 @@
+package cleaner
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shirou/gopsutil/host"
+)
+
+// SetSinceBoot limits junk to files modified since the system last booted,
+// the leftovers of the current session, and returns that boot time. The age
+// rules still apply on top, so min_age_days has to be shorter than the
+// uptime for anything to be left.
+func (sc *SystemCleaner) SetSinceBoot() (time.Time, error) {
+	secs, err := host.BootTime()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot read the boot time: %w", err)
+	}
+	boot := time.Unix(int64(secs), 0)
+	sc.sinceBoot = boot
+	sc.logger.Printf("Only cleaning files modified since boot at %s", boot.Format(time.RFC3339))
+
+	minAge := time.Duration(sc.config.MinAgeDays) * 24 * time.Hour
+	if uptime := time.Since(boot); minAge > uptime {
+		sc.logger.Printf("min_age_days %d is longer than the uptime %s", sc.config.MinAgeDays, uptime.Round(time.Minute))
+		fmt.Fprintf(sc.msg, "⚠️  min_age_days (%d) is longer than the uptime (%s), so only paths with a shorter min_age_days have junk\n",
+			sc.config.MinAgeDays, uptime.Round(time.Minute))
+	}
+	return boot, nil
+}
@@ -0,0 +1,156 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"time"
+)
+
+// ScanSnapshot records every file of a directory at one point in time
+type ScanSnapshot struct {
+	Directory string     `json:"directory"`
+	Created   time.Time  `json:"created"`
+	Files     []FileInfo `json:"files"`
+}
+
+// FileChange describes how a file differs between two scans
+type FileChange struct {
+	Path    string `json:"path"`
+	Change  string `json:"change"` // "added", "removed", "grown" or "shrunk"
+	OldSize int64  `json:"old_size"`
+	NewSize int64  `json:"new_size"`
+	Delta   int64  `json:"delta"`
+}
+
+// takeSnapshot records the size and modification time of every file in directory
+func (sc *SystemCleaner) takeSnapshot(directory string) (*ScanSnapshot, error) {
+	snapshot := &ScanSnapshot{Directory: directory, Created: time.Now()}
+	err := sc.walk(directory, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			sc.logger.Printf("Error accessing path %s: %v", path, err)
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			sc.logger.Printf("Error accessing path %s: %v", path, err)
+			return nil
+		}
+		snapshot.Files = append(snapshot.Files, FileInfo{Path: path, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, &ScanError{Path: directory, Err: err}
+	}
+	return snapshot, nil
+}
+
+// writeSnapshot saves a scan as a JSON baseline at path
+func (sc *SystemCleaner) writeSnapshot(snapshot *ScanSnapshot, path string) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save scan: %w", err)
+	}
+
+	fmt.Fprintf(sc.msg, "💾 Saved scan of %d files to %s\n", len(snapshot.Files), path)
+	return nil
+}
+
+// diffSnapshots lists added, removed, grown and shrunk files, biggest change first
+func diffSnapshots(before, after *ScanSnapshot) []FileChange {
+	old := make(map[string]int64, len(before.Files))
+	for _, f := range before.Files {
+		old[f.Path] = f.Size
+	}
+
+	var changes []FileChange
+	for _, f := range after.Files {
+		size, ok := old[f.Path]
+		delete(old, f.Path)
+		switch {
+		case !ok:
+			changes = append(changes, FileChange{Path: f.Path, Change: "added", NewSize: f.Size, Delta: f.Size})
+		case f.Size > size:
+			changes = append(changes, FileChange{Path: f.Path, Change: "grown", OldSize: size, NewSize: f.Size, Delta: f.Size - size})
+		case f.Size < size:
+			changes = append(changes, FileChange{Path: f.Path, Change: "shrunk", OldSize: size, NewSize: f.Size, Delta: f.Size - size})
+		}
+	}
+	for path, size := range old {
+		changes = append(changes, FileChange{Path: path, Change: "removed", OldSize: size, Delta: -size})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i].Delta, changes[j].Delta
+		if a < 0 {
+			a = -a
+		}
+		if b < 0 {
+			b = -b
+		}
+		return a > b
+	})
+	return changes
+}
+
+// signedSize formats a size change with an explicit sign
+func signedSize(sc *SystemCleaner, delta int64) string {
+	if delta >= 0 {
+		return "+" + sc.FormatSize(delta)
+	}
+	return sc.FormatSize(delta)
+}
+
+// CompareScan scans directory and reports what changed since the baseline
+// saved at baselinePath. When savePath is set, the new scan is saved there as
+// the next baseline.
+func (sc *SystemCleaner) CompareScan(directory, baselinePath, savePath string) error {
+	data, err := os.ReadFile(baselinePath)
+	if err != nil {
+		return fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var baseline ScanSnapshot
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return fmt.Errorf("failed to parse baseline %s: %w", baselinePath, err)
+	}
+
+	fmt.Fprintf(sc.msg, "\n🔬 Comparing %s with the scan from %s\n", directory, baseline.Created.Format(time.RFC3339))
+	current, err := sc.takeSnapshot(directory)
+	if err != nil {
+		return err
+	}
+	changes := diffSnapshots(&baseline, current)
+	if savePath != "" {
+		if err := sc.writeSnapshot(current, savePath); err != nil {
+			return err
+		}
+	}
+
+	if sc.jsonOutput {
+		encoder := json.NewEncoder(sc.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(changes)
+	}
+
+	if len(changes) == 0 {
+		fmt.Fprintln(sc.out, "✅ No changes since the baseline")
+		return nil
+	}
+
+	icons := map[string]string{"added": "➕", "removed": "➖", "grown": "📈", "shrunk": "📉"}
+	var net int64
+	for _, c := range changes {
+		net += c.Delta
+		fmt.Fprintf(sc.out, "%s %-7s %s (%s)\n", icons[c.Change], c.Change, c.Path, signedSize(sc, c.Delta))
+	}
+	fmt.Fprintf(sc.out, "\n📊 %d files changed, net %s\n", len(changes), signedSize(sc, net))
+	return nil
+}
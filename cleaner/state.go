@@ -0,0 +1,76 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// stateFile is the on-disk form of the run state
+type stateFile struct {
+	LastClean map[string]time.Time `json:"last_clean"` // start of the last successful clean of each cleanup path
+}
+
+// runState remembers facts about earlier runs in the configured state_file
+type runState struct {
+	mu        sync.Mutex
+	file      string
+	lastClean map[string]time.Time
+}
+
+// loadState reads the state file; a missing or unset file is an empty state
+func loadState(file string) (*runState, error) {
+	state := &runState{file: file, lastClean: make(map[string]time.Time)}
+	if file == "" {
+		return state, nil
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	var saved stateFile
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return state, err
+	}
+	for path, t := range saved.LastClean {
+		state.lastClean[path] = t
+	}
+	return state, nil
+}
+
+// lastCleanOf returns when path was last cleaned successfully, or the zero
+// time if never
+func (s *runState) lastCleanOf(path string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastClean[path]
+}
+
+// recordClean stores started as the last successful clean of paths and writes
+// the state file; without a state_file nothing is recorded
+func (s *runState) recordClean(paths []string, started time.Time) error {
+	if s.file == "" || len(paths) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, path := range paths {
+		s.lastClean[path] = started
+	}
+
+	data, err := json.MarshalIndent(stateFile{LastClean: s.lastClean}, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write then rename so a crash never leaves a truncated state file
+	tmp := s.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.file)
+}
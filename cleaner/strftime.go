@@ -0,0 +1,48 @@
+package cleaner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// strftimeVerbs are the placeholders strftime understands, besides %%
+var strftimeVerbs = map[byte]func(t time.Time) string{
+	'Y': func(t time.Time) string { return t.Format("2006") },
+	'y': func(t time.Time) string { return t.Format("06") },
+	'm': func(t time.Time) string { return t.Format("01") },
+	'd': func(t time.Time) string { return t.Format("02") },
+	'H': func(t time.Time) string { return t.Format("15") },
+	'M': func(t time.Time) string { return t.Format("04") },
+	'S': func(t time.Time) string { return t.Format("05") },
+	'j': func(t time.Time) string { return fmt.Sprintf("%03d", t.YearDay()) },
+	'F': func(t time.Time) string { return t.Format("2006-01-02") },
+	's': func(t time.Time) string { return strconv.FormatInt(t.Unix(), 10) },
+}
+
+// strftime expands the strftime-style placeholders of layout with t, e.g.
+// "clean-%Y%m%d.log" to "clean-20240131.log"; an unknown placeholder is an error
+func strftime(layout string, t time.Time) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' {
+			b.WriteByte(layout[i])
+			continue
+		}
+		if i+1 == len(layout) {
+			return "", fmt.Errorf("%q ends with a lone %%", layout)
+		}
+		i++
+		if layout[i] == '%' {
+			b.WriteByte('%')
+			continue
+		}
+		verb, ok := strftimeVerbs[layout[i]]
+		if !ok {
+			return "", fmt.Errorf("unknown placeholder %%%c in %q (want %%Y, %%y, %%m, %%d, %%H, %%M, %%S, %%j, %%F, %%s or %%%%)", layout[i], layout)
+		}
+		b.WriteString(verb(t))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,183 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// knownLocation is a well-known junk directory; Path may start with ~ and
+// use ${VAR} environment references. Category is the setupCategories entry
+// it belongs to.
+type knownLocation struct {
+	Path        string
+	Description string
+	Category    string
+}
+
+// knownLocations lists the junk directories the suggest command looks at, per
+// GOOS. Entries do not nest, so their sizes never count the same file twice.
+var knownLocations = map[string][]knownLocation{
+	"darwin": {
+		{"~/Library/Caches", "Application caches", "cache"},
+		{"~/Library/Logs", "Application logs", "cache"},
+		{"~/.Trash", "Trash", "trash"},
+		{"~/Library/Developer/Xcode/DerivedData", "Xcode build products", "dev"},
+		{"~/Library/Application Support/CrashReporter", "Crash reports", "crash"},
+		{"${TMPDIR}", "Per-user temporary files", "temp"},
+		{"/private/var/tmp", "Temporary files kept across reboots", "temp"},
+	},
+	"linux": {
+		{"~/.cache/thumbnails", "Thumbnail cache", "cache"},
+		{"~/.thumbnails", "Thumbnail cache (old location)", "cache"},
+		{"~/.local/share/Trash/files", "Trash", "trash"},
+		{"~/.cache/pip", "pip download cache", "dev"},
+		{"~/.cache/go-build", "Go build cache", "dev"},
+		{"~/.npm/_cacache", "npm cache", "dev"},
+		{"/tmp", "Temporary files", "temp"},
+		{"/var/tmp", "Temporary files kept across reboots", "temp"},
+		{"/var/crash", "Crash reports", "crash"},
+	},
+	"freebsd": {
+		{"~/.cache/thumbnails", "Thumbnail cache", "cache"},
+		{"~/.local/share/Trash/files", "Trash", "trash"},
+		{"~/.cache/pip", "pip download cache", "dev"},
+		{"~/.cache/go-build", "Go build cache", "dev"},
+		{"/tmp", "Temporary files", "temp"},
+		{"/var/tmp", "Temporary files kept across reboots", "temp"},
+	},
+	"windows": {
+		{"${TEMP}", "Per-user temporary files", "temp"},
+		{"${LOCALAPPDATA}/Microsoft/Windows/INetCache", "Internet cache", "browser"},
+		{"${LOCALAPPDATA}/CrashDumps", "Crash dumps", "crash"},
+		{"${LOCALAPPDATA}/go-build", "Go build cache", "dev"},
+		{"${SystemRoot}/Temp", "System temporary files", "temp"},
+	},
+}
+
+// Suggestion is an existing known location with its junk size
+type Suggestion struct {
+	Path        string `json:"path"`
+	Description string `json:"description"`
+	Size        int64  `json:"size"`
+	Configured  bool   `json:"configured"` // already in cleanup_paths
+}
+
+// resolveLocation expands ~ and environment references in a known location;
+// it returns false when a referenced variable is unset
+func resolveLocation(path string) (string, bool) {
+	ok := true
+	path = os.Expand(path, func(name string) string {
+		value := os.Getenv(name)
+		if value == "" {
+			ok = false
+		}
+		return value
+	})
+	return filepath.Clean(filepath.FromSlash(expandHome(path))), ok
+}
+
+// findLocation resolves a known location and reports whether it is an
+// existing directory
+func findLocation(loc knownLocation) (string, bool) {
+	path, ok := resolveLocation(loc.Path)
+	if !ok {
+		return "", false
+	}
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return "", false
+	}
+	return path, true
+}
+
+// suggestions measures the known locations of this OS that exist, largest first
+func (sc *SystemCleaner) suggestions() []Suggestion {
+	configured := make(map[string]bool)
+	for _, dir := range sc.config.cleanupDirs() {
+		configured[filepath.Clean(expandHome(dir))] = true
+	}
+
+	var found []Suggestion
+	for _, loc := range knownLocations[runtime.GOOS] {
+		path, ok := findLocation(loc)
+		if !ok {
+			continue
+		}
+		size, err := sc.getDirSize(CleanupPath{Path: path})
+		if err != nil {
+			sc.logger.Printf("Error scanning directory %s: %v", path, err)
+		}
+		found = append(found, Suggestion{Path: path, Description: loc.Description, Size: size, Configured: configured[path]})
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].Size > found[j].Size })
+	return found
+}
+
+// Suggest reports the sizes of well-known junk locations and prints a
+// cleanup_paths snippet for the ones not configured yet. It deletes nothing.
+func (sc *SystemCleaner) Suggest() error {
+	return sc.SuggestInto("")
+}
+
+// SuggestInto is Suggest that adds the new locations to the cleanup_paths of
+// the config file at configPath instead of printing a snippet, keeping the
+// file's comments and formatting; "" only prints
+func (sc *SystemCleaner) SuggestInto(configPath string) error {
+	fmt.Fprintf(sc.msg, "\n🔍 Looking for known junk locations on %s...\n", runtime.GOOS)
+	found := sc.suggestions()
+
+	if sc.jsonOutput {
+		encoder := json.NewEncoder(sc.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(found)
+	}
+	if len(found) == 0 {
+		fmt.Fprintln(sc.msg, "ℹ️  None of the known locations exist here")
+		return nil
+	}
+
+	var snippet []Suggestion
+	for _, s := range found {
+		note := ""
+		if s.Configured {
+			note = " (already configured)"
+		} else if s.Size > 0 {
+			snippet = append(snippet, s)
+		}
+		fmt.Fprintf(sc.out, "📂 %s → %s  %s%s\n", s.Path, sc.FormatSize(s.Size), s.Description, note)
+	}
+
+	if len(snippet) == 0 {
+		fmt.Fprintln(sc.msg, "\n✅ Nothing new to suggest")
+		return nil
+	}
+	if configPath != "" {
+		paths := make([]string, len(snippet))
+		comments := make([]string, len(snippet))
+		for i, s := range snippet {
+			paths[i] = s.Path
+			comments[i] = fmt.Sprintf("%s, %s", s.Description, sc.FormatSize(s.Size))
+		}
+		err := editConfigFile(configPath, func(root *yaml.Node) error {
+			return appendCleanupPaths(root, paths, comments)
+		})
+		if err != nil {
+			return err
+		}
+		sc.logger.Printf("Added %d suggested cleanup paths to %s", len(paths), configPath)
+		fmt.Fprintf(sc.msg, "\n✅ Added %d cleanup paths to %s\n", len(paths), configPath)
+		return nil
+	}
+
+	fmt.Fprintln(sc.msg, "\n📝 Paste into your config:")
+	fmt.Fprintln(sc.out, "cleanup_paths:")
+	for _, s := range snippet {
+		fmt.Fprintf(sc.out, "  - %q # %s, %s\n", s.Path, s.Description, sc.FormatSize(s.Size))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package cleaner
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// SummarySchemaVersion is bumped whenever RunSummary changes incompatibly
+const SummarySchemaVersion = 1
+
+// OperationResult records the outcome of one operation of a run
+type OperationResult struct {
+	Name         string    `json:"name"`
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	Error        string    `json:"error,omitempty"`
+	FilesDeleted int       `json:"files_deleted,omitempty"`
+	BytesFreed   int64     `json:"bytes_freed,omitempty"`
+	NotDeleted   int       `json:"not_deleted,omitempty"` // verify_deletion failures
+	DiskFreed    int64     `json:"disk_freed,omitempty"`  // measured by check_freed_space
+}
+
+// RunSummary is the structured record of a whole run, written once at the end
+// for log ingestion
+type RunSummary struct {
+	SchemaVersion int               `json:"schema_version"`
+	Version       string            `json:"version"`
+	Host          string            `json:"host"`
+	Start         time.Time         `json:"start"`
+	End           time.Time         `json:"end"`
+	Config        *Config           `json:"config"`
+	Operations    []OperationResult `json:"operations"`
+	Errors        []string          `json:"errors"`
+}
+
+// NewRunSummary starts a summary for a run of the given tool version
+func NewRunSummary(version string, config *Config) *RunSummary {
+	host, _ := os.Hostname()
+	return &RunSummary{
+		SchemaVersion: SummarySchemaVersion,
+		Version:       version,
+		Host:          host,
+		Start:         time.Now(),
+		Config:        config,
+		Operations:    []OperationResult{},
+		Errors:        []string{},
+	}
+}
+
+// Record adds an operation that started at start and has just finished.
+// progress carries the deletion counters of clean operations and may be nil.
+func (s *RunSummary) Record(name string, start time.Time, err error, progress *Progress) {
+	result := OperationResult{Name: name, Start: start, End: time.Now()}
+	if err != nil {
+		result.Error = err.Error()
+		s.Errors = append(s.Errors, name+": "+err.Error())
+	}
+	if progress != nil {
+		result.FilesDeleted = progress.FilesDeleted
+		result.BytesFreed = progress.BytesFreed
+		result.NotDeleted = progress.NotDeleted
+		result.DiskFreed = progress.DiskFreed
+	}
+	s.Operations = append(s.Operations, result)
+}
+
+// Write stamps the end time and writes the summary as JSON to path
+func (s *RunSummary) Write(path string) error {
+	s.End = time.Now()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,155 @@
+package cleaner
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// PathUsage is the junk size of one cleanup path. With inode reporting on,
+// Files counts its junk files and InodeShare is their share of the used
+// inodes of the path's filesystem.
+type PathUsage struct {
+	Path       string  `json:"path"`
+	Size       int64   `json:"size"`
+	Network    bool    `json:"network"`
+	Files      int64   `json:"files,omitempty"`
+	InodeShare float64 `json:"inode_share,omitempty"`
+	InodeHeavy bool    `json:"inode_heavy,omitempty"`
+	OverLimit  bool    `json:"over_limit,omitempty"` // junk exceeds max_path_size
+}
+
+// RecipeUsage is the space a built-in cleanup recipe would reclaim
+type RecipeUsage struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Size        int64         `json:"size"`
+	Parts       []RecipeUsage `json:"parts,omitempty"` // per browser for the browser recipe
+}
+
+// JunkReport is the data behind ShowJunkUsage
+type JunkReport struct {
+	Paths       []PathUsage        `json:"paths"`
+	Recipes     []RecipeUsage      `json:"recipes"`
+	Total       int64              `json:"total"`
+	EmptyFiles  int64              `json:"empty_files"`           // zero-byte junk files, counted even when left out
+	Filesystems []FilesystemInodes `json:"filesystems,omitempty"` // with inode reporting on
+}
+
+// ScanReport is the data behind ScanLargeFiles
+type ScanReport struct {
+	Directory string
+	Files     []FileInfo
+}
+
+// MonitorSample is one reading of the system monitor; a disabled metric is -1
+// and GPUs stays empty unless GPU monitoring is on
+type MonitorSample struct {
+	Time       time.Time    `json:"time"`
+	CPUPercent float64      `json:"cpu_percent"`
+	CPUPerCore []float64    `json:"cpu_per_core,omitempty"` // with cpu_per_core
+	MemPercent float64      `json:"mem_percent"`
+	MemUsed    int64        `json:"mem_used"`
+	MemTotal   int64        `json:"mem_total"`
+	GPUs       []GPUSample  `json:"gpus,omitempty"`
+	Disks      []DiskSample `json:"disks,omitempty"` // one per filesystem holding cleanup paths
+}
+
+// Report is the value a -template is rendered with. Kind tells which of the
+// other fields is set: "junk", "scan" or "monitor".
+type Report struct {
+	Kind    string
+	Junk    *JunkReport
+	Scan    *ScanReport
+	Monitor *MonitorSample
+}
+
+// builtinTemplates can be selected by name instead of writing a template
+var builtinTemplates = map[string]string{
+	"csv": `{{- if eq .Kind "junk" -}}
+path,bytes
+{{range .Junk.Paths}}{{csv .Path}},{{.Size}}
+{{end}}{{range .Junk.Recipes}}{{csv .Name}},{{.Size}}
+{{end}}
+{{- else if eq .Kind "scan" -}}
+path,bytes,modified
+{{range .Scan.Files}}{{csv .Path}},{{.Size}},{{.ModTime.Format "2006-01-02T15:04:05Z07:00"}}
+{{end}}
+{{- else if eq .Kind "monitor" -}}
+{{.Monitor.Time.Format "2006-01-02T15:04:05Z07:00"}},{{printf "%.2f" .Monitor.CPUPercent}},{{printf "%.2f" .Monitor.MemPercent}}
+{{end}}`,
+	"markdown": `{{- if eq .Kind "junk" -}}
+| Path | Size |
+| --- | ---: |
+{{range .Junk.Paths}}| {{.Path}} | {{size .Size}} |
+{{end}}{{range .Junk.Recipes}}| {{.Description}} | {{size .Size}} |
+{{end}}| **Total** | **{{size .Junk.Total}}** |
+{{else if eq .Kind "scan" -}}
+### Largest files in {{.Scan.Directory}}
+
+| File | Size |
+| --- | ---: |
+{{range .Scan.Files}}| {{.Path}} | {{size .Size}} |
+{{end}}
+{{- else if eq .Kind "monitor" -}}
+- {{.Monitor.Time.Format "15:04:05"}} CPU {{printf "%.1f" .Monitor.CPUPercent}}%, RAM {{printf "%.1f" .Monitor.MemPercent}}%
+{{- range .Monitor.GPUs}}, GPU{{.Index}} {{printf "%.0f" .UtilPercent}}% ({{size .MemUsed}} of {{size .MemTotal}}){{end}}
+{{end}}`,
+}
+
+// csvField quotes a value for use as one CSV field
+func csvField(s string) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write([]string{s})
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// SetTemplate renders reports with a Go text/template instead of the default
+// format. spec is the name of a built-in template ("csv" or "markdown"), the
+// path of a template file, or the template text itself; "" restores the
+// default format.
+func (sc *SystemCleaner) SetTemplate(spec string) error {
+	if spec == "" {
+		sc.template = nil
+		return nil
+	}
+
+	text, ok := builtinTemplates[spec]
+	if !ok {
+		data, err := os.ReadFile(spec)
+		switch {
+		case err == nil:
+			text = string(data)
+		case errors.Is(err, os.ErrNotExist):
+			text = spec
+		default:
+			return err
+		}
+	}
+
+	tmpl, err := template.New("report").Funcs(template.FuncMap{
+		"size": sc.FormatSize,
+		"csv":  csvField,
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	sc.template = tmpl
+	return nil
+}
+
+// render writes a report through the template set with SetTemplate
+func (sc *SystemCleaner) render(report Report) error {
+	return sc.template.Execute(sc.out, report)
+}
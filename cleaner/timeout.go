@@ -0,0 +1,33 @@
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// runWithTimeout calls fn with a context that expires after timeout (0 means
+// never). A walk notices the deadline between entries; if fn is instead stuck
+// in a system call, e.g. on a hung network mount, it is abandoned and keeps
+// running in the background while runWithTimeout returns ErrTimeout.
+func runWithTimeout(timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(context.Background())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- fn(ctx) }()
+
+	select {
+	case err := <-done:
+		if err == ErrTimeout {
+			return fmt.Errorf("%w after %s", ErrTimeout, timeout)
+		}
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%w after %s", ErrTimeout, timeout)
+	}
+}
@@ -0,0 +1,62 @@
+package cleaner
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// bySize ranks larger files first
+func bySize(a, b FileInfo) bool { return a.Size > b.Size }
+
+// byAge ranks files modified longer ago first
+func byAge(a, b FileInfo) bool { return a.ModTime.Before(b.ModTime) }
+
+// fileHeap is a heap of files with the lowest ranked one on top
+type fileHeap struct {
+	files []FileInfo
+	above func(a, b FileInfo) bool
+}
+
+func (h fileHeap) Len() int           { return len(h.files) }
+func (h fileHeap) Less(i, j int) bool { return h.above(h.files[j], h.files[i]) }
+func (h fileHeap) Swap(i, j int)      { h.files[i], h.files[j] = h.files[j], h.files[i] }
+func (h *fileHeap) Push(x any)        { h.files = append(h.files, x.(FileInfo)) }
+func (h *fileHeap) Pop() any {
+	old := h.files
+	file := old[len(old)-1]
+	h.files = old[:len(old)-1]
+	return file
+}
+
+// topFiles keeps the n highest ranked files offered to it in O(n) memory;
+// with n <= 0 it keeps every file
+type topFiles struct {
+	n     int
+	files fileHeap
+}
+
+// newTopFiles keeps the n files ranked highest by above, e.g. bySize
+func newTopFiles(n int, above func(a, b FileInfo) bool) *topFiles {
+	return &topFiles{n: n, files: fileHeap{above: above}}
+}
+
+// add offers a file, evicting the lowest ranked kept file once n are held
+func (t *topFiles) add(file FileInfo) {
+	if t.n <= 0 || t.files.Len() < t.n {
+		heap.Push(&t.files, file)
+		return
+	}
+	if t.files.above(file, t.files.files[0]) {
+		t.files.files[0] = file
+		heap.Fix(&t.files, 0)
+	}
+}
+
+// sorted returns the kept files, highest ranked first
+func (t *topFiles) sorted() []FileInfo {
+	files := t.files.files
+	sort.Slice(files, func(i, j int) bool {
+		return t.files.above(files[i], files[j])
+	})
+	return files
+}
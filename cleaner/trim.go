@@ -0,0 +1,84 @@
+package cleaner
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/disk"
+)
+
+// errTrimUnsupported is returned where filesystems cannot be trimmed on
+// demand
+var errTrimUnsupported = errors.New("trimming is not supported on this platform")
+
+// SetTrim makes a clean discard the freed blocks of every filesystem it
+// deleted from, like fstrim, so SSDs can reuse them at full speed
+func (sc *SystemCleaner) SetTrim(enabled bool) {
+	sc.trim = enabled
+}
+
+// mountPointOf returns the mount point of the filesystem holding path: the
+// longest mount point that contains it
+func mountPointOf(path string, parts []disk.PartitionStat) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	best := ""
+	for _, p := range parts {
+		mp := p.Mountpoint
+		if len(mp) > len(best) && (path == mp || strings.HasPrefix(path, strings.TrimSuffix(mp, string(filepath.Separator))+string(filepath.Separator))) {
+			best = mp
+		}
+	}
+	return best
+}
+
+// trimCleaned trims the filesystems of the cleanup paths a clean deleted
+// from, or lists them in a dry run. Trimming is a finishing touch: a
+// filesystem that cannot be trimmed is reported and the run goes on.
+func (sc *SystemCleaner) trimCleaned() {
+	if !sc.trim {
+		return
+	}
+	parts, err := disk.Partitions(true)
+	if err != nil {
+		sc.logger.Printf("Error listing mount points for trimming: %v", err)
+		fmt.Fprintln(sc.msg, "⚠️  Could not trim: cannot list mount points:", err)
+		return
+	}
+	seen := make(map[string]bool)
+	var mounts []string
+	for path, t := range sc.progress.pathTotals() {
+		if t.files == 0 {
+			continue
+		}
+		if mp := mountPointOf(path, parts); mp != "" && !seen[mp] {
+			seen[mp] = true
+			mounts = append(mounts, mp)
+		}
+	}
+	sort.Strings(mounts)
+
+	for _, mp := range mounts {
+		if supported, known := discardSupported(mp); known && !supported {
+			sc.logger.Printf("Not trimming %s: its device does not support discard", mp)
+			fmt.Fprintf(sc.msg, "⚠️  Not trimming %s: its device does not support discard\n", mp)
+			continue
+		}
+		if sc.dryRun {
+			fmt.Fprintf(sc.out, "🧪 Would trim %s\n", mp)
+			continue
+		}
+		trimmed, err := trimFilesystem(mp)
+		if err != nil {
+			sc.logger.Printf("Error trimming %s: %v", mp, err)
+			fmt.Fprintf(sc.msg, "⚠️  Could not trim %s: %v\n", mp, err)
+			continue
+		}
+		sc.logger.Printf("Trimmed %s: %d bytes", mp, trimmed)
+		fmt.Fprintf(sc.out, "✂️  Trimmed %s of %s\n", sc.FormatSize(trimmed), mp)
+	}
+}
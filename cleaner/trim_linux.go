@@ -0,0 +1,58 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// discardSupported reads the discard limit of the block device holding
+// mount from sysfs. known is false when there is no such device, e.g. for
+// btrfs subvolumes or network filesystems; fstrim then has the final say.
+func discardSupported(mount string) (supported, known bool) {
+	var st unix.Stat_t
+	if err := unix.Stat(mount, &st); err != nil {
+		return false, false
+	}
+	dev := fmt.Sprintf("/sys/dev/block/%d:%d", unix.Major(st.Dev), unix.Minor(st.Dev))
+	data, err := os.ReadFile(filepath.Join(dev, "queue", "discard_max_bytes"))
+	if err != nil {
+		// a partition has no queue of its own, its disk does
+		data, err = os.ReadFile(filepath.Join(dev, "..", "queue", "discard_max_bytes"))
+	}
+	if err != nil {
+		return false, false
+	}
+	limit, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return false, false
+	}
+	return limit > 0, true
+}
+
+// fstrimBytes finds the byte count in fstrim -v output such as
+// "/: 1.2 GiB (1288490188 bytes) trimmed"
+var fstrimBytes = regexp.MustCompile(`\((\d+) bytes\) trimmed`)
+
+// trimFilesystem runs fstrim on a mount point through sudo and returns how
+// many bytes it reports trimmed
+func trimFilesystem(mount string) (int64, error) {
+	out, err := exec.Command("sudo", "fstrim", "-v", mount).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return 0, fmt.Errorf("fstrim: %s", msg)
+		}
+		return 0, fmt.Errorf("fstrim: %w", err)
+	}
+	m := fstrimBytes.FindSubmatch(out)
+	if m == nil {
+		return 0, fmt.Errorf("unexpected fstrim output %q", strings.TrimSpace(string(out)))
+	}
+	return strconv.ParseInt(string(m[1]), 10, 64)
+}
@@ -0,0 +1,22 @@
+//go:build !linux
+
+package cleaner
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// discardSupported cannot be told outside Linux
+func discardSupported(mount string) (supported, known bool) {
+	return false, false
+}
+
+// trimFilesystem is only implemented on Linux. macOS has no on-demand trim:
+// it trims SSDs itself, at mount time, once TRIM is enabled.
+func trimFilesystem(mount string) (int64, error) {
+	if runtime.GOOS == "darwin" {
+		return 0, fmt.Errorf("%w; macOS trims SSDs itself when TRIM is enabled (see trimforce)", errTrimUnsupported)
+	}
+	return 0, errTrimUnsupported
+}
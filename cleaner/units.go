@@ -0,0 +1,106 @@
+package cleaner
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// unitSystems maps a unit system name to its divisor and labels
+var unitSystems = map[string]struct {
+	base   float64
+	labels []string
+}{
+	"iec": {1024, []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}},
+	"si":  {1000, []string{"B", "kB", "MB", "GB", "TB", "PB"}},
+}
+
+// SetUnits selects how sizes are printed: "iec" (1024-based, the default) or
+// "si" (1000-based). JSON output always uses raw bytes.
+func (sc *SystemCleaner) SetUnits(name string) error {
+	if _, ok := unitSystems[name]; !ok {
+		return fmt.Errorf("invalid units %q (want si or iec)", name)
+	}
+	sc.units = name
+	return nil
+}
+
+// fixedUnits maps the names SetUnit accepts to an index into the labels of
+// a unit system; auto picks the unit per value
+var fixedUnits = map[string]int{"auto": 0, "mb": 2, "gb": 3}
+
+// SetUnit selects the unit sizes are printed in: "auto" (the default) picks
+// one per value, "mb" or "gb" puts every size in MB or GB (MiB or GiB with iec
+// units) so rows can be compared and summed at a glance
+func (sc *SystemCleaner) SetUnit(name string) error {
+	unit, ok := fixedUnits[name]
+	if !ok {
+		return fmt.Errorf("invalid unit %q (want auto, mb or gb)", name)
+	}
+	sc.fixedUnit = unit
+	return nil
+}
+
+// FormatSize renders a byte count in the largest unit that keeps it above
+// one, or in the unit chosen with SetUnit
+func (sc *SystemCleaner) FormatSize(bytes int64) string {
+	system, ok := unitSystems[sc.units]
+	if !ok {
+		system = unitSystems["iec"]
+	}
+
+	value := float64(bytes)
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	unit := 0
+	if sc.fixedUnit > 0 {
+		unit = sc.fixedUnit
+		value /= math.Pow(system.base, float64(unit))
+	}
+	for sc.fixedUnit == 0 && value >= system.base && unit < len(system.labels)-1 {
+		value /= system.base
+		unit++
+	}
+	if unit == 0 {
+		return fmt.Sprintf("%s%d %s", sign, int64(value), system.labels[0])
+	}
+	return fmt.Sprintf("%s%.2f %s", sign, value, system.labels[unit])
+}
+
+// sizeUnits maps the unit suffixes parseSize accepts, lowercased, to bytes
+var sizeUnits = map[string]float64{
+	"": 1, "b": 1,
+	"k": 1 << 10, "kb": 1e3, "kib": 1 << 10,
+	"m": 1 << 20, "mb": 1e6, "mib": 1 << 20,
+	"g": 1 << 30, "gb": 1e9, "gib": 1 << 30,
+	"t": 1 << 40, "tb": 1e12, "tib": 1 << 40,
+}
+
+// parseSize reads a size such as "500MB", "1.5 GiB" or "1024". Like the si
+// and iec units, kB, MB, GB and TB are 1000-based and KiB, MiB, GiB and TiB
+// 1024-based; a bare K, M, G or T is 1024-based.
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	split := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if split < 0 {
+		split = len(s)
+	}
+	value, err := strconv.ParseFloat(s[:split], 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(s[split:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q (want a number with B, kB, MB, GB, TB, KiB, MiB, GiB or TiB)", s)
+	}
+	bytes := value * unit
+	if bytes > math.MaxInt64 {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return int64(bytes), nil
+}
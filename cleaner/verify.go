@@ -0,0 +1,36 @@
+package cleaner
+
+import (
+	"fmt"
+	"os"
+)
+
+// removeFile deletes one junk file. With verify_deletion it then stats the
+// path again, because some network filesystems report success for a remove
+// that did not happen; such files are counted and not reported as freed.
+func (sc *SystemCleaner) removeFile(r *fileRemover, path string) error {
+	if err := r.remove(path); err != nil {
+		return err
+	}
+	if !sc.config.VerifyDeletion {
+		return nil
+	}
+
+	_, err := os.Lstat(longPath(path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	sc.progress.stillExists()
+	if err != nil {
+		return fmt.Errorf("cannot verify deletion: %w", err)
+	}
+	return ErrStillExists
+}
+
+// reportNotDeleted warns about the files verify_deletion found still in place
+func (sc *SystemCleaner) reportNotDeleted() {
+	if n := sc.Progress().NotDeleted; n > 0 {
+		sc.logger.Printf("verify_deletion: %d files still existed after being removed", n)
+		fmt.Fprintf(sc.out, "⚠️  %d files still exist after deletion and were not counted as freed (see the log)\n", n)
+	}
+}
@@ -0,0 +1,63 @@
+package cleaner
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// fileKey identifies a file by device and inode
+type fileKey struct {
+	dev, ino uint64
+}
+
+// walkGuard protects one walk against broken or hostile trees: nesting
+// deeper than max_walk_depth, and directories reached twice, as through a
+// bind mount of a parent, which would otherwise be walked forever
+type walkGuard struct {
+	root     string
+	maxDepth int
+	seen     map[fileKey]bool
+}
+
+// newWalkGuard returns the guard of a walk of root
+func (sc *SystemCleaner) newWalkGuard(root string) *walkGuard {
+	return &walkGuard{root: root, maxDepth: sc.config.MaxWalkDepth, seen: make(map[fileKey]bool)}
+}
+
+// depth returns how many levels below the root of the walk p is
+func (g *walkGuard) depth(p string) int {
+	rel, err := filepath.Rel(g.root, p)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(rel, string(filepath.Separator)) + 1
+}
+
+// checkDir is called for every directory of the walk. It returns an error
+// wrapping ErrTooDeep to abort the walk, or filepath.SkipDir for a directory
+// that was walked before.
+func (sc *SystemCleaner) checkDir(g *walkGuard, p string, d fs.DirEntry) error {
+	if g.maxDepth > 0 {
+		if depth := g.depth(p); depth > g.maxDepth {
+			sc.logger.Printf("Walk of %s aborted at %s: %d levels deep, over max_walk_depth %d", g.root, p, depth, g.maxDepth)
+			return fmt.Errorf("%s: %w (%d)", p, ErrTooDeep, g.maxDepth)
+		}
+	}
+	info, err := d.Info()
+	if err != nil {
+		return nil
+	}
+	id, ok := fileID(info)
+	if !ok {
+		return nil
+	}
+	if g.seen[id] {
+		sc.logger.Printf("Skipping %s: the walk of %s reached this directory before, a filesystem loop or bind mount", p, g.root)
+		sc.emit(Event{Type: EventSkipped, Path: p, Reason: "directory already walked"})
+		return filepath.SkipDir
+	}
+	g.seen[id] = true
+	return nil
+}
@@ -0,0 +1,149 @@
+package cleaner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// PathGrowth is how fast one cleanup path grew between two watch samples
+type PathGrowth struct {
+	Path string  `json:"path"`
+	Size int64   `json:"size"` // bytes of every file in the path, size_mode applies
+	Rate float64 `json:"rate"` // bytes per minute; negative when the path shrank
+}
+
+// WatchSample is one cycle of Watch, fastest-growing path first
+type WatchSample struct {
+	Time  time.Time    `json:"time"`
+	Paths []PathGrowth `json:"paths"`
+}
+
+// pathTotal adds up the size of every file in a cleanup path, junk or not
+func (sc *SystemCleaner) pathTotal(path string) (int64, error) {
+	var total int64
+	err := runWithTimeout(sc.config.ScanTimeout, func(ctx context.Context) error {
+		return sc.walkContext(ctx, path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if p == path {
+					return err
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				total += sc.fileSize(info)
+			}
+			return nil
+		})
+	})
+	if errors.Is(err, ErrTimeout) {
+		// the abandoned walk may still be adding to total
+		return 0, err
+	}
+	return total, err
+}
+
+// Watch measures every cleanup path each interval and reports the top paths
+// by growth rate since the previous sample (0 lists every growing path).
+// Shrinking paths are counted but not ranked. Rankings are logged too. It
+// runs until ctx is done or Stop is called and never deletes anything.
+func (sc *SystemCleaner) Watch(ctx context.Context, interval time.Duration, top int) error {
+	if interval <= 0 {
+		return fmt.Errorf("watch interval must be positive, got %s", interval)
+	}
+	fmt.Fprintf(sc.msg, "\n👀 Watching %d cleanup paths every %s (Press Ctrl+C to stop)\n", len(sc.config.CleanupPaths), interval)
+
+	sizes := make(map[string]int64)
+	sampled := make(map[string]time.Time)
+	measure := func() []PathGrowth {
+		var growth []PathGrowth
+		for _, cp := range sc.config.CleanupPaths {
+			size, err := sc.pathTotal(cp.Path)
+			if errors.Is(err, ErrInterrupted) {
+				return nil
+			}
+			if err != nil {
+				sc.logger.Printf("Error measuring %s: %v", cp.Path, err)
+				continue
+			}
+			now := time.Now()
+			if last, ok := sampled[cp.Path]; ok {
+				minutes := now.Sub(last).Minutes()
+				growth = append(growth, PathGrowth{Path: cp.Path, Size: size, Rate: float64(size-sizes[cp.Path]) / minutes})
+			}
+			sizes[cp.Path], sampled[cp.Path] = size, now
+		}
+		return growth
+	}
+	measure()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	encoder := json.NewEncoder(sc.out)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-sc.stopChan:
+			return nil
+		case <-ticker.C:
+		}
+
+		sample := WatchSample{Time: time.Now(), Paths: measure()}
+		sort.SliceStable(sample.Paths, func(i, j int) bool { return sample.Paths[i].Rate > sample.Paths[j].Rate })
+		for _, g := range sample.Paths {
+			sc.logger.Printf("Growth of %s: %s/min (now %s)", g.Path, sc.formatRate(g.Rate), sc.FormatSize(g.Size))
+		}
+		if sc.jsonOutput {
+			if err := encoder.Encode(sample); err != nil {
+				sc.logger.Printf("Error writing watch sample: %v", err)
+			}
+			continue
+		}
+		sc.printGrowth(sample, top)
+	}
+}
+
+// formatRate formats a signed byte rate
+func (sc *SystemCleaner) formatRate(rate float64) string {
+	if rate < 0 {
+		return "-" + sc.FormatSize(int64(-rate))
+	}
+	return "+" + sc.FormatSize(int64(rate))
+}
+
+// printGrowth prints the ranking of one watch cycle
+func (sc *SystemCleaner) printGrowth(sample WatchSample, top int) {
+	var growing, shrinking int
+	for _, g := range sample.Paths {
+		switch {
+		case g.Rate > 0:
+			growing++
+		case g.Rate < 0:
+			shrinking++
+		}
+	}
+
+	fmt.Fprintf(sc.out, "\n📈 Fastest-growing paths at %s:\n", sample.Time.Format("15:04:05"))
+	if growing == 0 {
+		fmt.Fprintln(sc.out, "   nothing grew")
+	}
+	for i, g := range sample.Paths[:growing] {
+		if top > 0 && i >= top {
+			fmt.Fprintf(sc.out, "   … and %d more\n", growing-top)
+			break
+		}
+		fmt.Fprintf(sc.out, "%2d. %s  %s/min (now %s)\n", i+1, g.Path, sc.formatRate(g.Rate), sc.FormatSize(g.Size))
+	}
+	if shrinking > 0 {
+		fastest := sample.Paths[len(sample.Paths)-1]
+		fmt.Fprintf(sc.out, "📉 Shrinking: %d, fastest %s at %s/min\n", shrinking, fastest.Path, sc.formatRate(fastest.Rate))
+	}
+}
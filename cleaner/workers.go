@@ -0,0 +1,131 @@
+package cleaner
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// ioProfile holds the default worker counts for a kind of storage
+type ioProfile struct {
+	scanWorkers   int
+	cleanWorkers  int
+	deleteWorkers int
+}
+
+// ioProfiles maps profile names to their defaults. Spinning disks get a single
+// worker to avoid seek storms; SSDs handle one worker per CPU comfortably.
+var ioProfiles = map[string]ioProfile{
+	"hdd": {scanWorkers: 1, cleanWorkers: 1, deleteWorkers: 1},
+	"ssd": {scanWorkers: runtime.NumCPU(), cleanWorkers: runtime.NumCPU(), deleteWorkers: 4},
+}
+
+// validIOProfile reports an error for unknown profile names
+func validIOProfile(name string) error {
+	if _, ok := ioProfiles[name]; !ok {
+		return fmt.Errorf("invalid io_profile %q (want hdd or ssd)", name)
+	}
+	return nil
+}
+
+// SetIOProfile switches the storage profile used for default worker counts;
+// explicit scan_workers and clean_workers settings still take precedence
+func (c *Config) SetIOProfile(name string) error {
+	if err := validIOProfile(name); err != nil {
+		return err
+	}
+	c.IOProfile = name
+	return nil
+}
+
+// scanWorkers returns how many paths may be scanned at once
+func (c *Config) scanWorkers() int {
+	if c.ScanWorkers > 0 {
+		return c.ScanWorkers
+	}
+	return ioProfiles[c.IOProfile].scanWorkers
+}
+
+// cleanWorkers returns how many paths may be cleaned at once
+func (c *Config) cleanWorkers() int {
+	if c.CleanWorkers > 0 {
+		return c.CleanWorkers
+	}
+	return ioProfiles[c.IOProfile].cleanWorkers
+}
+
+// deleteWorkers returns how many files of one path may be deleted at once
+func (c *Config) deleteWorkers() int {
+	if c.DeleteWorkers > 0 {
+		return c.DeleteWorkers
+	}
+	return ioProfiles[c.IOProfile].deleteWorkers
+}
+
+// hashWorkers returns how many files dedupe may hash at once; like scanning,
+// hashing is bound by reads, so it follows scan_workers by default
+func (c *Config) hashWorkers() int {
+	if c.HashWorkers > 0 {
+		return c.HashWorkers
+	}
+	return c.scanWorkers()
+}
+
+// networkWorkers returns how many network paths may be cleaned at once; a NAS
+// is shared, so this defaults to one whatever the io_profile
+func (c *Config) networkWorkers() int {
+	if c.NetworkWorkers > 0 {
+		return c.NetworkWorkers
+	}
+	return 1
+}
+
+// runParallel calls fn for every index in [0, n) using at most workers goroutines
+func runParallel(n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// pathSizes measures every cleanup path using the configured scan workers.
+// Failed paths are logged and reported with a size of zero.
+func (sc *SystemCleaner) pathSizes() []int64 {
+	sizes := make([]int64, len(sc.config.CleanupPaths))
+	for i, usage := range sc.pathUsage() {
+		sizes[i] = usage.size
+	}
+	return sizes
+}
+
+// pathUsage is pathSizes with the number of junk files of every path
+func (sc *SystemCleaner) pathUsage() []dirUsage {
+	usages := make([]dirUsage, len(sc.config.CleanupPaths))
+	runParallel(len(usages), sc.config.scanWorkers(), func(i int) {
+		cp := sc.config.CleanupPaths[i]
+		usage, err := sc.getDirUsage(cp)
+		if err != nil {
+			sc.logger.Printf("Error scanning directory %s: %v", cp.Path, err)
+			return
+		}
+		usages[i] = usage
+	})
+	return usages
+}
@@ -0,0 +1,56 @@
+package cleaner
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunParallel(t *testing.T) {
+	tests := []struct {
+		name       string
+		n, workers int
+	}{
+		{"nothing to do", 0, 4},
+		{"zero workers run serially", 10, 0},
+		{"one worker", 10, 1},
+		{"more workers than jobs", 3, 8},
+		{"more jobs than workers", 100, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := make(map[int]int)
+			var running, peak atomic.Int32
+			runParallel(tt.n, tt.workers, func(i int) {
+				now := running.Add(1)
+				for {
+					old := peak.Load()
+					if now <= old || peak.CompareAndSwap(old, now) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+
+				mu.Lock()
+				defer mu.Unlock()
+				calls[i]++
+			})
+
+			if len(calls) != tt.n {
+				t.Errorf("ran %d jobs, want %d", len(calls), tt.n)
+			}
+			for i, n := range calls {
+				if i < 0 || i >= tt.n || n != 1 {
+					t.Errorf("job %d ran %d times, want 1", i, n)
+				}
+			}
+			limit := max(tt.workers, 1)
+			if int(peak.Load()) > limit {
+				t.Errorf("%d jobs ran at once, want at most %d", peak.Load(), limit)
+			}
+		})
+	}
+}
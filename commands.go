@@ -0,0 +1,322 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"cleanmac/cleaner"
+)
+
+// runCommand dispatches a subcommand and returns the process exit code
+func runCommand(sc *cleaner.SystemCleaner, args []string) int {
+	switch args[0] {
+	case "scan":
+		return runScan(sc, args[1:])
+	case "clean":
+		return runClean(sc, args[1:])
+	case "scan-oldest":
+		return runScanOldest(sc, args[1:])
+	case "check-config":
+		return runCheckConfig(sc)
+	case "remote-usage":
+		return runRemoteUsage(sc)
+	case "suggest":
+		return runSuggest(sc, args[1:])
+	case "watch":
+		return runWatch(sc, args[1:])
+	case "history":
+		return runHistory(sc, args[1:])
+	case "setup":
+		return runSetup(sc, args[1:])
+	case "compare-configs":
+		return runCompareConfigs(sc, args[1:])
+	default:
+		fmt.Fprintf(stderr, "❌ Unknown command %q\n", args[0])
+		return 2
+	}
+}
+
+// runClean implements "clean [-jobs name,...] [-one-filesystem] [path]": the
+// configured jobs, or just the top-level cleanup paths when there are none,
+// or only the cleanup paths within path
+func runClean(sc *cleaner.SystemCleaner, args []string) int {
+	fs := flag.NewFlagSet("clean", flag.ExitOnError)
+	jobList := fs.String("jobs", "", "comma-separated jobs to run (default: all)")
+	oneFS := fs.Bool("one-filesystem", false, "do not descend into mounts of other filesystems, like find -xdev")
+	fs.Parse(args)
+
+	var dir string
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+		fs.Parse(fs.Args()[1:]) // flags may also follow the path
+	}
+	if fs.NArg() > 0 || (dir != "" && *jobList != "") {
+		fmt.Fprintln(stderr, "usage: cleanpc clean [-jobs name,...] [-one-filesystem] [path]")
+		return 2
+	}
+	sc.SetOneFilesystem(*oneFS)
+
+	if dir != "" {
+		if !promptUser(fmt.Sprintf("Do you want to clean junk files within %s?", dir)) {
+			return 0
+		}
+		if err := trackClean(sc, "clean_within", func() error { return sc.CleanWithin(dir) }); err != nil {
+			sc.Logger().Printf("Error cleaning %s: %v", dir, err)
+			fmt.Fprintln(stderr, "❌", err)
+			return 1
+		}
+		return 0
+	}
+
+	jobs := sc.Config().JobNames()
+	if *jobList != "" {
+		known := make(map[string]bool)
+		for _, name := range jobs {
+			known[name] = true
+		}
+		jobs = strings.Split(*jobList, ",")
+		for _, name := range jobs {
+			if !known[name] {
+				fmt.Fprintf(stderr, "❌ Unknown job %q (configured: %s)\n", name, strings.Join(sc.Config().JobNames(), ", "))
+				return 2
+			}
+		}
+	}
+	if len(jobs) == 0 {
+		if !promptUser("Do you want to clean junk files?") {
+			return 0
+		}
+		if err := trackClean(sc, "clean_junk", sc.CleanJunk); err != nil {
+			sc.Logger().Printf("Error cleaning junk: %v", err)
+			fmt.Fprintln(stderr, "❌", err)
+			return 1
+		}
+		return 0
+	}
+	if !promptUser(fmt.Sprintf("Run cleanup jobs %s?", strings.Join(jobs, ", "))) {
+		return 0
+	}
+
+	type jobResult struct {
+		name     string
+		progress cleaner.Progress
+		err      error
+	}
+	var results []jobResult
+	interrupted := false
+	for _, name := range jobs {
+		err := trackClean(sc, "clean_job_"+name, func() error { return sc.CleanJob(name) })
+		if err != nil {
+			sc.Logger().Printf("Error cleaning job %s: %v", name, err)
+			fmt.Fprintf(stderr, "❌ Job %s: %v\n", name, err)
+		}
+		results = append(results, jobResult{name, sc.Progress(), err})
+		if interrupted = errors.Is(err, cleaner.ErrInterrupted); interrupted {
+			break
+		}
+	}
+	recipeErr := false
+	if !interrupted && len(sc.Config().Recipes) > 0 {
+		// jobs leave the top-level recipes out, so they run once here
+		if err := track("recipes", sc.RunRecipes); err != nil {
+			sc.Logger().Printf("Error running recipes: %v", err)
+			fmt.Fprintln(stderr, "❌", err)
+			recipeErr = true
+		}
+	}
+
+	var files, failed int
+	var bytes int64
+	fmt.Fprintln(stderr, "\n📊 Jobs:")
+	for _, r := range results {
+		status := "✅"
+		if r.err != nil {
+			status = "❌"
+			failed++
+		}
+		files += r.progress.FilesDeleted
+		bytes += r.progress.BytesFreed
+		fmt.Fprintf(stderr, "   %s %s: %d files, %s\n", status, r.name, r.progress.FilesDeleted, sc.FormatSize(r.progress.BytesFreed))
+	}
+	fmt.Fprintf(stderr, "   Total: %d files, %s freed by %d jobs\n", files, sc.FormatSize(bytes), len(results))
+	if failed > 0 || recipeErr {
+		return 1
+	}
+	return 0
+}
+
+// runScan implements "scan [-save file] [-compare file] <dir>"
+func runScan(sc *cleaner.SystemCleaner, args []string) int {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	save := fs.String("save", "", "save the scan of every file to this JSON file")
+	compare := fs.String("compare", "", "compare against a scan saved with -save")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: cleanpc scan [-save file] [-compare file] <dir>")
+		return 2
+	}
+	dir := fs.Arg(0)
+
+	// the scan that is reported is also the one saved, so dir is walked once
+	var err error
+	if *compare != "" {
+		err = track("compare_scan", func() error { return sc.CompareScan(dir, *compare, *save) })
+	} else {
+		err = track("scan_large_files", func() error { return sc.ScanAndSave(dir, *save) })
+	}
+	if err != nil {
+		sc.Logger().Printf("Error scanning %s: %v", dir, err)
+		fmt.Fprintln(stderr, "❌", err)
+		return 1
+	}
+	return 0
+}
+
+// runScanOldest implements "scan-oldest [-n count] [-sort age|size|path] <dir>"
+func runScanOldest(sc *cleaner.SystemCleaner, args []string) int {
+	fs := flag.NewFlagSet("scan-oldest", flag.ExitOnError)
+	count := fs.Int("n", sc.Config().TopFiles, "number of files to list (0 lists every file)")
+	order := fs.String("sort", "age", "list order: age, size or path")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: cleanpc scan-oldest [-n count] [-sort age|size|path] <dir>")
+		return 2
+	}
+	dir := fs.Arg(0)
+
+	if err := track("scan_oldest_files", func() error { return sc.ScanOldestFiles(dir, *count, *order) }); err != nil {
+		sc.Logger().Printf("Error scanning %s: %v", dir, err)
+		fmt.Fprintln(stderr, "❌", err)
+		return 1
+	}
+	return 0
+}
+
+// runCheckConfig implements "check-config", a pre-flight check of every cleanup path
+func runCheckConfig(sc *cleaner.SystemCleaner) int {
+	if err := track("check_config", sc.CheckConfig); err != nil {
+		fmt.Fprintln(stderr, "❌", err)
+		return 1
+	}
+	return 0
+}
+
+// runRemoteUsage implements "remote-usage", the junk usage of every remote host
+func runRemoteUsage(sc *cleaner.SystemCleaner) int {
+	if err := track("remote_usage", sc.ShowRemoteJunkUsage); err != nil {
+		fmt.Fprintln(stderr, "❌", err)
+		return 1
+	}
+	return 0
+}
+
+// runSuggest implements "suggest [-write]", a cleanup_paths snippet of known
+// junk locations, or those locations added to the config file
+func runSuggest(sc *cleaner.SystemCleaner, args []string) int {
+	fs := flag.NewFlagSet("suggest", flag.ExitOnError)
+	write := fs.Bool("write", false, "add the suggested paths to the -config file instead of printing them")
+	fs.Parse(args)
+
+	configPath := ""
+	if *write {
+		configPath = flag.Lookup("config").Value.String()
+	}
+	if err := track("suggest", func() error { return sc.SuggestInto(configPath) }); err != nil {
+		fmt.Fprintln(stderr, "❌", err)
+		return 1
+	}
+	return 0
+}
+
+// runWatch implements "watch [-interval d] [-top n]", a periodic ranking of
+// the cleanup paths by how fast they grow
+func runWatch(sc *cleaner.SystemCleaner, args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := fs.Duration("interval", time.Minute, "time between two size samples")
+	top := fs.Int("top", 5, "number of fastest-growing paths to list (0 lists all)")
+	fs.Parse(args)
+
+	err := track("watch", func() error { return sc.Watch(context.Background(), *interval, *top) })
+	if err != nil {
+		fmt.Fprintln(stderr, "❌", err)
+		return 1
+	}
+	return 0
+}
+
+// runHistory implements "history [-days n]", the cleans recorded in history_db
+func runHistory(sc *cleaner.SystemCleaner, args []string) int {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	days := fs.Int("days", 30, "number of days to report")
+	fs.Parse(args)
+
+	if err := track("history", func() error { return sc.ShowHistory(*days) }); err != nil {
+		fmt.Fprintln(stderr, "❌", err)
+		return 1
+	}
+	return 0
+}
+
+// runSetup implements "setup [-categories list] [-min-age-days n]
+// [-min-size size] [-downloads-days n] [-force]", the first-run wizard writing
+// the -config file. Options given as flags are not asked; with -yes nothing
+// is asked and the rest take their defaults.
+func runSetup(sc *cleaner.SystemCleaner, args []string) int {
+	fs := flag.NewFlagSet("setup", flag.ExitOnError)
+	categories := fs.String("categories", "", "comma-separated kinds of junk: browser, cache, temp, crash, trash, dev, downloads")
+	minAge := fs.Int("min-age-days", 7, "treat files as junk once unmodified for this many days")
+	minSize := fs.String("min-size", "0", "leave files smaller than this alone, e.g. 4KB")
+	downloadsDays := fs.Int("downloads-days", 30, "clean downloads older than this many days")
+	force := fs.Bool("force", false, "replace an existing config file without asking")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fmt.Fprintln(stderr, "usage: cleanpc setup [-categories list] [-min-age-days n] [-min-size size] [-downloads-days n] [-force]")
+		return 2
+	}
+
+	opts := cleaner.SetupOptions{Force: *force}
+	if *categories != "" {
+		opts.Categories = strings.Split(*categories, ",")
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "min-age-days":
+			opts.MinAgeDays = minAge
+		case "min-size":
+			opts.MinSize = *minSize
+		case "downloads-days":
+			opts.DownloadsDays = downloadsDays
+		}
+	})
+
+	configPath := flag.Lookup("config").Value.String()
+	if err := track("setup", func() error { return sc.Setup(configPath, opts) }); err != nil {
+		fmt.Fprintln(stderr, "❌", err)
+		return 1
+	}
+	return 0
+}
+
+// runCompareConfigs implements "compare-configs <a.yaml> <b.yaml>", the junk
+// each config would clean, side by side
+func runCompareConfigs(sc *cleaner.SystemCleaner, args []string) int {
+	if len(args) != 2 {
+		fmt.Fprintln(stderr, "usage: cleanpc compare-configs <a.yaml> <b.yaml>")
+		return 2
+	}
+	err := track("compare_configs", func() error {
+		_, err := sc.CompareConfigs(args[0], args[1])
+		return err
+	})
+	if err != nil {
+		fmt.Fprintln(stderr, "❌", err)
+		return 1
+	}
+	return 0
+}
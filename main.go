@@ -3,292 +3,265 @@ package main
 import (
 	"bufio"
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"os/exec"
 	"os/signal"
-	"path/filepath"
-	"runtime"
-	"sort"
 	"strings"
-	"sync"
 	"syscall"
 	"time"
+	"unicode/utf8"
 
-	"github.com/shirou/gopsutil/cpu"
-	"github.com/shirou/gopsutil/mem"
-	"gopkg.in/yaml.v2"
+	"cleanmac/cleaner"
 )
 
-// Config holds the application configuration
-type Config struct {
-	CleanupPaths []string `yaml:"cleanup_paths"`
-	MaxFileSize  int64    `yaml:"max_file_size"` // in bytes
-	TopFiles     int      `yaml:"top_files"`
-	LogFile      string   `yaml:"log_file"`
-}
+// version is reported in the banner and in run summaries
+const version = "1.0.0"
 
-// SystemCleaner handles the cleaning operations
-type SystemCleaner struct {
-	config     *Config
-	logger     *log.Logger
-	stopChan   chan struct{}
-	operations *sync.WaitGroup
-}
+// summary collects operation results when -summary-json is set
+var summary *cleaner.RunSummary
 
-// FileInfo represents information about a file
-type FileInfo struct {
-	Path string
-	Size int64
-}
-
-// NewSystemCleaner creates a new instance of SystemCleaner
-func NewSystemCleaner(configPath string) (*SystemCleaner, error) {
-	config, err := loadConfig(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load config: %w", err)
+// track runs an operation and records its outcome in the run summary
+func track(name string, op func() error) error {
+	start := time.Now()
+	err := op()
+	if summary != nil {
+		summary.Record(name, start, err, nil)
 	}
-
-	logFile, err := os.OpenFile(config.LogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
-	}
-
-	logger := log.New(logFile, "", log.LstdFlags)
-
-	return &SystemCleaner{
-		config:     config,
-		logger:     logger,
-		stopChan:   make(chan struct{}),
-		operations: &sync.WaitGroup{},
-	}, nil
+	return err
 }
 
-// loadConfig loads the configuration from a YAML file
-func loadConfig(path string) (*Config, error) {
-	file, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	config := &Config{}
-	if err := yaml.Unmarshal(file, config); err != nil {
-		return nil, err
+// trackClean is track for operations that delete files; it also records the
+// number of files deleted and bytes freed
+func trackClean(sc *cleaner.SystemCleaner, name string, op func() error) error {
+	start := time.Now()
+	err := op()
+	if summary != nil {
+		progress := sc.Progress()
+		summary.Record(name, start, err, &progress)
 	}
-
-	return config, nil
+	return err
 }
 
-// startLoading shows a loading animation
-func (sc *SystemCleaner) startLoading(message string) chan bool {
-	stop := make(chan bool)
-	sc.operations.Add(1)
-
-	go func() {
-		frames := []string{"⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
-		i := 0
-		for {
-			select {
-			case <-stop:
-				fmt.Printf("\r✅ %s\n", message)
-				sc.operations.Done()
-				return
-			case <-sc.stopChan:
-				fmt.Printf("\r❌ %s (interrupted)\n", message)
-				sc.operations.Done()
-				return
-			default:
-				fmt.Printf("\r%s %s", frames[i%len(frames)], message)
-				i++
-				time.Sleep(100 * time.Millisecond)
-			}
-		}
-	}()
+// stringList is a flag that may be repeated
+type stringList []string
 
-	return stop
-}
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
 
-// getDirSize calculates the total size of a directory
-func (sc *SystemCleaner) getDirSize(path string) (int64, error) {
-	var size int64
-	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			size += info.Size()
-		}
-		return nil
-	})
-	return size, err
-}
+// stderr receives prompts and messages; -ascii wraps it
+var stderr io.Writer = os.Stderr
 
-// ShowJunkUsage displays information about junk files
-func (sc *SystemCleaner) ShowJunkUsage() error {
-	fmt.Println("\n🔍 Scanning junk files...")
-	var totalSize int64
+// prompter asks every question of the run, so buffered input is not lost
+// between them; it is set up once -ascii is known
+var prompter cleaner.Prompter
 
-	fmt.Println("clean paths")
-	fmt.Println(sc.config.CleanupPaths)
+// assumeYes answers every prompt with yes (set by -yes)
+var assumeYes bool
 
-	for _, dir := range sc.config.CleanupPaths {
-		size, err := sc.getDirSize(dir)
-		if err != nil {
-			sc.logger.Printf("Error scanning directory %s: %v", dir, err)
-			continue
-		}
-		totalSize += size
-		fmt.Printf("📂 %s → %d MB\n", dir, size/1024/1024)
+// promptUser asks for user confirmation
+func promptUser(message string) bool {
+	if assumeYes {
+		fmt.Fprint(stderr, "\n⚠️  "+message+" (yes/no): ")
+		fmt.Fprintln(stderr, "yes")
+		return true
 	}
+	return prompter.Confirm(message)
+}
 
-	if totalSize == 0 {
-		fmt.Println("\n✅ No junk files found! Your system is clean.")
-		return nil
+// printBanner prints the startup banner, or the configured replacement
+func printBanner(custom *string) {
+	if custom == nil {
+		fmt.Fprintf(stderr, "🚀 System Cleaner Pro - v%s 🚀\n", version)
+		fmt.Fprintln(stderr, "=================================")
+		return
 	}
-
-	fmt.Printf("\n🚨 Total Junk Size: %d MB 🚨\n", totalSize/1024/1024)
-	return nil
+	if *custom == "" {
+		return
+	}
+	fmt.Fprintln(stderr, *custom)
+	fmt.Fprintln(stderr, strings.Repeat("=", utf8.RuneCountInString(*custom)))
 }
 
-// CleanJunk removes junk files
-func (sc *SystemCleaner) CleanJunk() error {
-	fmt.Println("\n🗑️  Deleting junk files...")
-
-	fmt.Println("clean paths")
-	fmt.Println(sc.config.CleanupPaths)
-
-	for _, dir := range sc.config.CleanupPaths {
-		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				sc.logger.Printf("Error accessing path %s: %v", path, err)
-				return nil
-			}
-			if !info.IsDir() {
-				if err := os.Remove(path); err != nil {
-					sc.logger.Printf("Error removing file %s: %v", path, err)
-				}
-			}
-			return nil
-		})
-		if err != nil {
-			return fmt.Errorf("error cleaning directory %s: %w", dir, err)
+// printProgress summarizes a clean that was cut short
+func printProgress(sc *cleaner.SystemCleaner, p cleaner.Progress) {
+	if !p.Running {
+		return
+	}
+	fmt.Fprintf(stderr, "📊 Done so far: %d files deleted, %s freed\n", p.FilesDeleted, sc.FormatSize(p.BytesFreed))
+	if p.NotDeleted > 0 {
+		fmt.Fprintf(stderr, "⚠️  %d files still exist after deletion\n", p.NotDeleted)
+	}
+	if total := len(p.PathsDone) + len(p.PathsPending); total > 0 {
+		fmt.Fprintf(stderr, "📂 Paths completed: %d of %d\n", len(p.PathsDone), total)
+		for _, path := range p.PathsPending {
+			fmt.Fprintln(stderr, "   ⏳", path)
 		}
 	}
-
-	fmt.Println("✅ Junk files cleaned successfully!")
-	return nil
 }
 
-// OptimizeMemory performs memory optimization based on the OS
-func (sc *SystemCleaner) OptimizeMemory() error {
-	fmt.Println("\n🚀 Optimizing Memory...")
+func main() {
+	os.Exit(run())
+}
 
-	var cmd *exec.Cmd
-	switch runtime.GOOS {
-	case "darwin":
-		cmd = exec.Command("sudo", "purge")
-	case "linux":
-		cmd = exec.Command("sudo", "sysctl", "-w", "vm.drop_caches=3")
-	default:
-		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+// run is the body of main; it returns the exit code so deferred cleanup runs
+func run() int {
+	configPath := flag.String("config", "config.yaml", "path to the configuration file; - reads it from stdin, \"\" uses none")
+	freeTarget := flag.Float64("free-target", 0, "clean until the disk has this percent free (0 disables)")
+	freeDisk := flag.String("free-disk", "/", "disk (mount point) checked by -free-target")
+	scanLinks := flag.String("scan-links", "", "report symbolic links (and broken ones) in this directory and exit")
+	ioProfile := flag.String("io-profile", "", "storage profile for default worker counts: hdd or ssd")
+	deleteWorkers := flag.Int("delete-workers", 0, "files of one cleanup path deleted at once (0 = io profile default, 1 = serial)")
+	analyze := flag.String("analyze", "", "report the file-type breakdown of this directory and exit")
+	compressible := flag.Bool("compressible", false, "sample large junk files, report the ones worth compressing instead of deleting and exit")
+	knownJunk := flag.Bool("clean-known-junk", false, "find core dumps, crash reports, .DS_Store, Thumbs.db and editor backups in the cleanup paths, delete them with -allow-delete and exit")
+	jsonOutput := flag.Bool("json", false, "print reports as JSON (applies to -analyze, -compressible, -clean-known-junk, junk usage, scan and the system monitor)")
+	perPath := flag.Bool("interactive-per-path", false, "ask before cleaning each cleanup path")
+	flag.BoolVar(&assumeYes, "yes", false, "answer yes to every prompt")
+	units := flag.String("units", "iec", "size units: iec (KiB, MiB, GiB) or si (kB, MB, GB)")
+	unit := flag.String("unit", "auto", "unit of printed sizes: auto picks one per value, mb or gb uses it for every size")
+	dryRun := flag.Bool("dry-run", false, "report what would be deleted or optimized without doing it")
+	allowDelete := flag.Bool("allow-delete", false, "let the interactive flow and -free-target delete files (the clean subcommand always may)")
+	var skipPaths stringList
+	flag.Var(&skipPaths, "skip", "relative path pattern to skip, e.g. '**/node_modules/**' (repeatable)")
+	var recipes stringList
+	flag.Var(&recipes, "recipe", "built-in cleanup to enable: apt, dnf, journal, snap, docker or browser (repeatable)")
+	chart := flag.Bool("chart", false, "show junk usage as a bar chart scaled to the terminal width")
+	inodes := flag.Bool("inodes", false, "also report junk file counts and the free inodes of each cleanup path's filesystem")
+	skipEmpty := flag.Bool("skip-empty", false, "leave zero-byte files out of junk usage and cleans, since they free no space")
+	onlyEmpty := flag.Bool("only-empty", false, "treat only zero-byte files as junk, for a quick tidy of lock and marker files")
+	force := flag.Bool("force", false, "clean cleanup paths even when their junk exceeds max_path_size")
+	trim := flag.Bool("trim", false, "after a clean, trim the SSD filesystems it deleted from, like fstrim (Linux, needs sudo)")
+	sinceBoot := flag.Bool("since-boot", false, "only treat files modified since the last boot as junk, e.g. after a crashed session")
+	noBanner := flag.Bool("no-banner", false, "do not print the startup banner")
+	tmpl := flag.String("template", "", "render reports with a Go template: csv, markdown, a template file or template text")
+	review := flag.Bool("review", false, "list the files a clean would delete and let you deselect some before committing")
+	ascii := flag.Bool("ascii", !cleaner.UnicodeTerminal(), "replace emoji with plain-text labels (default on when the locale is not UTF-8)")
+	maxDuration := flag.Duration("max-duration", 0, "stop deleting once this much time has passed, e.g. 5m, and report what was done")
+	maxFiles := flag.Int("max-files", 0, "stop large-file and oldest-file scans after examining this many entries (0 = no cap)")
+	explainConfig := flag.Bool("explain-config", false, "print the effective configuration as YAML and exit")
+	gpu := flag.Bool("gpu", false, "show NVIDIA GPU utilization and memory in the system monitor")
+	summaryPath := flag.String("summary-json", "", "write a JSON summary of the whole run to this file")
+	scriptOut := flag.String("script-out", "", "delete nothing; write a shell script of rm commands for everything a clean would delete to this file")
+	flag.Parse()
+
+	// Load configuration; setup writes the config file instead of reading it,
+	// and compare-configs reads the ones it is given
+	loadPath := *configPath
+	if flag.Arg(0) == "setup" || flag.Arg(0) == "compare-configs" {
+		loadPath = ""
 	}
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("memory optimization failed: %w", err)
+	sc, err := cleaner.NewSystemCleaner(loadPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize system cleaner: %v", err)
 	}
-
-	fmt.Println("✅ Memory optimization complete!")
-	return nil
-}
-
-// SystemMonitor provides real-time system monitoring
-func (sc *SystemCleaner) SystemMonitor(ctx context.Context) {
-	fmt.Println("\n📊 Live System Monitor (Press Ctrl+C to exit)")
-
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			v, err := mem.VirtualMemory()
-			if err != nil {
-				sc.logger.Printf("Error getting memory info: %v", err)
-				continue
-			}
-
-			cpuPercent, err := cpu.Percent(time.Second, false)
-			if err != nil {
-				sc.logger.Printf("Error getting CPU info: %v", err)
-				continue
+	defer sc.Close()
+	defer func() {
+		if errs := sc.ErrorSummary(); errs != "" {
+			fmt.Fprintf(stderr, "⚠️  Errors: %s; see %s for details\n", errs, sc.LogPath())
+		}
+	}()
+	if *summaryPath != "" {
+		summary = cleaner.NewRunSummary(version, sc.Config())
+		defer func() {
+			if err := summary.Write(*summaryPath); err != nil {
+				fmt.Fprintln(stderr, "❌ Failed to write run summary:", err)
 			}
-
-			fmt.Printf("\r🖥️ CPU Usage: %.2f%%  🏋️ RAM Usage: %.2f%%  (%.2f GB used of %.2f GB)  ",
-				cpuPercent[0], v.UsedPercent, float64(v.Used)/1e9, float64(v.Total)/1e9)
+		}()
+	}
+	sc.Config().SkipPaths = append(sc.Config().SkipPaths, skipPaths...)
+	if err := sc.Config().EnableRecipes(recipes...); err != nil {
+		log.Fatalf("Invalid -recipe: %v", err)
+	}
+	if *ioProfile != "" {
+		if err := sc.Config().SetIOProfile(*ioProfile); err != nil {
+			log.Fatalf("Invalid -io-profile: %v", err)
 		}
 	}
-}
-
-// ScanLargeFiles finds and reports large files in a directory
-func (sc *SystemCleaner) ScanLargeFiles(directory string) error {
-	fmt.Println("\n🔎 Scanning for large files in:", directory)
-
-	stop := sc.startLoading("Analyzing files...")
+	if *deleteWorkers > 0 {
+		sc.Config().DeleteWorkers = *deleteWorkers
+	}
+	if *explainConfig {
+		if err := sc.ExplainConfig(); err != nil {
+			fmt.Fprintln(stderr, "❌ Failed to print the configuration:", err)
+			return 1
+		}
+		return 0
+	}
 
-	var files []FileInfo
-	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+	if *ascii {
+		stderr = cleaner.ASCIIWriter(os.Stderr)
+	}
+	sc.SetASCII(*ascii)
+	sc.SetJSONOutput(*jsonOutput)
+	sc.SetDryRun(*dryRun)
+	sc.SetChart(*chart)
+	sc.SetInodes(*inodes)
+	sc.SetGPUMonitor(*gpu)
+	sc.SetMaxDuration(*maxDuration)
+	sc.SetMaxFiles(*maxFiles)
+	sc.SetTrim(*trim)
+	sc.SetForce(*force)
+	if err := sc.SetUnits(*units); err != nil {
+		log.Fatalf("Invalid -units: %v", err)
+	}
+	if err := sc.SetUnit(*unit); err != nil {
+		log.Fatalf("Invalid -unit: %v", err)
+	}
+	switch {
+	case *skipEmpty && *onlyEmpty:
+		log.Fatal("-skip-empty and -only-empty cannot be used together")
+	case *skipEmpty:
+		sc.SetEmptyFiles("skip")
+	case *onlyEmpty:
+		sc.SetEmptyFiles("only")
+	}
+	if *sinceBoot {
+		boot, err := sc.SetSinceBoot()
 		if err != nil {
-			sc.logger.Printf("Error accessing path %s: %v", path, err)
-			return nil
+			log.Fatalf("Invalid -since-boot: %v", err)
 		}
-		if !info.IsDir() && info.Size() > sc.config.MaxFileSize {
-			files = append(files, FileInfo{Path: path, Size: info.Size()})
-		}
-		return nil
-	})
-
-	stop <- true
-	<-stop
-
-	if err != nil {
-		return fmt.Errorf("error scanning directory: %w", err)
+		fmt.Fprintf(stderr, "🔌 Only junk modified since boot at %s\n", boot.Format("2006-01-02 15:04:05"))
 	}
-
-	sort.Slice(files, func(i, j int) bool {
-		return files[i].Size > files[j].Size
-	})
-
-	fmt.Printf("\n📂 Top %d largest files:\n", sc.config.TopFiles)
-	for i, file := range files {
-		if i >= sc.config.TopFiles {
-			break
+	if *scriptOut != "" {
+		file, err := os.OpenFile(*scriptOut, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o700)
+		if err != nil {
+			log.Fatalf("Invalid -script-out: %v", err)
 		}
-		fmt.Printf("📄 %s → %.2f GB\n", file.Path, float64(file.Size)/1e9)
+		script := bufio.NewWriter(file)
+		sc.SetScriptOut(script)
+		defer func() {
+			err := script.Flush()
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				fmt.Fprintln(stderr, "❌ Failed to write the deletion script:", err)
+				return
+			}
+			fmt.Fprintf(stderr, "📜 Wrote %d rm commands to %s; review it, then run it with sh\n", sc.ScriptFiles(), *scriptOut)
+		}()
 	}
-
-	return nil
-}
-
-// promptUser asks for user confirmation
-func promptUser(message string) bool {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("\n⚠️  " + message + " (yes/no): ")
-	input, _ := reader.ReadString('\n')
-	return strings.ToLower(strings.TrimSpace(input)) == "yes"
-}
-
-func main() {
-	// Load configuration
-	cleaner, err := NewSystemCleaner("config.yaml")
-	if err != nil {
-		log.Fatalf("Failed to initialize system cleaner: %v", err)
+	if err := sc.SetTemplate(*tmpl); err != nil {
+		log.Fatalf("Invalid -template: %v", err)
+	}
+	prompter = cleaner.NewStdPrompter(os.Stdin, stderr)
+	if assumeYes {
+		// nobody is there to answer the extra confirmations
+		sc.SetPrompter(nil)
+	} else {
+		sc.SetPrompter(prompter)
+	}
+	if *review {
+		sc.SetReview(func(files []cleaner.FileInfo) []cleaner.FileInfo { return reviewFiles(sc, files) })
+	}
+	if *perPath && !assumeYes {
+		sc.SetPathConfirm(func(path string, size int64) bool {
+			return promptUser(fmt.Sprintf("Clean %s (%s)?", path, sc.FormatSize(size)))
+		})
 	}
-
-	fmt.Println("🚀 System Cleaner Pro - v1.0.0 🚀")
-	fmt.Println("=================================")
 
 	// Set up context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
@@ -299,45 +272,154 @@ func main() {
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-sigChan
-		fmt.Println("\n⚠️  Received interrupt signal. Cleaning up...")
-		close(cleaner.stopChan)
+		fmt.Fprintln(stderr, "\n⚠️  Received interrupt signal. Cleaning up...")
+		sc.Stop()
 		cancel()
+		printProgress(sc, sc.Progress())
+		sc.FlushLog()
 	}()
 
+	// File-type analysis is a read-only report
+	if *analyze != "" {
+		if err := track("analyze", func() error { return sc.AnalyzeTypes(*analyze) }); err != nil {
+			fmt.Fprintf(stderr, "❌ Failed to analyze %s: %v\n", *analyze, err)
+			return 1
+		}
+		return 0
+	}
+
+	// The compressibility report reads file contents, so it only runs on request
+	if *compressible {
+		if err := track("compressible", sc.ScanCompressible); err != nil {
+			fmt.Fprintln(stderr, "❌ Failed to sample junk files:", err)
+			return 1
+		}
+		return 0
+	}
+
+	// Known junk is reported wherever it is, and only deleted on request
+	if *knownJunk {
+		var report *cleaner.KnownJunkReport
+		err := track("scan_known_junk", func() (err error) {
+			report, err = sc.ScanKnownJunk()
+			return err
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, "❌ Failed to look for known junk:", err)
+			return 1
+		}
+		if len(report.Files) == 0 {
+			return 0
+		}
+		if !*allowDelete && *scriptOut == "" {
+			fmt.Fprintln(stderr, "\n🔒 Safe mode: nothing will be deleted. Use -allow-delete to delete these files.")
+			return 0
+		}
+		if promptUser(fmt.Sprintf("Delete these %d files?", len(report.Files))) {
+			if err := trackClean(sc, "clean_known_junk", func() error { return sc.CleanKnownJunk(report) }); err != nil {
+				sc.Logger().Printf("Error cleaning known junk: %v", err)
+				fmt.Fprintln(stderr, "❌", err)
+				return 1
+			}
+		}
+		return 0
+	}
+
+	// Subcommands replace the interactive flow
+	if args := flag.Args(); len(args) > 0 {
+		return runCommand(sc, args)
+	}
+
+	if !*noBanner && !*jsonOutput {
+		printBanner(sc.Config().Banner)
+	}
+
+	// Link scan mode replaces the interactive flow
+	if *scanLinks != "" {
+		if err := track("scan_links", func() error { return sc.ScanLinks(*scanLinks) }); err != nil {
+			sc.Logger().Printf("Error scanning links: %v", err)
+			fmt.Fprintln(stderr, "❌", err)
+			return 1
+		}
+		return 0
+	}
+
+	// Free-space target mode replaces the interactive flow
+	if *freeTarget > 0 {
+		if !*allowDelete && *scriptOut == "" {
+			fmt.Fprintln(stderr, "🔒 -free-target deletes files; re-run with -allow-delete to use it")
+			return 2
+		}
+		question := fmt.Sprintf("Delete files from the cleanup paths until %s is %.1f%% free?", *freeDisk, *freeTarget)
+		var enough bool
+		err := track("estimate_free_target", func() (err error) {
+			enough, err = sc.EstimateFreeTarget(*freeDisk, *freeTarget)
+			return err
+		})
+		if err != nil {
+			fmt.Fprintln(stderr, "❌ Failed to estimate free-space target:", err)
+			return 1
+		}
+		if !enough {
+			question = "The target cannot be reached by cleaning alone. Clean anyway?"
+		}
+		if promptUser(question) {
+			err := trackClean(sc, "clean_to_free_target", func() error { return sc.CleanToFreeTarget(*freeDisk, *freeTarget) })
+			if err != nil {
+				sc.Logger().Printf("Error cleaning to free-space target: %v", err)
+				fmt.Fprintln(stderr, "❌", err)
+				return 1
+			}
+		}
+		return 0
+	}
+
 	// Show junk usage
-	if err := cleaner.ShowJunkUsage(); err != nil {
-		cleaner.logger.Printf("Error showing junk usage: %v", err)
+	if err := track("show_junk_usage", sc.ShowJunkUsage); err != nil {
+		sc.Logger().Printf("Error showing junk usage: %v", err)
 	}
 
-	// Clean junk files if confirmed
-	if promptUser("Do you want to clean junk files?") {
-		if err := cleaner.CleanJunk(); err != nil {
-			cleaner.logger.Printf("Error cleaning junk: %v", err)
+	// Clean junk files if confirmed; without -allow-delete the flow is read-only,
+	// apart from writing the -script-out script
+	if !*allowDelete && *scriptOut == "" {
+		fmt.Fprintln(stderr, "\n🔒 Safe mode: nothing will be deleted. Use -allow-delete or the clean subcommand to clean junk files.")
+	} else if promptUser("Do you want to clean junk files?") {
+		if err := trackClean(sc, "clean_junk", sc.CleanJunk); err != nil {
+			sc.Logger().Printf("Error cleaning junk: %v", err)
+			fmt.Fprintln(stderr, "❌", err)
 		}
 	}
 
 	// Scan for large files if confirmed
 	if promptUser("Do you want to scan for large files?") {
-		fmt.Print("📂 Enter directory to scan: ")
-		reader := bufio.NewReader(os.Stdin)
-		dir, _ := reader.ReadString('\n')
-		dir = strings.TrimSpace(dir)
+		dir, _ := prompter.Ask("📂 Enter directory to scan: ")
 
-		if err := cleaner.ScanLargeFiles(dir); err != nil {
-			cleaner.logger.Printf("Error scanning large files: %v", err)
+		if err := track("scan_large_files", func() error { return sc.ScanLargeFiles(dir) }); err != nil {
+			sc.Logger().Printf("Error scanning large files: %v", err)
 		}
 	}
 
-	// Start system monitoring
-	go cleaner.SystemMonitor(ctx)
+	// Start system monitoring. On a terminal it takes keys and runs in the
+	// foreground once memory is optimized, so it does not read keys while sudo
+	// may be asking for a password.
+	interactiveMonitor := sc.SetMonitorKeys(os.Stdin)
+	if !interactiveMonitor {
+		go sc.SystemMonitor(ctx)
+	}
 
 	// Optimize memory
-	if err := cleaner.OptimizeMemory(); err != nil {
-		cleaner.logger.Printf("Error optimizing memory: %v", err)
+	if err := track("optimize_memory", sc.OptimizeMemory); err != nil {
+		sc.Logger().Printf("Error optimizing memory: %v", err)
+		fmt.Fprintln(stderr, "❌", err)
+	}
+
+	if interactiveMonitor {
+		sc.SystemMonitor(ctx)
 	}
 
 	// Wait for all operations to complete
-	cleaner.operations.Wait()
+	sc.Wait()
 
-	fmt.Println("\n👋 Thank you for using System Cleaner Pro!")
+	fmt.Fprintln(stderr, "\n👋 Thank you for using System Cleaner Pro!")
+	return 0
 }
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"cleanmac/cleaner"
+)
+
+// reviewPageSize is the number of files listed per page by -review
+const reviewPageSize = 20
+
+// reviewFiles lets the user toggle files, or whole directories, off the list
+// a clean would delete and returns what is still selected. End of input or
+// "q" returns nothing, so no file is deleted without an explicit commit.
+func reviewFiles(sc *cleaner.SystemCleaner, files []cleaner.FileInfo) []cleaner.FileInfo {
+	if len(files) == 0 {
+		return nil
+	}
+
+	selected := make([]bool, len(files))
+	for i := range selected {
+		selected[i] = true
+	}
+	pages := (len(files) + reviewPageSize - 1) / reviewPageSize
+	page := 0
+
+	for {
+		var count int
+		var size int64
+		for i, f := range files {
+			if selected[i] {
+				count++
+				size += f.Size
+			}
+		}
+		fmt.Fprintf(stderr, "\n📋 %d of %d files selected (%s) - page %d/%d\n", count, len(files), sc.FormatSize(size), page+1, pages)
+		for i := page * reviewPageSize; i < len(files) && i < (page+1)*reviewPageSize; i++ {
+			mark := " "
+			if selected[i] {
+				mark = "x"
+			}
+			fmt.Fprintf(stderr, "  [%s] %4d  %s (%s)\n", mark, i+1, files[i].Path, sc.FormatSize(files[i].Size))
+		}
+		input, ok := prompter.Ask("Toggle: 3 | 3-7 | d 3 (directory of 3) | n/p page | a all | none | c commit | q quit: ")
+		input = strings.ToLower(input)
+		if !ok {
+			fmt.Fprintln(stderr)
+			return nil
+		}
+
+		switch {
+		case input == "c":
+			var kept []cleaner.FileInfo
+			for i, f := range files {
+				if selected[i] {
+					kept = append(kept, f)
+				}
+			}
+			return kept
+		case input == "q":
+			return nil
+		case input == "n":
+			if page < pages-1 {
+				page++
+			}
+		case input == "p":
+			if page > 0 {
+				page--
+			}
+		case input == "a", input == "none":
+			for i := range selected {
+				selected[i] = input == "a"
+			}
+		case strings.HasPrefix(input, "d "):
+			n, err := strconv.Atoi(strings.TrimSpace(input[2:]))
+			if err != nil || n < 1 || n > len(files) {
+				fmt.Fprintln(stderr, "❌ No such file:", input[2:])
+				continue
+			}
+			toggleDir(files, selected, filepath.Dir(files[n-1].Path))
+		default:
+			first, last, ok := parseRange(input, len(files))
+			if !ok {
+				fmt.Fprintln(stderr, "❌ Unknown command:", input)
+				continue
+			}
+			for i := first; i <= last; i++ {
+				selected[i-1] = !selected[i-1]
+			}
+		}
+	}
+}
+
+// toggleDir deselects every file under dir, or selects them all again when
+// none of them is selected
+func toggleDir(files []cleaner.FileInfo, selected []bool, dir string) {
+	prefix := dir + string(filepath.Separator)
+	var inDir []int
+	on := false
+	for i, f := range files {
+		if strings.HasPrefix(f.Path, prefix) {
+			inDir = append(inDir, i)
+			on = on || selected[i]
+		}
+	}
+	for _, i := range inDir {
+		selected[i] = !on
+	}
+	fmt.Fprintf(stderr, "📁 %s: %d files %s\n", dir, len(inDir), map[bool]string{true: "deselected", false: "selected"}[on])
+}
+
+// parseRange parses "N" or "N-M" as 1-based file numbers up to max
+func parseRange(input string, max int) (int, int, bool) {
+	from, to, found := strings.Cut(input, "-")
+	first, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil {
+		return 0, 0, false
+	}
+	last := first
+	if found {
+		if last, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+			return 0, 0, false
+		}
+	}
+	if first < 1 || last > max || first > last {
+		return 0, 0, false
+	}
+	return first, last, true
+}